@@ -0,0 +1,607 @@
+// Command hclschema converts, formats, bundles, validates, lints and
+// compares JSON schemas (draft-07) written in JSON or HCL, migrates them to
+// draft 2020-12, and generates Go types, TypeScript declarations and
+// documentation from them.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/genelet/determined/dethcl"
+	"github.com/genelet/hclschema/codegen/golang"
+	"github.com/genelet/hclschema/codegen/typescript"
+	"github.com/genelet/hclschema/docgen"
+	"github.com/genelet/hclschema/jsm07"
+	"github.com/genelet/hclschema/jsm2020"
+	"github.com/genelet/hclschema/lint"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+)
+
+var usages = map[string]string{
+	"json2hcl": "json2hcl [-o output] [schema.json]",
+	"hcl2json": "hcl2json [-o output] [-strict] [schema.hcl]",
+	"validate": "validate -schema schema.(json|hcl) instance.(json|hcl) ...",
+	"fmt":      "fmt [-w] [-strict] schema.hcl ...",
+	"bundle":   "bundle [-o output] [-format json|hcl] schema.(json|hcl)",
+	"convert":  "convert -to 2020-12|07 [-o output] [-format json|hcl] schema.(json|hcl)",
+	"gogen":    "gogen [-o output] [-package name] [-type name] schema.(json|hcl)",
+	"tsgen":    "tsgen [-o output] [-type name] schema.(json|hcl)",
+	"docgen":   "docgen [-o output] [-format markdown|html] [-title title] schema.(json|hcl)",
+	"diff":     "diff [-breaking any|producers|consumers|none] old.(json|hcl) new.(json|hcl)",
+	"lint":     "lint [-rules rule,...] [-disable rule,...] [-strict] [-list] schema.(json|hcl) ...",
+}
+
+var commands = map[string]func(args []string) error{
+	"json2hcl": runJSON2HCL,
+	"hcl2json": runHCL2JSON,
+	"validate": runValidate,
+	"fmt":      runFmt,
+	"bundle":   runBundle,
+	"convert":  runConvert,
+	"gogen":    runGoGen,
+	"tsgen":    runTSGen,
+	"docgen":   runDocGen,
+	"diff":     runDiff,
+	"lint":     runLint,
+}
+
+var order = []string{"json2hcl", "hcl2json", "validate", "fmt", "bundle", "convert", "gogen", "tsgen", "docgen", "diff", "lint"}
+
+// errInvalid signals that validation failed after reporting the reasons.
+var errInvalid = fmt.Errorf("invalid")
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "usage: %s <command> [options] [arguments]\n\ncommands:\n", filepath.Base(os.Args[0]))
+	for _, name := range order {
+		fmt.Fprintf(os.Stderr, "  %s\n", usages[name])
+	}
+	fmt.Fprintf(os.Stderr, "\nFiles ending in .hcl are read as HCL, all others as JSON; \"-\" or no file reads standard input.\n")
+	os.Exit(2)
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage()
+	}
+	run, ok := commands[os.Args[1]]
+	if !ok {
+		usage()
+	}
+	if err := run(os.Args[2:]); err != nil {
+		if err != errInvalid {
+			fmt.Fprintf(os.Stderr, "error: %v\n", err)
+		}
+		os.Exit(1)
+	}
+}
+
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: %s %s\n", filepath.Base(os.Args[0]), usages[name])
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+func runJSON2HCL(args []string) error {
+	fs := newFlagSet("json2hcl")
+	output := fs.String("o", "", "write to `file` instead of standard output")
+	fs.Parse(args)
+
+	data, err := readInput(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	schema := new(jsm07.Schema)
+	if err := json.Unmarshal(data, schema); err != nil {
+		return err
+	}
+	warnSkipped(fs.Arg(0), schema)
+	bs, err := dethcl.Marshal(schema)
+	if err != nil {
+		return err
+	}
+	return writeOutput(*output, bs)
+}
+
+func runHCL2JSON(args []string) error {
+	fs := newFlagSet("hcl2json")
+	output := fs.String("o", "", "write to `file` instead of standard output")
+	strict := fs.Bool("strict", false, "treat unknown keywords as errors")
+	fs.Parse(args)
+
+	filename := fs.Arg(0)
+	data, err := readInput(filename)
+	if err != nil {
+		return err
+	}
+	schema, err := parseHCL(data, filename, *strict)
+	if err != nil {
+		return err
+	}
+	bs, err := json.MarshalIndent(schema, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeOutput(*output, append(bs, '\n'))
+}
+
+func runFmt(args []string) error {
+	fs := newFlagSet("fmt")
+	write := fs.Bool("w", false, "write the result back to the source file instead of standard output")
+	strict := fs.Bool("strict", false, "treat unknown keywords as errors")
+	fs.Parse(args)
+
+	filenames := fs.Args()
+	if len(filenames) == 0 {
+		if *write {
+			return fmt.Errorf("-w requires file names")
+		}
+		filenames = []string{"-"}
+	}
+
+	for _, filename := range filenames {
+		data, err := readInput(filename)
+		if err != nil {
+			return err
+		}
+		// the schema is only checked: formatting the source itself keeps
+		// comments, unknown blocks and values exactly as written
+		if _, err := parseHCL(data, filename, *strict); err != nil {
+			return err
+		}
+		bs := hclwrite.Format(data)
+		if *write {
+			err = os.WriteFile(filename, bs, 0o644)
+		} else {
+			_, err = os.Stdout.Write(bs)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func runValidate(args []string) error {
+	fs := newFlagSet("validate")
+	schemaFile := fs.String("schema", "", "the schema `file` to validate against")
+	fs.Parse(args)
+
+	if *schemaFile == "" {
+		fs.Usage()
+		os.Exit(2)
+	}
+	r, err := newResolver(*schemaFile)
+	if err != nil {
+		return err
+	}
+
+	filenames := fs.Args()
+	if len(filenames) == 0 {
+		filenames = []string{"-"}
+	}
+
+	invalid := false
+	for _, filename := range filenames {
+		data, err := readInput(filename)
+		if err != nil {
+			return err
+		}
+
+		if isHCL(filename) {
+			err = r.ValidateHCL(data)
+		} else {
+			var instance interface{}
+			if err := json.Unmarshal(data, &instance); err != nil {
+				return fmt.Errorf("%s: %w", filename, err)
+			}
+			err = r.Validate(instance)
+		}
+
+		if errs, ok := err.(jsm07.ValidationErrors); ok {
+			invalid = true
+			for _, e := range errs {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", filename, e)
+			}
+		} else if err != nil {
+			return fmt.Errorf("%s: %w", filename, err)
+		}
+	}
+
+	if invalid {
+		return errInvalid
+	}
+	return nil
+}
+
+func runDiff(args []string) error {
+	fs := newFlagSet("diff")
+	breaking := fs.String("breaking", "any", "fail on changes breaking `side`: any, producers, consumers or none")
+	fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	fails := map[string]func(c *jsm07.Change) bool{
+		"any":       (*jsm07.Change).Breaking,
+		"producers": func(c *jsm07.Change) bool { return c.BreaksProducers },
+		"consumers": func(c *jsm07.Change) bool { return c.BreaksConsumers },
+		"none":      func(c *jsm07.Change) bool { return false },
+	}[*breaking]
+	if fails == nil {
+		return fmt.Errorf("unknown -breaking %q", *breaking)
+	}
+
+	old, err := readSchema(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	newer, err := readSchema(fs.Arg(1))
+	if err != nil {
+		return err
+	}
+
+	failed := false
+	for _, c := range jsm07.Diff(old, newer) {
+		fmt.Println(c)
+		failed = failed || fails(c)
+	}
+	if failed {
+		return errInvalid
+	}
+	return nil
+}
+
+func runLint(args []string) error {
+	fs := newFlagSet("lint")
+	rules := fs.String("rules", "", "comma-separated `rules` to run; defaults to all")
+	disable := fs.String("disable", "", "comma-separated `rules` not to run")
+	strict := fs.Bool("strict", false, "treat unknown HCL attributes and blocks as errors")
+	list := fs.Bool("list", false, "list the rules and exit")
+	fs.Parse(args)
+
+	if *list {
+		for _, rule := range lint.Rules {
+			fmt.Printf("%-20s %s\n", rule.Name, rule.Description)
+		}
+		return nil
+	}
+	split := func(s string) []string {
+		if s == "" {
+			return nil
+		}
+		return strings.Split(s, ",")
+	}
+
+	filenames := fs.Args()
+	if len(filenames) == 0 {
+		filenames = []string{"-"}
+	}
+
+	found := false
+	for _, filename := range filenames {
+		opts := &lint.Options{Rules: split(*rules), Disable: split(*disable), Filename: filename, Strict: *strict}
+		data, err := readInput(filename)
+		if err != nil {
+			return err
+		}
+
+		if isHCL(filename) {
+			problems, diags := lint.LintHCL(data, opts)
+			for _, p := range problems {
+				diags = append(diags, p.Diagnostic())
+			}
+			if len(diags) > 0 {
+				wr := hcl.NewDiagnosticTextWriter(os.Stderr, map[string]*hcl.File{filename: {Bytes: data}}, 0, false)
+				wr.WriteDiagnostics(diags)
+			}
+			found = found || len(diags) > 0
+			continue
+		}
+
+		schema := new(jsm07.Schema)
+		if err := json.Unmarshal(data, schema); err != nil {
+			return fmt.Errorf("%s: %w", filename, err)
+		}
+		problems, err := lint.Lint(schema, opts)
+		if err != nil {
+			return err
+		}
+		for _, p := range problems {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", filename, p)
+		}
+		found = found || len(problems) > 0
+	}
+
+	if found {
+		return errInvalid
+	}
+	return nil
+}
+
+func runBundle(args []string) error {
+	fs := newFlagSet("bundle")
+	output := fs.String("o", "", "write to `file` instead of standard output")
+	format := fs.String("format", "json", "output `format`, json or hcl")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	r, err := newResolver(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	uri, err := fileURI(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	bundled, err := jsm07.Bundle(r.Root(), uri, jsm07.NewDefaultLoader())
+	if err != nil {
+		return err
+	}
+
+	var bs []byte
+	switch *format {
+	case "json":
+		bs, err = json.MarshalIndent(bundled, "", "  ")
+	case "hcl":
+		warnSkipped(fs.Arg(0), bundled)
+		bs, err = dethcl.Marshal(bundled)
+	default:
+		return fmt.Errorf("unknown format %q", *format)
+	}
+	if err != nil {
+		return err
+	}
+	return writeOutput(*output, append(bs, '\n'))
+}
+
+func runConvert(args []string) error {
+	fs := newFlagSet("convert")
+	output := fs.String("o", "", "write to `file` instead of standard output")
+	to := fs.String("to", "", "target `draft`, 2020-12 or 07")
+	format := fs.String("format", "", "output `format`, json or hcl; defaults to that of the input")
+	fs.Parse(args)
+
+	if fs.NArg() > 1 || (*to != "2020-12" && *to != "07") {
+		fs.Usage()
+		os.Exit(2)
+	}
+	filename := fs.Arg(0)
+	data, err := readInput(filename)
+	if err != nil {
+		return err
+	}
+	if *format == "" {
+		*format = "json"
+		if isHCL(filename) {
+			*format = "hcl"
+		}
+	}
+
+	var converted interface{}
+	var issues []*jsm2020.Incompatibility
+	if *to == "2020-12" {
+		var schema *jsm07.Schema
+		if isHCL(filename) {
+			schema, err = parseHCL(data, filename, false)
+		} else {
+			schema = new(jsm07.Schema)
+			err = json.Unmarshal(data, schema)
+		}
+		if err != nil {
+			return err
+		}
+		converted, issues = jsm2020.FromDraft07(schema)
+	} else {
+		var schema *jsm2020.Schema
+		if isHCL(filename) {
+			schema, err = jsm2020.ParseSchema(data)
+		} else {
+			schema = new(jsm2020.Schema)
+			err = json.Unmarshal(data, schema)
+		}
+		if err != nil {
+			return err
+		}
+		converted, issues = schema.ToDraft07()
+	}
+	for _, issue := range issues {
+		fmt.Fprintf(os.Stderr, "%s: %v\n", filename, issue)
+	}
+
+	var bs []byte
+	switch *format {
+	case "json":
+		bs, err = json.MarshalIndent(converted, "", "  ")
+	case "hcl":
+		if s, ok := converted.(*jsm07.Schema); ok {
+			warnSkipped(filename, s)
+		}
+		bs, err = dethcl.Marshal(converted)
+	default:
+		return fmt.Errorf("unknown format %q", *format)
+	}
+	if err != nil {
+		return err
+	}
+	return writeOutput(*output, append(bs, '\n'))
+}
+
+func runGoGen(args []string) error {
+	fs := newFlagSet("gogen")
+	output := fs.String("o", "", "write to `file` instead of standard output")
+	pkg := fs.String("package", "schema", "`name` of the generated package")
+	name := fs.String("type", "", "`name` of the root type; defaults to one derived from the title")
+	fs.Parse(args)
+
+	if fs.NArg() > 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	schema, err := readSchema(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	bs, err := golang.Generate(schema, &golang.Options{Package: *pkg, Name: *name})
+	if err != nil {
+		return err
+	}
+	return writeOutput(*output, bs)
+}
+
+func runTSGen(args []string) error {
+	fs := newFlagSet("tsgen")
+	output := fs.String("o", "", "write to `file` instead of standard output")
+	name := fs.String("type", "", "`name` of the root type; defaults to one derived from the title")
+	fs.Parse(args)
+
+	if fs.NArg() > 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	schema, err := readSchema(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	bs, err := typescript.Generate(schema, &typescript.Options{Name: *name})
+	if err != nil {
+		return err
+	}
+	return writeOutput(*output, bs)
+}
+
+func runDocGen(args []string) error {
+	fs := newFlagSet("docgen")
+	output := fs.String("o", "", "write to `file` instead of standard output")
+	format := fs.String("format", "markdown", "output `format`, markdown or html")
+	title := fs.String("title", "", "`title` of the document; defaults to the title of the schema")
+	fs.Parse(args)
+
+	if fs.NArg() > 1 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	generate := docgen.Markdown
+	switch *format {
+	case "markdown", "md":
+	case "html":
+		generate = docgen.HTML
+	default:
+		return fmt.Errorf("unknown format %q", *format)
+	}
+	schema, err := readSchema(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	bs, err := generate(schema, &docgen.Options{Title: *title})
+	if err != nil {
+		return err
+	}
+	return writeOutput(*output, bs)
+}
+
+// warnSkipped prints the extensions that HCL output leaves out.
+func warnSkipped(filename string, schema *jsm07.Schema) {
+	if filename == "" {
+		filename = "-"
+	}
+	for _, ptr := range jsm07.SkippedExtensions(schema) {
+		fmt.Fprintf(os.Stderr, "%s: warning: extension %s is not an HCL attribute name, skipped\n", filename, ptr)
+	}
+}
+
+// readSchema reads a draft-07 schema in JSON or HCL, printing any HCL
+// warnings.
+func readSchema(filename string) (*jsm07.Schema, error) {
+	data, err := readInput(filename)
+	if err != nil {
+		return nil, err
+	}
+	if isHCL(filename) {
+		return parseHCL(data, filename, false)
+	}
+	schema := new(jsm07.Schema)
+	if err := json.Unmarshal(data, schema); err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+// newResolver loads the schema in filename, printing any HCL warnings,
+// with references resolved against its location.
+func newResolver(filename string) (*jsm07.Resolver, error) {
+	schema, err := readSchema(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	uri, err := fileURI(filename)
+	if err != nil {
+		return nil, err
+	}
+	return jsm07.NewResolver(schema, uri, jsm07.NewDefaultLoader())
+}
+
+// parseHCL parses an HCL schema, printing warnings; errors are printed
+// with their source ranges and returned.
+func parseHCL(data []byte, filename string, strict bool) (*jsm07.Schema, error) {
+	schema, diags := jsm07.ParseSchemaFile(data, &jsm07.ParseOptions{Filename: filename, Strict: strict})
+	if len(diags) > 0 {
+		file := &hcl.File{Bytes: data}
+		wr := hcl.NewDiagnosticTextWriter(os.Stderr, map[string]*hcl.File{filename: file}, 0, false)
+		wr.WriteDiagnostics(diags)
+	}
+	if diags.HasErrors() {
+		return nil, errInvalid
+	}
+	return schema, nil
+}
+
+func isHCL(filename string) bool {
+	return strings.EqualFold(filepath.Ext(filename), ".hcl")
+}
+
+func fileURI(filename string) (string, error) {
+	if filename == "" || filename == "-" {
+		dir, err := os.Getwd()
+		if err != nil {
+			return "", err
+		}
+		filename = filepath.Join(dir, "stdin")
+	}
+	abs, err := filepath.Abs(filename)
+	if err != nil {
+		return "", err
+	}
+	u := &url.URL{Scheme: "file", Path: filepath.ToSlash(abs)}
+	return u.String(), nil
+}
+
+func readInput(filename string) ([]byte, error) {
+	if filename == "" || filename == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(filename)
+}
+
+func writeOutput(filename string, data []byte) error {
+	if filename == "" || filename == "-" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	return os.WriteFile(filename, data, 0o644)
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/genelet/hclschema/jsm07"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestJSON2HCL2JSON(t *testing.T) {
+	dir := t.TempDir()
+	quoted := filepath.Join(dir, "quoted.json")
+	if err := os.WriteFile(quoted, []byte(`{
+		"description": "line one\nline two \\ \"quoted\"\t${x} %{y}",
+		"enum": ["a\nb", "${z}"],
+		"properties": {"a": {"type": "object", "default": {"b": [1, "%{if}"]}}}
+	}`), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+	numbers := filepath.Join(dir, "numbers.json")
+	if err := os.WriteFile(numbers, []byte(`{
+		"enum": [80, 443, -1, 0.5, 1e300, null, true],
+		"multipleOf": 1e-7,
+		"maximum": 9007199254740993,
+		"const": 123456789.123456789,
+		"default": [1, null, {"a": null}],
+		"examples": [1e-9, -2.5],
+		"properties": {"port": {"type": "integer", "enum": [80, 443], "default": 80}}
+	}`), 0644); err != nil {
+		t.Fatalf("Failed to write file: %v", err)
+	}
+
+	samples, err := filepath.Glob("../../jsm07/samples/*.json")
+	if err != nil {
+		t.Fatalf("Failed to list samples: %v", err)
+	}
+	for _, filename := range append(samples, quoted, numbers) {
+		hclFile := filepath.Join(dir, "schema.hcl")
+		jsonFile := filepath.Join(dir, "schema.json")
+		if err := commands["json2hcl"]([]string{"-o", hclFile, filename}); err != nil {
+			t.Fatalf("Failed to run json2hcl on %s: %v", filename, err)
+		}
+		if err := commands["hcl2json"]([]string{"-o", jsonFile, hclFile}); err != nil {
+			t.Fatalf("Failed to run hcl2json on %s: %v", filename, err)
+		}
+
+		// compare with the sample as encoded by jsm07
+		expected, err := encoded(filename)
+		if err != nil {
+			t.Fatalf("Failed to encode %s: %v", filename, err)
+		}
+		got, err := os.ReadFile(jsonFile)
+		if err != nil {
+			t.Fatalf("Failed to read file: %v", err)
+		}
+		var x, y interface{}
+		if err := json.Unmarshal(expected, &x); err != nil {
+			t.Fatalf("Failed to unmarshal: %v", err)
+		}
+		if err := json.Unmarshal(got, &y); err != nil {
+			t.Fatalf("Failed to unmarshal: %v", err)
+		}
+		if diff := cmp.Diff(x, y); diff != "" {
+			t.Errorf("%s: json2hcl | hcl2json mismatch (-want +got):\n%s", filename, diff)
+		}
+	}
+}
+
+func encoded(filename string) ([]byte, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+	schema := new(jsm07.Schema)
+	if err := json.Unmarshal(data, schema); err != nil {
+		return nil, err
+	}
+	return json.Marshal(schema)
+}
+
+func TestFmt(t *testing.T) {
+	dir := t.TempDir()
+	samples, err := filepath.Glob("../../jsm07/samples/*.json")
+	if err != nil {
+		t.Fatalf("Failed to list samples: %v", err)
+	}
+	for _, filename := range samples {
+		hclFile := filepath.Join(dir, "schema.hcl")
+		if err := commands["json2hcl"]([]string{"-o", hclFile, filename}); err != nil {
+			t.Fatalf("Failed to run json2hcl on %s: %v", filename, err)
+		}
+		bs, err := os.ReadFile(hclFile)
+		if err != nil {
+			t.Fatalf("Failed to read file: %v", err)
+		}
+		bs = append([]byte("# generated from "+filepath.Base(filename)+"\n"), bs...)
+		bs = append(bs, []byte(`
+properties "port" {
+    type =   "integer"
+  enum = [80, 443, -1, 0.5] # well-known ports
+}
+`)...)
+		if err := os.WriteFile(hclFile, bs, 0644); err != nil {
+			t.Fatalf("Failed to write file: %v", err)
+		}
+
+		if err := commands["fmt"]([]string{"-w", hclFile}); err != nil {
+			t.Fatalf("Failed to run fmt on %s: %v", filename, err)
+		}
+		got, err := os.ReadFile(hclFile)
+		if err != nil {
+			t.Fatalf("Failed to read file: %v", err)
+		}
+		for _, expected := range []string{"# generated from ", "# well-known ports", "  type = \"integer\"\n"} {
+			if !strings.Contains(string(got), expected) {
+				t.Errorf("%s: expected %q in formatted file", filename, expected)
+			}
+		}
+
+		schema, err := jsm07.ParseSchema(got)
+		if err != nil {
+			t.Fatalf("Failed to parse formatted %s: %v", filename, err)
+		}
+		enum, err := json.Marshal(schema.Properties["port"].Schema.Enumeration)
+		if err != nil {
+			t.Fatalf("Failed to marshal enum: %v", err)
+		}
+		if string(enum) != `[80,443,-1,0.5]` {
+			t.Errorf("%s: expected enum [80,443,-1,0.5], got %s", filename, enum)
+		}
+	}
+}
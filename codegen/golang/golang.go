@@ -0,0 +1,656 @@
+// Package golang generates Go type declarations with json and hcl struct
+// tags from a draft-07 schema.
+//
+// Definitions become named types, required properties become fields
+// without pointers, enumerations become typed constants, and oneOf and
+// anyOf become wrapper structs holding one pointer per alternative, in the
+// style of jsm07.Combined.
+package golang
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"net/url"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/genelet/hclschema/jsm07"
+)
+
+// Options controls the generated code.
+type Options struct {
+	// Package is the name of the generated package; the default is
+	// "schema".
+	Package string
+	// Name is the name of the type generated for the root schema; the
+	// default is derived from its title, or else "Root". A root schema
+	// holding only definitions generates no type.
+	Name string
+}
+
+// Generate returns the formatted Go source declaring the types of the
+// schema and its definitions.
+func Generate(schema *jsm07.Schema, opts *Options) ([]byte, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	pkg := opts.Package
+	if pkg == "" {
+		pkg = "schema"
+	}
+
+	g := &generator{
+		root:      schema,
+		names:     make(map[string]bool),
+		defs:      make(map[string]string),
+		resolving: make(map[string]bool),
+	}
+
+	if hasType(schema) {
+		name := opts.Name
+		if name == "" && schema.Title != nil {
+			name = goName(*schema.Title)
+		}
+		if name == "" {
+			name = "Root"
+		}
+		g.rootName = g.newName(name)
+	}
+	keys := schema.OrderedKeys("definitions")
+	for _, k := range keys {
+		g.defs[k] = g.newName(goName(k))
+	}
+
+	if g.rootName != "" {
+		if err := g.declare(g.rootName, jsm07.NewCombinedWithSchema(schema)); err != nil {
+			return nil, err
+		}
+	}
+	for _, k := range keys {
+		if err := g.declare(g.defs[k], schema.Definitions[k]); err != nil {
+			return nil, fmt.Errorf("definition %q: %w", k, err)
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by hclschema. DO NOT EDIT.\n\n")
+	fmt.Fprintf(&buf, "package %s\n\n", pkg)
+	if g.unions {
+		buf.WriteString("import (\n\t\"bytes\"\n\t\"encoding/json\"\n\t\"fmt\"\n\n\t\"github.com/genelet/determined/dethcl\"\n)\n\n")
+	}
+	for _, decl := range g.decls {
+		buf.WriteString(decl)
+		buf.WriteString("\n")
+	}
+	if g.unions {
+		buf.WriteString(strictHelper)
+	}
+
+	bs, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("invalid generated code: %w", err)
+	}
+	return bs, nil
+}
+
+// strictHelper decodes JSON rejecting unknown fields, so that a wrapper
+// type prefers the alternative describing all of them.
+const strictHelper = `func unmarshalStrict(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode(v)
+}
+`
+
+type generator struct {
+	root      *jsm07.Schema
+	rootName  string
+	names     map[string]bool   // type and constant names in use
+	defs      map[string]string // definition key to type name
+	resolving map[string]bool   // references being followed
+	decls     []string
+	unions    bool
+}
+
+func (self *generator) newName(base string) string {
+	name := base
+	for i := 2; self.names[name]; i++ {
+		name = base + strconv.Itoa(i)
+	}
+	self.names[name] = true
+	return name
+}
+
+// declare adds the declaration of the named type for c.
+func (self *generator) declare(name string, c *jsm07.Combined) error {
+	if c == nil || c.Schema == nil {
+		self.decls = append(self.decls, fmt.Sprintf("type %s interface{}\n", name))
+		return nil
+	}
+	s := c.Schema
+
+	switch {
+	case s.Ref == nil && len(s.Enumeration) > 0 && enumBase(s.Enumeration) != "":
+		self.enumDecl(name, s)
+		return nil
+	case s.Ref == nil && len(s.OneOf) > 0:
+		return self.unionDecl(name, s, s.OneOf)
+	case s.Ref == nil && len(s.AnyOf) > 0:
+		return self.unionDecl(name, s, s.AnyOf)
+	case s.Ref == nil && isStruct(s):
+		return self.structDecl(name, s)
+	default:
+	}
+
+	// reserve the place of the declaration before nested types are added
+	i := len(self.decls)
+	self.decls = append(self.decls, "")
+	typ, err := self.goType(c, name)
+	if err != nil {
+		return err
+	}
+	self.decls[i] = comment(s.Description) + fmt.Sprintf("type %s %s\n", name, typ)
+	return nil
+}
+
+// goType returns the Go type of c, declaring named types after hint for
+// the structs, enumerations and wrappers it needs.
+func (self *generator) goType(c *jsm07.Combined, hint string) (string, error) {
+	if c == nil || c.Schema == nil {
+		return "interface{}", nil
+	}
+	s := c.Schema
+
+	if s.Ref != nil {
+		ref := *s.Ref
+		if name := self.refName(ref); name != "" {
+			return name, nil
+		}
+		if self.resolving[ref] {
+			return "interface{}", nil
+		}
+		target, err := self.root.Resolve(ref)
+		if err != nil {
+			return "", err
+		}
+		self.resolving[ref] = true
+		defer delete(self.resolving, ref)
+		return self.goType(target, hint)
+	}
+
+	if len(s.Enumeration) > 0 && enumBase(s.Enumeration) != "" {
+		name := self.newName(hint)
+		self.enumDecl(name, s)
+		return name, nil
+	}
+	if len(s.OneOf) > 0 || len(s.AnyOf) > 0 {
+		alts := s.OneOf
+		if len(alts) == 0 {
+			alts = s.AnyOf
+		}
+		name := self.newName(hint)
+		return name, self.unionDecl(name, s, alts)
+	}
+	if len(s.AllOf) == 1 && s.Type == nil && s.Properties == nil {
+		return self.goType(s.AllOf[0], hint)
+	}
+
+	switch schemaType(s) {
+	case "string":
+		return "string", nil
+	case "integer":
+		return "int64", nil
+	case "number":
+		return "float64", nil
+	case "boolean":
+		return "bool", nil
+	case "array":
+		if s.Items == nil || s.Items.Combined == nil {
+			return "[]interface{}", nil
+		}
+		elem, err := self.elemType(s.Items.Combined, hint+"Item")
+		if err != nil {
+			return "", err
+		}
+		return "[]" + elem, nil
+	case "object":
+		if isStruct(s) {
+			name := self.newName(hint)
+			return name, self.structDecl(name, s)
+		}
+		if s.AdditionalProperties == nil || s.AdditionalProperties.Schema == nil {
+			return "map[string]interface{}", nil
+		}
+		elem, err := self.elemType(s.AdditionalProperties, hint+"Value")
+		if err != nil {
+			return "", err
+		}
+		return "map[string]" + elem, nil
+	default:
+	}
+	return "interface{}", nil
+}
+
+// elemType returns the type of the elements of a slice or map, which are
+// pointers for structs.
+func (self *generator) elemType(c *jsm07.Combined, hint string) (string, error) {
+	typ, err := self.goType(c, hint)
+	if err != nil {
+		return "", err
+	}
+	if self.isBlock(c, false) {
+		typ = "*" + typ
+	}
+	return typ, nil
+}
+
+// refName returns the type name of a reference to the root or to a
+// definition, or else an empty string.
+func (self *generator) refName(ref string) string {
+	if ref == "#" {
+		return self.rootName
+	}
+	if !strings.HasPrefix(ref, "#/definitions/") {
+		return ""
+	}
+	key, err := url.PathUnescape(strings.TrimPrefix(ref, "#/definitions/"))
+	if err != nil || strings.Contains(key, "/") {
+		return ""
+	}
+	key = strings.ReplaceAll(strings.ReplaceAll(key, "~1", "/"), "~0", "~")
+	return self.defs[key]
+}
+
+// isBlock tells if c generates a struct, which is written as an HCL
+// block, or if deep, also a slice or map of structs.
+func (self *generator) isBlock(c *jsm07.Combined, deep bool) bool {
+	seen := make(map[string]bool)
+	for c != nil && c.Schema != nil {
+		s := c.Schema
+		if s.Ref != nil {
+			if seen[*s.Ref] {
+				return false
+			}
+			seen[*s.Ref] = true
+			target, err := self.root.Resolve(*s.Ref)
+			if err != nil {
+				return false
+			}
+			c = target
+			continue
+		}
+
+		switch {
+		case len(s.Enumeration) > 0 && enumBase(s.Enumeration) != "":
+			return false
+		case len(s.OneOf) > 0 || len(s.AnyOf) > 0:
+			// a wrapper of scalars is written as an attribute
+			for _, alt := range append(append([]*jsm07.Combined{}, s.OneOf...), s.AnyOf...) {
+				if self.isBlock(alt, deep) {
+					return true
+				}
+			}
+			return false
+		case isStruct(s):
+			return true
+		case len(s.AllOf) == 1 && s.Type == nil && s.Properties == nil:
+			c = s.AllOf[0]
+		case deep && schemaType(s) == "array" && s.Items != nil:
+			c = s.Items.Combined
+		case deep && schemaType(s) == "object":
+			c = s.AdditionalProperties
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+func (self *generator) structDecl(name string, s *jsm07.Schema) error {
+	i := len(self.decls)
+	self.decls = append(self.decls, "")
+
+	required := make(map[string]bool)
+	for _, k := range s.Required {
+		required[k] = true
+	}
+
+	var b strings.Builder
+	b.WriteString(comment(s.Description))
+	fmt.Fprintf(&b, "type %s struct {\n", name)
+	fields := make(map[string]bool)
+	for _, k := range s.OrderedKeys("properties") {
+		prop := s.Properties[k]
+		field := goName(k)
+		for j := 2; fields[field]; j++ {
+			field = goName(k) + strconv.Itoa(j)
+		}
+		fields[field] = true
+
+		typ, err := self.goType(prop, name+field)
+		if err != nil {
+			return fmt.Errorf("property %q: %w", k, err)
+		}
+		block := self.isBlock(prop, true)
+		if !required[k] && !isReference(typ) {
+			typ = "*" + typ
+		}
+
+		jsonTag, hclTag := k, hclName(k)
+		switch {
+		case block:
+			hclTag += ",block"
+		case !required[k]:
+			hclTag += ",optional"
+		default:
+		}
+		if !required[k] {
+			jsonTag += ",omitempty"
+		}
+
+		if prop != nil && prop.Schema != nil && prop.Schema.Description != nil {
+			b.WriteString(comment(prop.Schema.Description))
+		}
+		fmt.Fprintf(&b, "%s %s `json:%q hcl:%q`\n", field, typ, jsonTag, hclTag)
+	}
+	b.WriteString("}\n")
+
+	self.decls[i] = b.String()
+	return nil
+}
+
+func (self *generator) enumDecl(name string, s *jsm07.Schema) {
+	base := enumBase(s.Enumeration)
+
+	var b strings.Builder
+	b.WriteString(comment(s.Description))
+	fmt.Fprintf(&b, "type %s %s\n\n", name, base)
+	fmt.Fprintf(&b, "// The values of %s.\nconst (\n", name)
+	for _, v := range s.Enumeration {
+		var suffix, value string
+		switch {
+		case v.String != nil:
+			suffix, value = words(*v.String), strconv.Quote(*v.String)
+		case v.Bool != nil:
+			value = strconv.FormatBool(*v.Bool)
+			suffix = words(value)
+		case v.Number.Integer != nil:
+			value = strconv.FormatInt(*v.Number.Integer, 10)
+			suffix = strings.ReplaceAll(value, "-", "Minus")
+		default:
+			value = strconv.FormatFloat(*v.Number.Float, 'g', -1, 64)
+			suffix = strings.NewReplacer("-", "Minus", ".", "Point", "+", "", "e", "E").Replace(value)
+		}
+		if suffix == "" {
+			suffix = "Empty"
+		}
+		fmt.Fprintf(&b, "%s %s = %s\n", self.newName(name+suffix), name, value)
+	}
+	b.WriteString(")\n")
+
+	self.decls = append(self.decls, b.String())
+}
+
+// unionDecl declares a wrapper holding one of the alternatives, which are
+// tried in order when decoding.
+func (self *generator) unionDecl(name string, s *jsm07.Schema, alts []*jsm07.Combined) error {
+	i := len(self.decls)
+	self.decls = append(self.decls, "")
+	self.unions = true
+
+	type alternative struct {
+		field, typ string
+		block      bool
+	}
+	var list []alternative
+	fields := make(map[string]bool)
+	for j, alt := range alts {
+		field := altName(alt, j)
+		if ref := self.altRef(alt); ref != "" {
+			field = ref
+		}
+		for k := 2; fields[field]; k++ {
+			field = altName(alt, j) + strconv.Itoa(k)
+		}
+		fields[field] = true
+
+		typ, err := self.goType(alt, name+field)
+		if err != nil {
+			return err
+		}
+		list = append(list, alternative{field: field, typ: typ, block: self.isBlock(alt, false)})
+	}
+
+	var b strings.Builder
+	b.WriteString(comment(s.Description))
+	fmt.Fprintf(&b, "// %s holds one of its alternatives.\n", name)
+	fmt.Fprintf(&b, "type %s struct {\n", name)
+	for _, alt := range list {
+		fmt.Fprintf(&b, "%s *%s\n", alt.field, alt.typ)
+	}
+	b.WriteString("}\n\n")
+
+	decode := func(unmarshal string, blocksOnly bool) {
+		for _, alt := range list {
+			if blocksOnly && !alt.block {
+				continue
+			}
+			fmt.Fprintf(&b, "if v := new(%s); %s(data, v) == nil {\nself.%s = v\nreturn nil\n}\n", alt.typ, unmarshal, alt.field)
+		}
+	}
+	encode := func(marshal string) {
+		for _, alt := range list {
+			fmt.Fprintf(&b, "if self.%s != nil {\nreturn %s(*self.%s)\n}\n", alt.field, marshal, alt.field)
+		}
+	}
+
+	fmt.Fprintf(&b, "func (self *%s) UnmarshalJSON(data []byte) error {\n*self = %s{}\n", name, name)
+	decode("unmarshalStrict", false)
+	decode("json.Unmarshal", true)
+	fmt.Fprintf(&b, "return fmt.Errorf(\"%%s matches no alternative of %s\", data)\n}\n\n", name)
+
+	fmt.Fprintf(&b, "func (self *%s) MarshalJSON() ([]byte, error) {\n", name)
+	encode("json.Marshal")
+	b.WriteString("return []byte(\"null\"), nil\n}\n\n")
+
+	fmt.Fprintf(&b, "func (self *%s) UnmarshalHCL(data []byte, labels ...string) error {\n*self = %s{}\n", name, name)
+	decode("dethcl.Unmarshal", false)
+	fmt.Fprintf(&b, "return fmt.Errorf(\"%%s matches no alternative of %s\", data)\n}\n\n", name)
+
+	fmt.Fprintf(&b, "func (self *%s) MarshalHCL() ([]byte, error) {\n", name)
+	encode("dethcl.Marshal")
+	b.WriteString("return nil, nil\n}\n")
+
+	self.decls[i] = b.String()
+	return nil
+}
+
+// altRef returns the type name of an alternative that refers to a
+// definition.
+func (self *generator) altRef(c *jsm07.Combined) string {
+	if c == nil || c.Schema == nil || c.Schema.Ref == nil {
+		return ""
+	}
+	return self.refName(*c.Schema.Ref)
+}
+
+// altName names the field of an alternative after its type.
+func altName(c *jsm07.Combined, i int) string {
+	if c != nil && c.Schema != nil {
+		switch schemaType(c.Schema) {
+		case "string":
+			return "String"
+		case "integer":
+			return "Integer"
+		case "number":
+			return "Number"
+		case "boolean":
+			return "Boolean"
+		case "array":
+			return "Array"
+		case "object":
+			return "Object"
+		default:
+		}
+	}
+	return "Option" + strconv.Itoa(i+1)
+}
+
+// hasType tells if the root schema describes a value, rather than only
+// holding definitions.
+func hasType(s *jsm07.Schema) bool {
+	return s.Type != nil || s.Ref != nil || s.Properties != nil || s.Items != nil ||
+		len(s.Enumeration) > 0 || len(s.OneOf) > 0 || len(s.AnyOf) > 0 || len(s.AllOf) > 0
+}
+
+// schemaType returns the single type of s other than null, inferring
+// object and array from their keywords, or else an empty string.
+func schemaType(s *jsm07.Schema) string {
+	var types []string
+	if s.Type != nil {
+		if s.Type.String != nil {
+			types = []string{*s.Type.String}
+		} else if s.Type.StringArray != nil {
+			types = *s.Type.StringArray
+		}
+	}
+
+	var found string
+	for _, t := range types {
+		if t == "null" {
+			continue
+		}
+		if found != "" {
+			return ""
+		}
+		found = t
+	}
+	if found == "" && s.Type == nil {
+		switch {
+		case s.Properties != nil:
+			return "object"
+		case s.Items != nil:
+			return "array"
+		default:
+		}
+	}
+	return found
+}
+
+func isStruct(s *jsm07.Schema) bool {
+	return schemaType(s) == "object" && len(s.Properties) > 0
+}
+
+// enumBase returns the Go type shared by the values of an enumeration, or
+// an empty string if they have none.
+func enumBase(values []jsm07.SchemaEnumValue) string {
+	var base string
+	for _, v := range values {
+		var t string
+		switch {
+		case v.String != nil:
+			t = "string"
+		case v.Bool != nil:
+			t = "bool"
+		case v.Number != nil && v.Number.Integer != nil:
+			t = "int64"
+		case v.Number != nil && v.Number.Float != nil:
+			t = "float64"
+		default:
+			return ""
+		}
+		switch {
+		case base == "" || base == t:
+			base = t
+		case (base == "int64" && t == "float64") || (base == "float64" && t == "int64"):
+			base = "float64"
+		default:
+			return ""
+		}
+	}
+	return base
+}
+
+// isReference tells if a field of type typ is nil when absent without
+// being a pointer.
+func isReference(typ string) bool {
+	return typ == "interface{}" || strings.HasPrefix(typ, "[]") || strings.HasPrefix(typ, "map[")
+}
+
+// hclName writes a leading $ as _, as package jsm07 does for keywords.
+func hclName(key string) string {
+	if strings.HasPrefix(key, "$") {
+		return "_" + key[1:]
+	}
+	return key
+}
+
+func comment(description *string) string {
+	if description == nil || *description == "" {
+		return ""
+	}
+	var b strings.Builder
+	for _, line := range strings.Split(strings.TrimSpace(*description), "\n") {
+		b.WriteString(strings.TrimRight("// "+line, " ") + "\n")
+	}
+	return b.String()
+}
+
+var initialisms = map[string]bool{
+	"API": true, "HCL": true, "HTML": true, "HTTP": true, "ID": true, "JSON": true,
+	"MIME": true, "SQL": true, "TLS": true, "URI": true, "URL": true, "UUID": true,
+}
+
+// goName turns a key into an exported Go identifier.
+func goName(s string) string {
+	name := words(s)
+	if name == "" || !unicode.IsLetter([]rune(name)[0]) {
+		name = "X" + name
+	}
+	return name
+}
+
+// words splits s into words at separators and case changes, and joins
+// them capitalized.
+func words(s string) string {
+	var words []string
+	var word []rune
+	flush := func() {
+		if len(word) > 0 {
+			words = append(words, string(word))
+			word = nil
+		}
+	}
+
+	runes := []rune(s)
+	for i, r := range runes {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+			continue
+		case unicode.IsUpper(r) && i > 0:
+			prev := runes[i-1]
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) ||
+				(unicode.IsUpper(prev) && i+1 < len(runes) && unicode.IsLower(runes[i+1])) {
+				flush()
+			}
+		default:
+		}
+		word = append(word, r)
+	}
+	flush()
+
+	var b strings.Builder
+	for _, w := range words {
+		if upper := strings.ToUpper(w); initialisms[upper] {
+			b.WriteString(upper)
+			continue
+		}
+		r := []rune(w)
+		b.WriteRune(unicode.ToUpper(r[0]))
+		b.WriteString(string(r[1:]))
+	}
+	return b.String()
+}
@@ -0,0 +1,115 @@
+package golang
+
+import (
+	"encoding/json"
+	"go/parser"
+	"go/token"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/genelet/hclschema/jsm07"
+)
+
+func TestGenerate(t *testing.T) {
+	data := `{
+		"title": "server config",
+		"type": "object",
+		"required": ["name", "role"],
+		"properties": {
+			"name": {"type": "string", "description": "The server name."},
+			"role": {"$ref": "#/definitions/Role"},
+			"port": {"type": "integer"},
+			"tags": {"type": "array", "items": {"type": "string"}},
+			"listeners": {"type": "array", "items": {"$ref": "#/definitions/Listener"}},
+			"timeout": {"oneOf": [{"type": "string"}, {"type": "number"}]},
+			"$comment": {"type": "string"}
+		},
+		"definitions": {
+			"Role": {"type": "string", "enum": ["primary", "read-only"]},
+			"Listener": {
+				"type": "object",
+				"required": ["url"],
+				"properties": {"url": {"type": "string"}, "tls": {"type": "boolean"}}
+			}
+		}
+	}`
+	schema := new(jsm07.Schema)
+	if err := json.Unmarshal([]byte(data), schema); err != nil {
+		t.Fatalf("Failed to unmarshal schema: %v", err)
+	}
+	bs, err := Generate(schema, &Options{Package: "config"})
+	if err != nil {
+		t.Fatalf("Failed to generate: %v", err)
+	}
+	code := strings.Join(strings.Fields(string(bs)), " ")
+
+	for _, expected := range []string{
+		"package config",
+		"type ServerConfig struct { // The server name. Name string `json:\"name\" hcl:\"name\"` Role Role `json:\"role\" hcl:\"role\"` Port *int64 `json:\"port,omitempty\" hcl:\"port,optional\"`",
+		"Tags []string `json:\"tags,omitempty\" hcl:\"tags,optional\"`",
+		"Listeners []*Listener `json:\"listeners,omitempty\" hcl:\"listeners,block\"`",
+		"Timeout *ServerConfigTimeout `json:\"timeout,omitempty\" hcl:\"timeout,optional\"`",
+		"Comment *string `json:\"$comment,omitempty\" hcl:\"_comment,optional\"`",
+		"type ServerConfigTimeout struct { String *string Number *float64 }",
+		"type Role string",
+		"RolePrimary Role = \"primary\" RoleReadOnly Role = \"read-only\"",
+		"type Listener struct { URL string `json:\"url\" hcl:\"url\"` TLS *bool `json:\"tls,omitempty\" hcl:\"tls,optional\"` }",
+	} {
+		if !strings.Contains(code, expected) {
+			t.Errorf("Expected %s in\n%s", expected, bs)
+		}
+	}
+}
+
+func TestGenerateMCP(t *testing.T) {
+	data, err := os.ReadFile("../../jsm07/samples/mcp.json")
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	schema := new(jsm07.Schema)
+	if err := json.Unmarshal(data, schema); err != nil {
+		t.Fatalf("Failed to unmarshal mcp: %v", err)
+	}
+	bs, err := Generate(schema, &Options{Package: "mcp"})
+	if err != nil {
+		t.Fatalf("Failed to generate: %v", err)
+	}
+
+	file, err := parser.ParseFile(token.NewFileSet(), "mcp.go", bs, 0)
+	if err != nil {
+		t.Fatalf("Failed to parse generated code: %v", err)
+	}
+	types := make(map[string]bool)
+	for _, obj := range file.Scope.Objects {
+		types[obj.Name] = true
+	}
+	for _, name := range []string{"Annotations", "CallToolResult", "CallToolResultContentItem", "Role", "RoleAssistant", "JSONRPCBatchRequest"} {
+		if !types[name] {
+			t.Errorf("Expected declaration of %s", name)
+		}
+	}
+	// a root holding only definitions generates no type, so the
+	// definition Root keeps its name
+	if !types["Root"] || types["Root2"] {
+		t.Errorf("Expected no root type")
+	}
+}
+
+func TestGoName(t *testing.T) {
+	tests := map[string]string{
+		"mimeType":     "MIMEType",
+		"_meta":        "Meta",
+		"$ref":         "Ref",
+		"read-only":    "ReadOnly",
+		"HTTPServer":   "HTTPServer",
+		"JSONRPCError": "JSONRPCError",
+		"2fa":          "X2fa",
+		"":             "X",
+	}
+	for input, expected := range tests {
+		if got := goName(input); got != expected {
+			t.Errorf("goName(%q): expected %s, got %s", input, expected, got)
+		}
+	}
+}
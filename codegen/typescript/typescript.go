@@ -0,0 +1,549 @@
+// Package typescript generates TypeScript declarations (.d.ts) from a
+// draft-07 schema.
+//
+// Definitions become exported interfaces or type aliases, $ref becomes a
+// reference to them, enum and const become literal types, oneOf and anyOf
+// union types, allOf intersection types, additionalProperties index
+// signatures, and an array of items a tuple type.
+package typescript
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/genelet/hclschema/jsm07"
+)
+
+// Options controls the generated declarations.
+type Options struct {
+	// Name is the name of the type declared for the root schema; the
+	// default is derived from its title, or else "Root". A root schema
+	// holding only definitions declares no type.
+	Name string
+}
+
+// Generate returns the declarations of the types of the schema and its
+// definitions.
+func Generate(schema *jsm07.Schema, opts *Options) ([]byte, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+
+	g := &generator{
+		root:      schema,
+		names:     make(map[string]bool),
+		defs:      make(map[string]string),
+		resolving: make(map[string]bool),
+	}
+	if hasType(schema) {
+		name := opts.Name
+		if name == "" && schema.Title != nil {
+			name = typeName(*schema.Title)
+		}
+		if name == "" {
+			name = "Root"
+		}
+		g.rootName = g.newName(name)
+	}
+	keys := schema.OrderedKeys("definitions")
+	for _, k := range keys {
+		g.defs[k] = g.newName(typeName(k))
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("// Code generated by hclschema. DO NOT EDIT.\n")
+	if g.rootName != "" {
+		if err := g.declare(&buf, g.rootName, jsm07.NewCombinedWithSchema(schema)); err != nil {
+			return nil, err
+		}
+	}
+	for _, k := range keys {
+		if err := g.declare(&buf, g.defs[k], schema.Definitions[k]); err != nil {
+			return nil, fmt.Errorf("definition %q: %w", k, err)
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+type generator struct {
+	root      *jsm07.Schema
+	rootName  string
+	names     map[string]bool
+	defs      map[string]string // definition key to type name
+	resolving map[string]bool   // references being followed
+}
+
+func (self *generator) newName(base string) string {
+	name := base
+	for i := 2; self.names[name]; i++ {
+		name = base + strconv.Itoa(i)
+	}
+	self.names[name] = true
+	return name
+}
+
+// declare writes an interface for a plain object, or else a type alias.
+func (self *generator) declare(buf *bytes.Buffer, name string, c *jsm07.Combined) error {
+	buf.WriteString("\n")
+	if c != nil && c.Schema != nil {
+		buf.WriteString(docComment(c.Schema.Description, ""))
+	}
+
+	if c != nil && c.Schema != nil && isInterface(c.Schema) {
+		body, err := self.objectType(c.Schema, 0)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(buf, "export interface %s %s\n", name, body)
+		return nil
+	}
+
+	typ, err := self.tsType(c, 0)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(buf, "export type %s = %s;\n", name, typ)
+	return nil
+}
+
+// tsType returns the type expression of c, whose object literals are
+// indented by depth levels.
+func (self *generator) tsType(c *jsm07.Combined, depth int) (string, error) {
+	if c == nil {
+		return "unknown", nil
+	}
+	if c.Schema == nil {
+		if c.Boolean != nil && !*c.Boolean {
+			return "never", nil
+		}
+		return "unknown", nil
+	}
+	s := c.Schema
+
+	if s.Ref != nil {
+		ref := *s.Ref
+		if name := self.refName(ref); name != "" {
+			return name, nil
+		}
+		if self.resolving[ref] {
+			return "unknown", nil
+		}
+		target, err := self.root.Resolve(ref)
+		if err != nil {
+			return "", err
+		}
+		self.resolving[ref] = true
+		defer delete(self.resolving, ref)
+		return self.tsType(target, depth)
+	}
+
+	if s.Const != nil {
+		return literal(*s.Const)
+	}
+	if len(s.Enumeration) > 0 {
+		var literals []string
+		for _, v := range s.Enumeration {
+			bs, err := json.Marshal(&v)
+			if err != nil {
+				return "", err
+			}
+			lit, err := literal(bs)
+			if err != nil {
+				return "", err
+			}
+			literals = append(literals, lit)
+		}
+		return join(literals, " | "), nil
+	}
+
+	var parts []string
+	base, err := self.baseType(s, depth)
+	if err != nil {
+		return "", err
+	}
+	if base != "" {
+		parts = append(parts, base)
+	}
+	for _, alts := range [][]*jsm07.Combined{s.OneOf, s.AnyOf} {
+		if len(alts) == 0 {
+			continue
+		}
+		var union []string
+		for _, alt := range alts {
+			typ, err := self.tsType(alt, depth)
+			if err != nil {
+				return "", err
+			}
+			union = append(union, typ)
+		}
+		parts = append(parts, join(union, " | "))
+	}
+	for _, sub := range s.AllOf {
+		typ, err := self.tsType(sub, depth)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, typ)
+	}
+
+	switch len(parts) {
+	case 0:
+		return "unknown", nil
+	case 1:
+		return parts[0], nil
+	default:
+	}
+	for i, part := range parts {
+		parts[i] = paren(part)
+	}
+	return strings.Join(parts, " & "), nil
+}
+
+// baseType returns the type given by the type keyword, or inferred from
+// the object and array keywords, or else an empty string.
+func (self *generator) baseType(s *jsm07.Schema, depth int) (string, error) {
+	var types []string
+	switch {
+	case s.Type != nil && s.Type.String != nil:
+		types = []string{*s.Type.String}
+	case s.Type != nil && s.Type.StringArray != nil:
+		types = *s.Type.StringArray
+	case s.Properties != nil || s.AdditionalProperties != nil || s.PatternProperties != nil:
+		types = []string{"object"}
+	case s.Items != nil:
+		types = []string{"array"}
+	default:
+	}
+
+	var union []string
+	for _, t := range types {
+		var typ string
+		var err error
+		switch t {
+		case "string":
+			typ = "string"
+		case "integer", "number":
+			typ = "number"
+		case "boolean":
+			typ = "boolean"
+		case "null":
+			typ = "null"
+		case "array":
+			typ, err = self.arrayType(s, depth)
+		case "object":
+			typ, err = self.objectType(s, depth)
+		default:
+			typ = "unknown"
+		}
+		if err != nil {
+			return "", err
+		}
+		union = append(union, typ)
+	}
+	return join(union, " | "), nil
+}
+
+// arrayType returns an array type, or a tuple type for an array of items
+// whose elements after minItems are optional.
+func (self *generator) arrayType(s *jsm07.Schema, depth int) (string, error) {
+	if s.Items == nil || (s.Items.Combined == nil && s.Items.CombinedArray == nil) {
+		return "unknown[]", nil
+	}
+	if s.Items.Combined != nil {
+		typ, err := self.tsType(s.Items.Combined, depth)
+		if err != nil {
+			return "", err
+		}
+		return paren(typ) + "[]", nil
+	}
+
+	var min int
+	if s.MinItems != nil {
+		min = int(*s.MinItems)
+	}
+	var elems []string
+	for i, item := range *s.Items.CombinedArray {
+		typ, err := self.tsType(item, depth)
+		if err != nil {
+			return "", err
+		}
+		if i >= min {
+			typ = paren(typ) + "?"
+		}
+		elems = append(elems, typ)
+	}
+	switch rest := s.AdditionalItems; {
+	case rest != nil && rest.Boolean != nil && !*rest.Boolean:
+	case rest == nil || rest.Schema == nil:
+		elems = append(elems, "...unknown[]")
+	default:
+		typ, err := self.tsType(rest, depth)
+		if err != nil {
+			return "", err
+		}
+		elems = append(elems, "..."+paren(typ)+"[]")
+	}
+	return "[" + strings.Join(elems, ", ") + "]", nil
+}
+
+// objectType returns an object type literal. Its index signature, if any,
+// admits the types of the properties too, as TypeScript requires.
+func (self *generator) objectType(s *jsm07.Schema, depth int) (string, error) {
+	indent := strings.Repeat("  ", depth+1)
+	required := make(map[string]bool)
+	for _, k := range s.Required {
+		required[k] = true
+	}
+
+	var b strings.Builder
+	b.WriteString("{\n")
+	var propTypes []string
+	optional := false
+	for _, k := range s.OrderedKeys("properties") {
+		prop := s.Properties[k]
+		typ, err := self.tsType(prop, depth+1)
+		if err != nil {
+			return "", fmt.Errorf("property %q: %w", k, err)
+		}
+		propTypes = append(propTypes, typ)
+		if prop != nil && prop.Schema != nil {
+			b.WriteString(docComment(prop.Schema.Description, indent))
+		}
+		mark := ""
+		if !required[k] {
+			mark = "?"
+			optional = true
+		}
+		fmt.Fprintf(&b, "%s%s%s: %s;\n", indent, propertyName(k), mark, typ)
+	}
+
+	var index []string
+	for _, k := range s.OrderedKeys("patternProperties") {
+		typ, err := self.tsType(s.PatternProperties[k], depth+1)
+		if err != nil {
+			return "", err
+		}
+		index = append(index, typ)
+	}
+	if ap := s.AdditionalProperties; ap != nil && (ap.Schema != nil || (ap.Boolean != nil && *ap.Boolean)) {
+		typ, err := self.tsType(ap, depth+1)
+		if err != nil {
+			return "", err
+		}
+		index = append(index, typ)
+	}
+	if len(index) > 0 {
+		if !contains(index, "unknown") {
+			index = append(index, propTypes...)
+			if optional {
+				index = append(index, "undefined")
+			}
+		}
+		fmt.Fprintf(&b, "%s[key: string]: %s;\n", indent, join(dedupe(index), " | "))
+	}
+
+	b.WriteString(strings.Repeat("  ", depth) + "}")
+	return b.String(), nil
+}
+
+// refName returns the type name of a reference to the root or to a
+// definition, or else an empty string.
+func (self *generator) refName(ref string) string {
+	if ref == "#" {
+		return self.rootName
+	}
+	if !strings.HasPrefix(ref, "#/definitions/") {
+		return ""
+	}
+	key := strings.TrimPrefix(ref, "#/definitions/")
+	if strings.Contains(key, "/") {
+		return ""
+	}
+	key = strings.ReplaceAll(strings.ReplaceAll(key, "~1", "/"), "~0", "~")
+	return self.defs[key]
+}
+
+// hasType tells if the root schema describes a value, rather than only
+// holding definitions.
+func hasType(s *jsm07.Schema) bool {
+	return s.Type != nil || s.Ref != nil || s.Properties != nil || s.Items != nil || s.Const != nil ||
+		len(s.Enumeration) > 0 || len(s.OneOf) > 0 || len(s.AnyOf) > 0 || len(s.AllOf) > 0
+}
+
+// isInterface tells if s is a plain object, which is declared as an
+// interface.
+func isInterface(s *jsm07.Schema) bool {
+	if s.Ref != nil || s.Const != nil || len(s.Enumeration) > 0 ||
+		len(s.OneOf) > 0 || len(s.AnyOf) > 0 || len(s.AllOf) > 0 {
+		return false
+	}
+	if s.Type != nil {
+		return s.Type.String != nil && *s.Type.String == "object"
+	}
+	return s.Properties != nil && s.Items == nil
+}
+
+// literal returns the literal type of a JSON value, which is written the
+// same way in TypeScript.
+func literal(raw json.RawMessage) (string, error) {
+	var v interface{}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return "", err
+	}
+	bs, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(bs), nil
+}
+
+var identifier = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+// propertyName quotes a key that is not an identifier.
+func propertyName(key string) string {
+	if identifier.MatchString(key) {
+		return key
+	}
+	return strconv.Quote(key)
+}
+
+// typeName keeps a key that is an identifier, and otherwise joins its
+// words capitalized. A reserved word or predefined type gets a Type
+// suffix, since it cannot name a type.
+func typeName(key string) string {
+	if identifier.MatchString(key) && !strings.Contains(key, "$") {
+		if reserved[key] {
+			return key + "Type"
+		}
+		return key
+	}
+
+	var b strings.Builder
+	upper := true
+	for _, r := range key {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	name := b.String()
+	if name == "" || unicode.IsDigit([]rune(name)[0]) {
+		name = "T" + name
+	}
+	if reserved[name] {
+		name += "Type"
+	}
+	return name
+}
+
+// reserved lists the words that cannot be the name of an interface or a
+// type alias: reserved words, strict mode reserved words and predefined
+// types.
+var reserved = map[string]bool{
+	"break": true, "case": true, "catch": true, "class": true, "const": true,
+	"continue": true, "debugger": true, "default": true, "delete": true,
+	"do": true, "else": true, "enum": true, "export": true, "extends": true,
+	"false": true, "finally": true, "for": true, "function": true, "if": true,
+	"import": true, "in": true, "instanceof": true, "new": true, "null": true,
+	"return": true, "super": true, "switch": true, "this": true, "throw": true,
+	"true": true, "try": true, "typeof": true, "var": true, "void": true,
+	"while": true, "with": true,
+
+	"implements": true, "interface": true, "let": true, "package": true,
+	"private": true, "protected": true, "public": true, "static": true,
+	"yield": true, "await": true,
+
+	"any": true, "bigint": true, "boolean": true, "never": true,
+	"number": true, "object": true, "string": true, "symbol": true,
+	"undefined": true, "unknown": true, "type": true,
+}
+
+func docComment(description *string, indent string) string {
+	if description == nil || *description == "" {
+		return ""
+	}
+	text := strings.ReplaceAll(strings.TrimSpace(*description), "*/", "*\\/")
+	lines := strings.Split(text, "\n")
+	if len(lines) == 1 {
+		return indent + "/** " + lines[0] + " */\n"
+	}
+	var b strings.Builder
+	b.WriteString(indent + "/**\n")
+	for _, line := range lines {
+		b.WriteString(strings.TrimRight(indent+" * "+line, " ") + "\n")
+	}
+	b.WriteString(indent + " */\n")
+	return b.String()
+}
+
+// paren parenthesizes a union or intersection used as an operand.
+func paren(typ string) string {
+	depth := 0
+	quoted := false
+	for i := 0; i < len(typ); i++ {
+		r := typ[i]
+		if quoted {
+			switch r {
+			case '\\':
+				i++
+			case '"':
+				quoted = false
+			default:
+			}
+			continue
+		}
+		switch r {
+		case '"':
+			quoted = true
+		case '{', '[', '(':
+			depth++
+		case '}', ']', ')':
+			depth--
+		case '|', '&':
+			if depth == 0 {
+				return "(" + typ + ")"
+			}
+		default:
+		}
+	}
+	return typ
+}
+
+func join(types []string, sep string) string {
+	types = dedupe(types)
+	if len(types) > 1 {
+		for i, typ := range types {
+			types[i] = paren(typ)
+		}
+	}
+	return strings.Join(types, sep)
+}
+
+func dedupe(types []string) []string {
+	var out []string
+	for _, typ := range types {
+		if !contains(out, typ) {
+			out = append(out, typ)
+		}
+	}
+	return out
+}
+
+func contains(types []string, typ string) bool {
+	for _, t := range types {
+		if t == typ {
+			return true
+		}
+	}
+	return false
+}
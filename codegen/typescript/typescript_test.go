@@ -0,0 +1,161 @@
+package typescript
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/genelet/hclschema/jsm07"
+)
+
+func TestGenerate(t *testing.T) {
+	data := `{
+		"title": "server config",
+		"description": "The configuration of a server.",
+		"type": "object",
+		"required": ["name", "role"],
+		"properties": {
+			"name": {"type": "string", "description": "The server name."},
+			"role": {"$ref": "#/definitions/Role"},
+			"port": {"type": ["integer", "null"]},
+			"listen-on": {"type": "array", "items": {"$ref": "#/definitions/Listener"}},
+			"point": {"type": "array", "items": [{"type": "number"}, {"type": "number"}], "minItems": 1, "additionalItems": false},
+			"timeout": {"oneOf": [{"type": "string"}, {"type": "number"}]},
+			"labels": {"type": "object", "additionalProperties": {"type": "string"}},
+			"kind": {"const": "server"}
+		},
+		"definitions": {
+			"Role": {"type": "string", "enum": ["primary", "read-only"]},
+			"Listener": {
+				"type": "object",
+				"required": ["url"],
+				"properties": {"url": {"type": "string"}},
+				"additionalProperties": {"type": "boolean"}
+			},
+			"secure listener": {
+				"allOf": [{"$ref": "#/definitions/Listener"}, {"properties": {"cert": {"type": "string"}}, "required": ["cert"]}]
+			}
+		}
+	}`
+	schema := new(jsm07.Schema)
+	if err := json.Unmarshal([]byte(data), schema); err != nil {
+		t.Fatalf("Failed to unmarshal schema: %v", err)
+	}
+	bs, err := Generate(schema, nil)
+	if err != nil {
+		t.Fatalf("Failed to generate: %v", err)
+	}
+	expected := `// Code generated by hclschema. DO NOT EDIT.
+
+/** The configuration of a server. */
+export interface ServerConfig {
+  /** The server name. */
+  name: string;
+  role: Role;
+  port?: number | null;
+  "listen-on"?: Listener[];
+  point?: [number, number?];
+  timeout?: string | number;
+  labels?: {
+    [key: string]: string;
+  };
+  kind?: "server";
+}
+
+export type Role = "primary" | "read-only";
+
+export interface Listener {
+  url: string;
+  [key: string]: boolean | string;
+}
+
+export type SecureListener = Listener & {
+  cert: string;
+};
+`
+	if string(bs) != expected {
+		t.Errorf("Expected\n%s\ngot\n%s", expected, bs)
+	}
+}
+
+func TestGenerateMCP(t *testing.T) {
+	data, err := os.ReadFile("../../jsm07/samples/mcp.json")
+	if err != nil {
+		t.Fatalf("Failed to read file: %v", err)
+	}
+	schema := new(jsm07.Schema)
+	if err := json.Unmarshal(data, schema); err != nil {
+		t.Fatalf("Failed to unmarshal mcp: %v", err)
+	}
+	bs, err := Generate(schema, nil)
+	if err != nil {
+		t.Fatalf("Failed to generate: %v", err)
+	}
+
+	for _, expected := range []string{
+		"export type Role = \"assistant\" | \"user\";",
+		"export type ProgressToken = string | number;",
+		"  content: (TextContent | ImageContent | AudioContent | EmbeddedResource)[];",
+	} {
+		if !strings.Contains(string(bs), expected) {
+			t.Errorf("Expected %s in generated declarations", expected)
+		}
+	}
+	// a root holding only definitions declares no type, so the
+	// definition Root keeps its name
+	if strings.Contains(string(bs), "Root2") {
+		t.Errorf("Expected no root type")
+	}
+}
+
+func TestGenerateReservedNames(t *testing.T) {
+	data := `{
+		"title": "number",
+		"type": "object",
+		"properties": {"s": {"$ref": "#/definitions/string"}, "d": {"$ref": "#/definitions/default"}},
+		"definitions": {
+			"string": {"type": "string"},
+			"default": {"type": "integer"},
+			"class": {"type": "object", "properties": {"n": {"$ref": "#/definitions/null"}}},
+			"null": {"type": "null"},
+			"stringType": {"type": "boolean"},
+			"any": {},
+			"un known": {"type": "string"}
+		}
+	}`
+	schema := new(jsm07.Schema)
+	if err := json.Unmarshal([]byte(data), schema); err != nil {
+		t.Fatalf("Failed to unmarshal schema: %v", err)
+	}
+	bs, err := Generate(schema, nil)
+	if err != nil {
+		t.Fatalf("Failed to generate: %v", err)
+	}
+	expected := `// Code generated by hclschema. DO NOT EDIT.
+
+export interface numberType {
+  s?: stringType;
+  d?: defaultType;
+}
+
+export type stringType = string;
+
+export type defaultType = number;
+
+export interface classType {
+  n?: nullType;
+}
+
+export type nullType = null;
+
+export type stringType2 = boolean;
+
+export type anyType = unknown;
+
+export type UnKnown = string;
+`
+	if string(bs) != expected {
+		t.Errorf("Expected\n%s\ngot\n%s", expected, bs)
+	}
+}
@@ -0,0 +1,543 @@
+// Package docgen renders reference documentation for a draft-07 schema in
+// Markdown or HTML.
+//
+// The document has a section for the root schema and one for each of its
+// definitions, giving the type, description and constraints of the
+// schema and a table of its properties. A $ref to a definition links to
+// its section; properties of nested objects are listed under dotted
+// names.
+package docgen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/genelet/hclschema/jsm07"
+)
+
+// Options controls the generated document.
+type Options struct {
+	// Title is the title of the document; the default is the title of
+	// the schema, or else "Schema".
+	Title string
+	// MaxDepth is the depth to which properties of nested objects are
+	// listed; the default is 3.
+	MaxDepth int
+}
+
+// Markdown returns the documentation of the schema in Markdown.
+func Markdown(schema *jsm07.Schema, opts *Options) ([]byte, error) {
+	d, err := build(schema, opts)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	writeMarkdown(&buf, d)
+	return buf.Bytes(), nil
+}
+
+// HTML returns the documentation of the schema as an HTML document.
+func HTML(schema *jsm07.Schema, opts *Options) ([]byte, error) {
+	d, err := build(schema, opts)
+	if err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	writeHTML(&buf, d)
+	return buf.Bytes(), nil
+}
+
+// document is the content shared by the Markdown and HTML forms.
+type document struct {
+	title       string
+	description string
+	root        *section
+	definitions []*section
+}
+
+type section struct {
+	name        string
+	anchor      string
+	description string
+	typ         []span
+	constraints []string
+	dflt        string
+	properties  []*row
+}
+
+type row struct {
+	name        string
+	typ         []span
+	required    bool
+	constraints []string
+	dflt        string
+	description string
+}
+
+// span is a piece of a type, linked to a section when href is set.
+type span struct {
+	text string
+	href string
+}
+
+type builder struct {
+	root     *jsm07.Schema
+	maxDepth int
+	anchors  map[string]string // definition key to anchor
+	used     map[string]bool
+}
+
+func build(schema *jsm07.Schema, opts *Options) (*document, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	b := &builder{
+		root:     schema,
+		maxDepth: opts.MaxDepth,
+		anchors:  make(map[string]string),
+		used:     make(map[string]bool),
+	}
+	if b.maxDepth <= 0 {
+		b.maxDepth = 3
+	}
+
+	d := &document{title: opts.Title}
+	if d.title == "" && schema.Title != nil {
+		d.title = *schema.Title
+	}
+	if d.title == "" {
+		d.title = "Schema"
+	}
+	if schema.Description != nil {
+		d.description = *schema.Description
+	}
+	b.anchor(d.title)
+	b.anchor("Definitions")
+	keys := schema.OrderedKeys("definitions")
+	for _, k := range keys {
+		b.anchors[k] = b.anchor(k)
+	}
+
+	if hasContent(schema) {
+		s, err := b.section("", "", jsm07.NewCombinedWithSchema(schema))
+		if err != nil {
+			return nil, err
+		}
+		s.description = ""
+		d.root = s
+	}
+	for _, k := range keys {
+		s, err := b.section(k, b.anchors[k], schema.Definitions[k])
+		if err != nil {
+			return nil, fmt.Errorf("definition %q: %w", k, err)
+		}
+		d.definitions = append(d.definitions, s)
+	}
+	return d, nil
+}
+
+// hasContent reports whether the root schema describes a value, rather
+// than only holding definitions.
+func hasContent(s *jsm07.Schema) bool {
+	return s.Type != nil || s.Ref != nil || s.Properties != nil || s.Items != nil || s.Enumeration != nil || s.Const != nil ||
+		s.AllOf != nil || s.AnyOf != nil || s.OneOf != nil || s.AdditionalProperties != nil
+}
+
+// anchor returns a unique anchor for a heading, made as GitHub does.
+func (self *builder) anchor(heading string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(heading) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '-' || r == '_':
+			b.WriteRune(r)
+		case r == ' ':
+			b.WriteRune('-')
+		default:
+		}
+	}
+	base := b.String()
+	name := base
+	for i := 1; self.used[name]; i++ {
+		name = base + "-" + strconv.Itoa(i)
+	}
+	self.used[name] = true
+	return name
+}
+
+func (self *builder) section(name, anchor string, c *jsm07.Combined) (*section, error) {
+	sec := &section{name: name, anchor: anchor}
+	typ, err := self.typeOf(c, 0)
+	if err != nil {
+		return nil, err
+	}
+	sec.typ = typ
+	if c == nil || c.Schema == nil {
+		return sec, nil
+	}
+	s := c.Schema
+	if s.Description != nil {
+		sec.description = *s.Description
+	}
+	sec.constraints = constraints(s)
+	sec.dflt = rawString(s.Default)
+	if err := self.rows(&sec.properties, "", s, 0); err != nil {
+		return nil, err
+	}
+	return sec, nil
+}
+
+// rows appends the properties of s, and of its inline objects, whose
+// names are prefixed by prefix.
+func (self *builder) rows(rows *[]*row, prefix string, s *jsm07.Schema, depth int) error {
+	required := make(map[string]bool)
+	for _, k := range s.Required {
+		required[k] = true
+	}
+	for _, k := range s.OrderedKeys("properties") {
+		c := s.Properties[k]
+		typ, err := self.typeOf(c, 0)
+		if err != nil {
+			return fmt.Errorf("property %q: %w", k, err)
+		}
+		r := &row{name: prefix + k, typ: typ, required: required[k]}
+		if c != nil && c.Schema != nil {
+			r.constraints = constraints(c.Schema)
+			r.dflt = rawString(c.Schema.Default)
+			if c.Schema.Description != nil {
+				r.description = *c.Schema.Description
+			}
+		}
+		*rows = append(*rows, r)
+
+		if c != nil && c.Schema != nil && c.Schema.Ref == nil && c.Schema.Properties != nil && depth+1 < self.maxDepth {
+			if err := self.rows(rows, r.name+".", c.Schema, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// typeOf returns the type of c, with references linked to the sections
+// of their definitions.
+func (self *builder) typeOf(c *jsm07.Combined, depth int) ([]span, error) {
+	if c == nil {
+		return text("any"), nil
+	}
+	if c.Schema == nil {
+		if c.Boolean != nil && !*c.Boolean {
+			return text("never"), nil
+		}
+		return text("any"), nil
+	}
+	if depth > 8 {
+		return text("…"), nil
+	}
+	s := c.Schema
+
+	if s.Ref != nil {
+		return self.refType(*s.Ref)
+	}
+
+	// the base type, oneOf, anyOf and allOf entries are intersected
+	var parts [][]span
+	base, err := self.baseType(s, depth)
+	if err != nil {
+		return nil, err
+	}
+	if base != nil {
+		parts = append(parts, base)
+	}
+	for _, alts := range [][]*jsm07.Combined{s.OneOf, s.AnyOf} {
+		if len(alts) == 0 {
+			continue
+		}
+		union, err := self.typesOf(alts, depth)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, joinSpans(union, " | ", len(s.AllOf) > 0 || len(parts) > 0 || len(s.AnyOf) > 0 && len(s.OneOf) > 0))
+	}
+	all, err := self.typesOf(s.AllOf, depth)
+	if err != nil {
+		return nil, err
+	}
+	parts = append(parts, all...)
+
+	if len(parts) == 0 {
+		return text("any"), nil
+	}
+	return joinSpans(parts, " & ", false), nil
+}
+
+func (self *builder) typesOf(cs []*jsm07.Combined, depth int) ([][]span, error) {
+	var out [][]span
+	for _, c := range cs {
+		typ, err := self.typeOf(c, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, typ)
+	}
+	return out, nil
+}
+
+func (self *builder) refType(ref string) ([]span, error) {
+	if ref == "#" {
+		return []span{{text: "root", href: "#"}}, nil
+	}
+	if strings.HasPrefix(ref, "#/definitions/") {
+		key := unescape(strings.TrimPrefix(ref, "#/definitions/"))
+		if anchor, ok := self.anchors[key]; ok {
+			return []span{{text: key, href: "#" + anchor}}, nil
+		}
+	}
+	if !strings.HasPrefix(ref, "#") {
+		return []span{{text: ref, href: ref}}, nil
+	}
+	if _, err := self.root.Resolve(ref); err != nil {
+		return nil, err
+	}
+	return text(ref), nil
+}
+
+// baseType returns the type given by the type keyword, or nil.
+func (self *builder) baseType(s *jsm07.Schema, depth int) ([]span, error) {
+	var types []string
+	if s.Type != nil {
+		if s.Type.String != nil {
+			types = []string{*s.Type.String}
+		} else if s.Type.StringArray != nil {
+			types = *s.Type.StringArray
+		}
+	}
+	if len(types) == 0 {
+		switch {
+		case s.Properties != nil || s.AdditionalProperties != nil:
+			types = []string{"object"}
+		case s.Items != nil:
+			types = []string{"array"}
+		case s.Const != nil || len(s.Enumeration) > 0:
+			types = valueTypes(s)
+		default:
+			return nil, nil
+		}
+	}
+
+	var parts [][]span
+	for _, t := range types {
+		switch t {
+		case "array":
+			typ, err := self.arrayType(s, depth)
+			if err != nil {
+				return nil, err
+			}
+			parts = append(parts, typ)
+		case "object":
+			if ap := s.AdditionalProperties; ap != nil && ap.Schema != nil && s.Properties == nil {
+				typ, err := self.typeOf(ap, depth+1)
+				if err != nil {
+					return nil, err
+				}
+				parts = append(parts, append(text("map of "), group(typ)...))
+				continue
+			}
+			parts = append(parts, text("object"))
+		default:
+			parts = append(parts, text(t))
+		}
+	}
+	return joinSpans(parts, " | ", false), nil
+}
+
+func (self *builder) arrayType(s *jsm07.Schema, depth int) ([]span, error) {
+	if s.Items == nil {
+		return text("array"), nil
+	}
+	if s.Items.CombinedArray != nil {
+		var items [][]span
+		for _, item := range *s.Items.CombinedArray {
+			typ, err := self.typeOf(item, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, typ)
+		}
+		out := text("[")
+		out = append(out, joinSpans(items, ", ", false)...)
+		return append(out, span{text: "]"}), nil
+	}
+	typ, err := self.typeOf(s.Items.Combined, depth+1)
+	if err != nil {
+		return nil, err
+	}
+	return append(text("array of "), group(typ)...), nil
+}
+
+// constraints returns the keywords restricting values of s, as written
+// in JSON.
+func constraints(s *jsm07.Schema) []string {
+	var out []string
+	add := func(keyword string, v interface{}) {
+		bs, err := json.Marshal(v)
+		if err == nil {
+			out = append(out, keyword+": "+string(bs))
+		}
+	}
+	if s.Const != nil {
+		out = append(out, "const: "+rawString(s.Const))
+	}
+	if len(s.Enumeration) > 0 {
+		add("enum", s.Enumeration)
+	}
+	if s.Format != nil {
+		add("format", *s.Format)
+	}
+	for _, n := range []struct {
+		keyword string
+		value   *jsm07.IntegerOrFloat
+	}{{"minimum", s.Minimum}, {"exclusiveMinimum", s.ExclusiveMinimum}, {"maximum", s.Maximum}, {"exclusiveMaximum", s.ExclusiveMaximum}, {"multipleOf", s.MultipleOf}} {
+		if n.value != nil {
+			add(n.keyword, n.value)
+		}
+	}
+	if s.Pattern != nil {
+		add("pattern", *s.Pattern)
+	}
+	for _, n := range []struct {
+		keyword string
+		value   *int64
+	}{{"minLength", s.MinLength}, {"maxLength", s.MaxLength}, {"minItems", s.MinItems}, {"maxItems", s.MaxItems}, {"minProperties", s.MinProperties}, {"maxProperties", s.MaxProperties}} {
+		if n.value != nil {
+			add(n.keyword, *n.value)
+		}
+	}
+	if s.UniqueItems != nil && *s.UniqueItems {
+		add("uniqueItems", true)
+	}
+	if ap := s.AdditionalProperties; ap != nil && ap.Boolean != nil && !*ap.Boolean {
+		add("additionalProperties", false)
+	}
+	if s.ReadOnly != nil && *s.ReadOnly {
+		add("readOnly", true)
+	}
+	if s.WriteOnly != nil && *s.WriteOnly {
+		add("writeOnly", true)
+	}
+	if len(s.Dependencies) > 0 {
+		keys := make([]string, 0, len(s.Dependencies))
+		for k, dep := range s.Dependencies {
+			if dep != nil && dep.StringArray != nil {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			add("dependencies."+k, *s.Dependencies[k].StringArray)
+		}
+	}
+	return out
+}
+
+// valueTypes returns the JSON types of the const and enum values of s.
+func valueTypes(s *jsm07.Schema) []string {
+	var values []json.RawMessage
+	if s.Const != nil {
+		values = append(values, *s.Const)
+	}
+	for _, v := range s.Enumeration {
+		if bs, err := json.Marshal(&v); err == nil {
+			values = append(values, bs)
+		}
+	}
+
+	var types []string
+	seen := make(map[string]bool)
+	for _, v := range values {
+		var t string
+		switch v = bytes.TrimSpace(v); {
+		case len(v) == 0:
+			continue
+		case v[0] == '"':
+			t = "string"
+		case v[0] == '{':
+			t = "object"
+		case v[0] == '[':
+			t = "array"
+		case v[0] == 't' || v[0] == 'f':
+			t = "boolean"
+		case v[0] == 'n':
+			t = "null"
+		default:
+			t = "number"
+		}
+		if !seen[t] {
+			seen[t] = true
+			types = append(types, t)
+		}
+	}
+	return types
+}
+
+func rawString(raw *json.RawMessage) string {
+	if raw == nil {
+		return ""
+	}
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, *raw); err != nil {
+		return string(*raw)
+	}
+	return buf.String()
+}
+
+// group puts a union or intersection in parentheses.
+func group(spans []span) []span {
+	level := 0
+	for _, s := range spans {
+		switch s.text {
+		case "(", "[":
+			level++
+		case ")", "]":
+			level--
+		case " | ", " & ":
+			if level == 0 {
+				return append(append(text("("), spans...), span{text: ")"})
+			}
+		default:
+		}
+	}
+	return spans
+}
+
+func text(s string) []span {
+	return []span{{text: s}}
+}
+
+// joinSpans joins the parts with sep, in parentheses if paren is set and
+// there is more than one part.
+func joinSpans(parts [][]span, sep string, paren bool) []span {
+	paren = paren && len(parts) > 1
+	var out []span
+	if paren {
+		out = append(out, span{text: "("})
+	}
+	for i, part := range parts {
+		if i > 0 {
+			out = append(out, span{text: sep})
+		}
+		out = append(out, part...)
+	}
+	if paren {
+		out = append(out, span{text: ")"})
+	}
+	return out
+}
+
+func unescape(token string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+}
@@ -0,0 +1,137 @@
+package docgen
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/genelet/hclschema/jsm07"
+)
+
+const serverSchema = `{
+	"title": "Server config",
+	"description": "The configuration of a server.",
+	"type": "object",
+	"required": ["name"],
+	"properties": {
+		"name": {"type": "string", "minLength": 1, "description": "The server name."},
+		"port": {"type": ["integer", "null"], "minimum": 1, "maximum": 65535, "default": 8080},
+		"role": {"$ref": "#/definitions/Role"},
+		"listeners": {"type": "array", "items": {"$ref": "#/definitions/Listener"}},
+		"tls": {
+			"type": "object",
+			"required": ["cert"],
+			"properties": {"cert": {"type": "string", "description": "Path | file"}}
+		}
+	},
+	"definitions": {
+		"Role": {"type": "string", "enum": ["primary", "replica"], "description": "The role of the server."},
+		"Listener": {
+			"type": "object",
+			"properties": {
+				"url": {"type": "string", "format": "uri"},
+				"timeout": {"oneOf": [{"type": "string"}, {"type": "number"}]}
+			},
+			"additionalProperties": false
+		}
+	}
+}`
+
+func TestMarkdown(t *testing.T) {
+	schema := new(jsm07.Schema)
+	if err := json.Unmarshal([]byte(serverSchema), schema); err != nil {
+		t.Fatalf("Failed to unmarshal schema: %v", err)
+	}
+	bs, err := Markdown(schema, nil)
+	if err != nil {
+		t.Fatalf("Failed to generate: %v", err)
+	}
+	expected := "# Server config\n" +
+		"\n" +
+		"The configuration of a server.\n" +
+		"\n" +
+		"**Type:** object\n" +
+		"\n" +
+		"| Property | Type | Required | Constraints | Default | Description |\n" +
+		"| --- | --- | --- | --- | --- | --- |\n" +
+		"| `name` | string | yes | `minLength: 1` |  | The server name. |\n" +
+		"| `port` | integer \\| null |  | `minimum: 1`, `maximum: 65535` | `8080` |  |\n" +
+		"| `role` | [Role](#role) |  |  |  |  |\n" +
+		"| `listeners` | array of [Listener](#listener) |  |  |  |  |\n" +
+		"| `tls` | object |  |  |  |  |\n" +
+		"| `tls.cert` | string | yes |  |  | Path \\| file |\n" +
+		"\n" +
+		"## Definitions\n" +
+		"\n" +
+		"### Role\n" +
+		"\n" +
+		"The role of the server.\n" +
+		"\n" +
+		"**Type:** string\n" +
+		"\n" +
+		"**Constraints:** `enum: [\"primary\",\"replica\"]`\n" +
+		"\n" +
+		"### Listener\n" +
+		"\n" +
+		"**Type:** object\n" +
+		"\n" +
+		"**Constraints:** `additionalProperties: false`\n" +
+		"\n" +
+		"| Property | Type | Required | Constraints | Default | Description |\n" +
+		"| --- | --- | --- | --- | --- | --- |\n" +
+		"| `url` | string |  | `format: \"uri\"` |  |  |\n" +
+		"| `timeout` | string \\| number |  |  |  |  |\n"
+	if string(bs) != expected {
+		t.Errorf("Expected\n%s\ngot\n%s", expected, bs)
+	}
+}
+
+func TestHTML(t *testing.T) {
+	schema := new(jsm07.Schema)
+	if err := json.Unmarshal([]byte(serverSchema), schema); err != nil {
+		t.Fatalf("Failed to unmarshal schema: %v", err)
+	}
+	bs, err := HTML(schema, &Options{Title: "Servers & ports"})
+	if err != nil {
+		t.Fatalf("Failed to generate: %v", err)
+	}
+	for _, expected := range []string{
+		"<title>Servers &amp; ports</title>",
+		`<section id="role">`,
+		`<tr><td><code>role</code></td><td><a href="#role">Role</a></td><td></td><td></td><td></td><td></td></tr>`,
+		`<td><code>8080</code></td>`,
+		"<td>Path | file</td>",
+	} {
+		if !strings.Contains(string(bs), expected) {
+			t.Errorf("Expected %q in\n%s", expected, bs)
+		}
+	}
+}
+
+func TestMarkdownMCP(t *testing.T) {
+	bs, err := os.ReadFile("../jsm07/samples/mcp.json")
+	if err != nil {
+		t.Fatalf("Failed to read mcp.json: %v", err)
+	}
+	schema := new(jsm07.Schema)
+	if err := json.Unmarshal(bs, schema); err != nil {
+		t.Fatalf("Failed to unmarshal mcp.json: %v", err)
+	}
+	md, err := Markdown(schema, nil)
+	if err != nil {
+		t.Fatalf("Failed to generate: %v", err)
+	}
+	doc := string(md)
+	for _, k := range schema.OrderedKeys("definitions") {
+		if !strings.Contains(doc, "\n### "+k+"\n") {
+			t.Errorf("Expected a section for %s", k)
+		}
+	}
+	if !strings.Contains(doc, "| `params.name` | string | yes |") {
+		t.Errorf("Expected nested properties of CallToolRequest")
+	}
+	if !strings.Contains(doc, "[CallToolRequest](#calltoolrequest)") {
+		t.Errorf("Expected links to CallToolRequest")
+	}
+}
@@ -0,0 +1,90 @@
+package docgen
+
+import (
+	"bytes"
+	"html"
+	"strings"
+)
+
+func writeHTML(buf *bytes.Buffer, d *document) {
+	buf.WriteString("<!DOCTYPE html>\n<html>\n<head>\n<meta charset=\"utf-8\">\n")
+	buf.WriteString("<title>" + html.EscapeString(d.title) + "</title>\n</head>\n<body>\n")
+	buf.WriteString("<h1>" + html.EscapeString(d.title) + "</h1>\n")
+	writeHTMLText(buf, d.description)
+	if d.root != nil {
+		writeHTMLSection(buf, d.root)
+	}
+	if len(d.definitions) > 0 {
+		buf.WriteString("<h2>Definitions</h2>\n")
+		for _, s := range d.definitions {
+			buf.WriteString("<section id=\"" + html.EscapeString(s.anchor) + "\">\n")
+			buf.WriteString("<h3>" + html.EscapeString(s.name) + "</h3>\n")
+			writeHTMLText(buf, s.description)
+			writeHTMLSection(buf, s)
+			buf.WriteString("</section>\n")
+		}
+	}
+	buf.WriteString("</body>\n</html>\n")
+}
+
+func writeHTMLSection(buf *bytes.Buffer, s *section) {
+	buf.WriteString("<p><strong>Type:</strong> " + htmlType(s.typ) + "</p>\n")
+	if len(s.constraints) > 0 {
+		buf.WriteString("<p><strong>Constraints:</strong> " + htmlCodes(s.constraints) + "</p>\n")
+	}
+	if s.dflt != "" {
+		buf.WriteString("<p><strong>Default:</strong> " + htmlCode(s.dflt) + "</p>\n")
+	}
+	if len(s.properties) == 0 {
+		return
+	}
+
+	buf.WriteString("<table>\n<thead>\n<tr><th>Property</th><th>Type</th><th>Required</th><th>Constraints</th><th>Default</th><th>Description</th></tr>\n</thead>\n<tbody>\n")
+	for _, r := range s.properties {
+		required := ""
+		if r.required {
+			required = "yes"
+		}
+		dflt := ""
+		if r.dflt != "" {
+			dflt = htmlCode(r.dflt)
+		}
+		cells := []string{htmlCode(r.name), htmlType(r.typ), required, htmlCodes(r.constraints), dflt, html.EscapeString(r.description)}
+		buf.WriteString("<tr><td>" + strings.Join(cells, "</td><td>") + "</td></tr>\n")
+	}
+	buf.WriteString("</tbody>\n</table>\n")
+}
+
+// writeHTMLText writes text as paragraphs separated by blank lines.
+func writeHTMLText(buf *bytes.Buffer, text string) {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	for _, p := range strings.Split(text, "\n\n") {
+		if p = strings.TrimSpace(p); p != "" {
+			buf.WriteString("<p>" + html.EscapeString(p) + "</p>\n")
+		}
+	}
+}
+
+func htmlType(spans []span) string {
+	var b strings.Builder
+	for _, s := range spans {
+		if s.href != "" {
+			b.WriteString("<a href=\"" + html.EscapeString(s.href) + "\">" + html.EscapeString(s.text) + "</a>")
+		} else {
+			b.WriteString(html.EscapeString(s.text))
+		}
+	}
+	return b.String()
+}
+
+func htmlCodes(codes []string) string {
+	out := make([]string, len(codes))
+	for i, c := range codes {
+		out[i] = htmlCode(c)
+	}
+	return strings.Join(out, ", ")
+}
+
+func htmlCode(s string) string {
+	return "<code>" + html.EscapeString(s) + "</code>"
+}
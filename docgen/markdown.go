@@ -0,0 +1,99 @@
+package docgen
+
+import (
+	"bytes"
+	"strings"
+)
+
+func writeMarkdown(buf *bytes.Buffer, d *document) {
+	buf.WriteString("# " + d.title + "\n")
+	if d.description != "" {
+		buf.WriteString("\n" + d.description + "\n")
+	}
+	if d.root != nil {
+		writeMarkdownSection(buf, d.root)
+	}
+	if len(d.definitions) == 0 {
+		return
+	}
+	buf.WriteString("\n## Definitions\n")
+	for _, s := range d.definitions {
+		buf.WriteString("\n### " + s.name + "\n")
+		if s.description != "" {
+			buf.WriteString("\n" + s.description + "\n")
+		}
+		writeMarkdownSection(buf, s)
+	}
+}
+
+func writeMarkdownSection(buf *bytes.Buffer, s *section) {
+	buf.WriteString("\n**Type:** " + markdownType(s.typ) + "\n")
+	if len(s.constraints) > 0 {
+		buf.WriteString("\n**Constraints:** " + markdownCodes(s.constraints) + "\n")
+	}
+	if s.dflt != "" {
+		buf.WriteString("\n**Default:** " + markdownCode(s.dflt) + "\n")
+	}
+	if len(s.properties) == 0 {
+		return
+	}
+
+	buf.WriteString("\n| Property | Type | Required | Constraints | Default | Description |\n")
+	buf.WriteString("| --- | --- | --- | --- | --- | --- |\n")
+	for _, r := range s.properties {
+		required := ""
+		if r.required {
+			required = "yes"
+		}
+		dflt := ""
+		if r.dflt != "" {
+			dflt = markdownCode(r.dflt)
+		}
+		cells := []string{markdownCode(r.name), markdownType(r.typ), required, markdownCodes(r.constraints), dflt, r.description}
+		for i, cell := range cells {
+			cells[i] = markdownCell(cell)
+		}
+		buf.WriteString("| " + strings.Join(cells, " | ") + " |\n")
+	}
+}
+
+func markdownType(spans []span) string {
+	var b strings.Builder
+	for _, s := range spans {
+		if s.href != "" {
+			b.WriteString("[" + s.text + "](" + s.href + ")")
+		} else {
+			b.WriteString(s.text)
+		}
+	}
+	return b.String()
+}
+
+func markdownCodes(codes []string) string {
+	out := make([]string, len(codes))
+	for i, c := range codes {
+		out[i] = markdownCode(c)
+	}
+	return strings.Join(out, ", ")
+}
+
+// markdownCode returns s as a code span, fenced by more backticks than it
+// holds in a row.
+func markdownCode(s string) string {
+	fence := "`"
+	for strings.Contains(s, fence) {
+		fence += "`"
+	}
+	if strings.HasPrefix(s, "`") || strings.HasSuffix(s, "`") {
+		s = " " + s + " "
+	}
+	return fence + s + fence
+}
+
+// markdownCell escapes s for a table cell.
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	s = strings.ReplaceAll(strings.TrimSpace(s), "\n\n", "<br><br>")
+	return strings.ReplaceAll(s, "\n", " ")
+}
@@ -0,0 +1,208 @@
+// Package lightexpr converts between constant HCL expressions, as modeled
+// by hcllight, and decoded JSON values.
+package lightexpr
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/genelet/hcllight/light"
+)
+
+// ToInterface evaluates a constant HCL expression to a decoded JSON value:
+// string, float64, bool, []interface{} or map[string]interface{}.
+func ToInterface(expr *light.Expression) (interface{}, error) {
+	if expr == nil {
+		return nil, nil
+	}
+
+	switch expr.ExpressionClause.(type) {
+	case *light.Expression_Lvexpr:
+		return light.LiteralValueExprToInterface(expr), nil
+	case *light.Expression_Texpr:
+		parts := expr.GetTexpr().Parts
+		if len(parts) == 0 {
+			return "", nil
+		}
+		if len(parts) == 1 && parts[0].GetLvexpr() != nil {
+			return *light.TextValueExprToString(expr), nil
+		}
+		return nil, fmt.Errorf("template interpolation is not supported")
+	case *light.Expression_Tcexpr:
+		arr := []interface{}{}
+		for _, x := range expr.GetTcexpr().Exprs {
+			v, err := ToInterface(x)
+			if err != nil {
+				return nil, err
+			}
+			arr = append(arr, v)
+		}
+		return arr, nil
+	case *light.Expression_Ocexpr:
+		obj := make(map[string]interface{})
+		for _, item := range expr.GetOcexpr().Items {
+			k := light.KeyValueExprToString(item.KeyExpr)
+			if k == nil {
+				return nil, fmt.Errorf("object key must be a name or a string")
+			}
+			v, err := ToInterface(item.ValueExpr)
+			if err != nil {
+				return nil, err
+			}
+			obj[*k] = v
+		}
+		return obj, nil
+	case *light.Expression_Uoexpr:
+		// the operator is not kept by light, but "-" is the only unary
+		// operator on numbers and "!" the only one on booleans
+		v, err := ToInterface(expr.GetUoexpr().Val)
+		if err != nil {
+			return nil, err
+		}
+		switch t := v.(type) {
+		case float64:
+			return -t, nil
+		case bool:
+			return !t, nil
+		default:
+		}
+		return nil, fmt.Errorf("unary operator on %T is not supported", v)
+	case *light.Expression_Pexpr:
+		return ToInterface(expr.GetPexpr().Expr)
+	default:
+	}
+
+	return nil, fmt.Errorf("not supported expression: %#v", expr)
+}
+
+// ToRaw evaluates a constant HCL expression to JSON.
+func ToRaw(expr *light.Expression) (*json.RawMessage, error) {
+	v, err := ToInterface(expr)
+	if err != nil {
+		return nil, err
+	}
+	bs, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	raw := json.RawMessage(bs)
+	return &raw, nil
+}
+
+// FromInterface builds the HCL expression of a decoded JSON value.
+// Object keys are written in sorted order, null as the keyword null, and
+// numbers with all the digits needed to read them back exactly; a
+// json.Number is written as is.
+func FromInterface(v interface{}) *light.Expression {
+	switch t := v.(type) {
+	case nil:
+		return light.StringToTraversal("null")
+	case string:
+		return String(t)
+	case bool:
+		return light.BooleanToLiteralValueExpr(t)
+	case float64:
+		if t == math.Trunc(t) && math.Abs(t) < 1<<63 {
+			return Number(strconv.FormatInt(int64(t), 10))
+		}
+		return Number(strconv.FormatFloat(t, 'g', -1, 64))
+	case json.Number:
+		return Number(t.String())
+	case []interface{}:
+		var exprs []*light.Expression
+		for _, x := range t {
+			exprs = append(exprs, FromInterface(x))
+		}
+		return &light.Expression{
+			ExpressionClause: &light.Expression_Tcexpr{
+				Tcexpr: &light.TupleConsExpr{Exprs: exprs},
+			},
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(t))
+		for k := range t {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		var items []*light.ObjectConsItem
+		for _, k := range keys {
+			items = append(items, &light.ObjectConsItem{
+				KeyExpr:   String(k),
+				ValueExpr: FromInterface(t[k]),
+			})
+		}
+		return &light.Expression{
+			ExpressionClause: &light.Expression_Ocexpr{
+				Ocexpr: &light.ObjectConsExpr{Items: items},
+			},
+		}
+	default:
+	}
+	return light.StringToTextValueExpr(fmt.Sprintf("%v", v))
+}
+
+// FromRaw builds the HCL expression of a JSON document, keeping its
+// numbers as written.
+func FromRaw(raw json.RawMessage) (*light.Expression, error) {
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return FromInterface(v), nil
+}
+
+// Number builds a number literal from its JSON text, which is also valid
+// HCL. light formats number values with a fixed precision, so the text is
+// carried in a literal that light writes verbatim.
+func Number(text string) *light.Expression {
+	return &light.Expression{
+		ExpressionClause: &light.Expression_Lvexpr{
+			Lvexpr: &light.LiteralValueExpr{
+				Val: &light.CtyValue{
+					CtyValueClause: &light.CtyValue_StringValue{StringValue: text},
+				},
+			},
+		},
+	}
+}
+
+// String builds the HCL expression of a quoted string. Unlike
+// light.StringToTextValueExpr, it keeps the string as is: backslashes,
+// quotes and control characters are escaped, and so are the template
+// sequences ${ and %{.
+func String(s string) *light.Expression {
+	return &light.Expression{
+		ExpressionClause: &light.Expression_Texpr{
+			Texpr: &light.TemplateExpr{
+				Parts: []*light.Expression{{
+					ExpressionClause: &light.Expression_Lvexpr{
+						Lvexpr: &light.LiteralValueExpr{
+							Val: &light.CtyValue{
+								CtyValueClause: &light.CtyValue_StringValue{
+									StringValue: stringEscaper.Replace(s),
+								},
+							},
+						},
+					},
+				}},
+			},
+		},
+	}
+}
+
+var stringEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	`"`, `\"`,
+	"\n", `\n`,
+	"\r", `\r`,
+	"\t", `\t`,
+	"${", "$${",
+	"%{", "%%{",
+)
@@ -0,0 +1,168 @@
+package jsm07
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// Bundle returns a self-contained copy of root. Every schema that root
+// references in another document, directly or through other documents, is
+// fetched through loader, copied into the definitions of the result under
+// a name not used before, and the reference is rewritten to a local
+// pointer such as "#/definitions/Role". References within root are kept.
+//
+// baseURI is the URI root was retrieved from; it may be empty if root
+// declares an absolute $id or only references absolute URIs. Nested $id
+// keywords that would change the base URI are removed from the result,
+// so that all rewritten references resolve against the root.
+func Bundle(root *Schema, baseURI string, loader Loader) (*Schema, error) {
+	r, err := NewResolver(root, baseURI, loader)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &bundler{
+		resolver: r,
+		names:    make(map[string]string),
+		taken:    make(map[string]bool),
+	}
+	for k := range root.Definitions {
+		b.taken[k] = true
+	}
+
+	c, err := b.rewrite(NewCombinedWithSchema(root), true, false)
+	if err != nil {
+		return nil, err
+	}
+	bundled := c.Schema
+
+	// copying a definition may discover more external references
+	for i := 0; i < len(b.pending); i++ {
+		p := b.pending[i]
+		def, err := b.rewrite(p.target, false, true)
+		if err != nil {
+			return nil, err
+		}
+		if bundled.Definitions == nil {
+			bundled.Definitions = make(map[string]*Combined)
+		}
+		bundled.Definitions[p.name] = def
+	}
+
+	return bundled, nil
+}
+
+type pendingDefinition struct {
+	name   string
+	target *Combined
+}
+
+type bundler struct {
+	resolver *Resolver
+	// names maps the absolute URI of each bundled target to its definition
+	names   map[string]string
+	taken   map[string]bool
+	pending []pendingDefinition
+}
+
+// rewrite copies c, rewriting references to other documents. In copies
+// of other documents every $id is removed; in root only those that would
+// change the base URI.
+func (self *bundler) rewrite(c *Combined, top, copied bool) (*Combined, error) {
+	if c == nil {
+		return nil, nil
+	}
+	if c.Schema == nil {
+		if c.Boolean == nil {
+			return &Combined{}, nil
+		}
+		return NewCombinedWithBoolean(*c.Boolean), nil
+	}
+
+	s := c.Schema
+	out, err := mapChildren(s, func(child *Combined) (*Combined, error) {
+		return self.rewrite(child, false, copied)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if out.ID != nil && !top && (copied || !strings.HasPrefix(*out.ID, "#")) {
+		out.ID = nil
+	}
+	if copied {
+		// $schema is only meaningful at the root
+		out.Schema = nil
+	}
+
+	if s.Ref != nil {
+		ref, err := self.localRef(self.resolver.BaseURI(s), *s.Ref)
+		if err != nil {
+			return nil, err
+		}
+		out.Ref = &ref
+	}
+
+	return NewCombinedWithSchema(out), nil
+}
+
+// localRef returns a reference local to the root that stands for ref,
+// scheduling the target to be copied if it is in another document.
+func (self *bundler) localRef(base, ref string) (string, error) {
+	b, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	u, err := b.Parse(ref)
+	if err != nil {
+		return "", fmt.Errorf("invalid reference %q: %w", ref, err)
+	}
+
+	if c, ok := self.resolver.resources[withoutFragment(u)]; ok && c.Schema == self.resolver.root {
+		if u.Fragment == "" {
+			return "#", nil
+		}
+		return "#" + u.EscapedFragment(), nil
+	}
+
+	key := u.String()
+	name, ok := self.names[key]
+	if !ok {
+		target, _, err := self.resolver.Resolve(base, ref)
+		if err != nil {
+			return "", err
+		}
+		name = self.newName(u)
+		self.names[key] = name
+		self.pending = append(self.pending, pendingDefinition{name: name, target: target})
+	}
+
+	x := &url.URL{Fragment: pointerAppend("/definitions", name)}
+	return "#" + x.EscapedFragment(), nil
+}
+
+// newName derives a definition name from the last pointer token or anchor
+// of u, or else from its file name, adding a number if it is taken.
+func (self *bundler) newName(u *url.URL) string {
+	name := u.Fragment
+	if strings.HasPrefix(name, "/") {
+		if tokens, err := splitPointer(name); err == nil {
+			name = tokens[len(tokens)-1]
+		}
+	}
+	if name == "" {
+		name = strings.TrimSuffix(path.Base(u.Path), path.Ext(u.Path))
+	}
+	if name == "" || name == "." || name == "/" {
+		name = "schema"
+	}
+
+	candidate := name
+	for i := 2; self.taken[candidate]; i++ {
+		candidate = fmt.Sprintf("%s_%d", name, i)
+	}
+	self.taken[candidate] = true
+	return candidate
+}
@@ -0,0 +1,93 @@
+package jsm07
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestBundle(t *testing.T) {
+	root, err := ParseSchema([]byte(`
+  _id = "http://example.com/schemas/root.hcl"
+  type = "object"
+  definitions "Role" {
+    type = "string"
+  }
+  properties "role" {
+    _ref = "#/definitions/Role"
+  }
+  properties "author" {
+    _ref = "people.json#/definitions/Role"
+  }
+  properties "editor" {
+    _ref = "http://example.com/schemas/people.json#/definitions/Role"
+  }
+  properties "address" {
+    _ref = "address.json"
+  }
+`))
+	if err != nil {
+		t.Fatalf("Failed to parse root: %v", err)
+	}
+	loader := MapLoader{
+		"http://example.com/schemas/people.json": mustSchema(t, `{
+			"$schema": "http://json-schema.org/draft-07/schema#",
+			"definitions": {
+				"Role": {"type": "object", "required": ["name"], "properties": {"name": {"$ref": "#/definitions/Name"}}},
+				"Name": {"type": "string", "minLength": 1}
+			}
+		}`),
+		"http://example.com/schemas/address.json": mustSchema(t, `{
+			"$id": "http://example.com/schemas/address.json",
+			"type": "object",
+			"properties": {"city": {"type": "string"}}
+		}`),
+	}
+
+	bundled, err := Bundle(root, "", loader)
+	if err != nil {
+		t.Fatalf("Failed to bundle: %v", err)
+	}
+
+	expected := map[string]string{
+		"role":    "#/definitions/Role",
+		"author":  "#/definitions/Role_2",
+		"editor":  "#/definitions/Role_2",
+		"address": "#/definitions/address",
+	}
+	for k, ref := range expected {
+		p := bundled.Properties[k]
+		if p == nil || p.Schema.Ref == nil || *p.Schema.Ref != ref {
+			t.Errorf("Expected %s to reference %s, got %#v", k, ref, p)
+		}
+	}
+	if len(bundled.Definitions) != 4 {
+		t.Errorf("Expected 4 definitions, got %d", len(bundled.Definitions))
+	}
+	if ref := bundled.Definitions["Role_2"].Schema.Properties["name"].Schema.Ref; ref == nil || *ref != "#/definitions/Name" {
+		t.Errorf("Expected nested reference to be bundled, got %v", ref)
+	}
+	if bundled.Definitions["address"].Schema.ID != nil {
+		t.Error("Expected $id to be removed from bundled definition")
+	}
+	if bundled.Definitions["Role_2"].Schema.Schema != nil {
+		t.Error("Expected $schema to be removed from bundled definition")
+	}
+
+	// the bundle validates alone
+	bs, err := json.Marshal(bundled)
+	if err != nil {
+		t.Fatalf("Failed to marshal bundle: %v", err)
+	}
+	if strings.Contains(string(bs), ".json") {
+		t.Errorf("Expected no external references in %s", bs)
+	}
+	var instance interface{}
+	if err := json.Unmarshal([]byte(`{"role": "x", "author": {"name": ""}, "address": {"city": "Paris"}}`), &instance); err != nil {
+		t.Fatal(err)
+	}
+	err = bundled.Validate(instance)
+	if errs, ok := err.(ValidationErrors); !ok || len(errs) != 1 || errs[0].InstancePath != "/author/name" {
+		t.Errorf("Expected one error at /author/name, got %v", err)
+	}
+}
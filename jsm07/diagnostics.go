@@ -0,0 +1,85 @@
+package jsm07
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+)
+
+// ParseOptions controls how ParseSchemaFile reads an HCL schema.
+type ParseOptions struct {
+	// Filename is reported in the ranges of diagnostics.
+	Filename string
+	// Strict reports unknown attributes and blocks as errors instead of warnings.
+	Strict bool
+}
+
+// ParseSchemaFile parses HCL data representing a JSON schema, like
+// ParseSchema, and reports every problem found as a diagnostic carrying
+// the file name, line and column range of the offending construct.
+// The schema is nil if the diagnostics contain errors.
+func ParseSchemaFile(data []byte, opts *ParseOptions) (*Schema, hcl.Diagnostics) {
+	if opts == nil {
+		opts = &ParseOptions{}
+	}
+
+	file, diags := hclsyntax.ParseConfig(data, opts.Filename, hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	body, ok := file.Body.(*hclsyntax.Body)
+	if !ok {
+		return nil, diags
+	}
+
+	p := &parser{strict: opts.Strict}
+	schema := p.schemaBody(body)
+	diags = append(diags, p.diags...)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	return schema, diags
+}
+
+// parser builds a Schema from HCL syntax, collecting a diagnostic for
+// every problem instead of stopping at the first.
+type parser struct {
+	strict bool
+	diags  hcl.Diagnostics
+}
+
+func (self *parser) unknown(what, name string, rng hcl.Range) {
+	severity := hcl.DiagWarning
+	if self.strict {
+		severity = hcl.DiagError
+	}
+	self.diags = append(self.diags, &hcl.Diagnostic{
+		Severity: severity,
+		Summary:  fmt.Sprintf("Unknown %s", what),
+		Detail:   fmt.Sprintf("%q is not a draft-07 schema keyword.", name),
+		Subject:  rng.Ptr(),
+	})
+}
+
+func (self *parser) invalid(summary string, rng hcl.Range, format string, args ...interface{}) {
+	self.diags = append(self.diags, &hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  summary,
+		Detail:   fmt.Sprintf(format, args...),
+		Subject:  rng.Ptr(),
+	})
+}
+
+// sortedAttributes returns the attributes of body in source order.
+func sortedAttributes(body *hclsyntax.Body) []*hclsyntax.Attribute {
+	attrs := make([]*hclsyntax.Attribute, 0, len(body.Attributes))
+	for _, attr := range body.Attributes {
+		attrs = append(attrs, attr)
+	}
+	sort.Slice(attrs, func(i, j int) bool {
+		return attrs[i].SrcRange.Start.Byte < attrs[j].SrcRange.Start.Byte
+	})
+	return attrs
+}
@@ -0,0 +1,130 @@
+package jsm07
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+)
+
+func TestParseSchemaFileDiagnostics(t *testing.T) {
+	data := []byte(`type = "object"
+maxLength = "ten"
+minimun = 1
+properties "a" {
+  type = "integer"
+  minimum = -2
+}
+definitions {
+  type = "string"
+}
+`)
+
+	_, diags := ParseSchemaFile(data, &ParseOptions{Filename: "schema.hcl"})
+	if !diags.HasErrors() {
+		t.Fatal("Expected errors")
+	}
+
+	expected := []struct {
+		severity hcl.DiagnosticSeverity
+		summary  string
+		line     int
+		column   int
+	}{
+		{hcl.DiagError, "Invalid value type", 2, 13},
+		{hcl.DiagWarning, "Unknown attribute", 3, 1},
+		{hcl.DiagError, "Missing block label", 8, 1},
+	}
+	if len(diags) != len(expected) {
+		t.Fatalf("Expected %d diagnostics, got %v", len(expected), diags)
+	}
+	for i, e := range expected {
+		d := diags[i]
+		if d.Severity != e.severity || d.Summary != e.summary {
+			t.Errorf("Expected %v %q, got %v %q", e.severity, e.summary, d.Severity, d.Summary)
+		}
+		if d.Subject == nil || d.Subject.Filename != "schema.hcl" || d.Subject.Start.Line != e.line || d.Subject.Start.Column != e.column {
+			t.Errorf("Expected schema.hcl:%d,%d, got %v", e.line, e.column, d.Subject)
+		}
+	}
+}
+
+func TestParseSchemaFileInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		summary string
+		line    int
+		column  int
+	}{
+		{"attribute", "type = \"string\"\nexamples = \"a${x}\"\n", "Invalid value", 2, 12},
+		{"nested", "properties \"a\" {\n  type = \"array\"\n  items {\n    enum = [1, [2]]\n  }\n}\n", "Invalid value type", 4, 16},
+		{"dependencies", "dependencies \"a\" {\n  minLength = -1\n}\n", "Invalid value type", 2, 15},
+		{"inline", "additionalProperties = {\n  enum = [\"a\", {x = 1}]\n}\n", "Invalid value type", 2, 16},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, diags := ParseSchemaFile([]byte(tt.data), &ParseOptions{Filename: "schema.hcl"})
+			if len(diags) != 1 || diags[0].Summary != tt.summary {
+				t.Fatalf("Expected one %q error, got %v", tt.summary, diags)
+			}
+			d := diags[0]
+			if d.Subject == nil || d.Subject.Filename != "schema.hcl" || d.Subject.Start.Line != tt.line || d.Subject.Start.Column != tt.column {
+				t.Errorf("Expected schema.hcl:%d,%d, got %v", tt.line, tt.column, d.Subject)
+			}
+		})
+	}
+}
+
+func TestParseSchemaEnum(t *testing.T) {
+	s, err := ParseSchema([]byte(`enum = [80, -1, 0.5, 1e-7, null, "a", true]
+`))
+	if err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+	bs, err := json.Marshal(s.Enumeration)
+	if err != nil {
+		t.Fatalf("Failed to marshal enum: %v", err)
+	}
+	if string(bs) != `[80,-1,0.5,1e-7,null,"a",true]` {
+		t.Errorf("Unexpected enum %s", bs)
+	}
+}
+
+func TestParseSchemaFileStrict(t *testing.T) {
+	data := []byte(`type = "string"
+markdownDescription = "text"
+`)
+
+	s, diags := ParseSchemaFile(data, nil)
+	if diags.HasErrors() || len(diags) != 1 {
+		t.Fatalf("Expected one warning, got %v", diags)
+	}
+	if s == nil || s.Type == nil || *s.Type.String != "string" {
+		t.Fatalf("Expected schema of type string, got %#v", s)
+	}
+
+	_, diags = ParseSchemaFile(data, &ParseOptions{Strict: true})
+	if !diags.HasErrors() {
+		t.Fatal("Expected unknown keyword to be an error in strict mode")
+	}
+}
+
+func TestParseSchemaNumbers(t *testing.T) {
+	s, err := ParseSchema([]byte(`minimum = -2
+maximum = 0.5
+multipleOf = 0.25
+`))
+	if err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+	if s.Minimum == nil || s.Minimum.Integer == nil || *s.Minimum.Integer != -2 {
+		t.Errorf("Expected minimum -2, got %#v", s.Minimum)
+	}
+	if s.Maximum == nil || s.Maximum.Float == nil || *s.Maximum.Float != 0.5 {
+		t.Errorf("Expected maximum 0.5, got %#v", s.Maximum)
+	}
+	if s.MultipleOf == nil || s.MultipleOf.Float == nil || *s.MultipleOf.Float != 0.25 {
+		t.Errorf("Expected multipleOf 0.25, got %#v", s.MultipleOf)
+	}
+}
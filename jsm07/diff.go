@@ -0,0 +1,689 @@
+package jsm07
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+)
+
+// ChangeKind names the kind of a Change.
+type ChangeKind string
+
+const (
+	PropertyAdded       ChangeKind = "property added"
+	PropertyRemoved     ChangeKind = "property removed"
+	RequiredAdded       ChangeKind = "required added"
+	RequiredRemoved     ChangeKind = "required removed"
+	TypeChanged         ChangeKind = "type changed"
+	EnumValueAdded      ChangeKind = "enum value added"
+	EnumValueRemoved    ChangeKind = "enum value removed"
+	ConstraintTightened ChangeKind = "constraint tightened"
+	ConstraintLoosened  ChangeKind = "constraint loosened"
+	ConstraintChanged   ChangeKind = "constraint changed"
+	SchemaChanged       ChangeKind = "schema changed"
+	DefinitionAdded     ChangeKind = "definition added"
+	DefinitionRemoved   ChangeKind = "definition removed"
+	DefaultChanged      ChangeKind = "default changed"
+)
+
+// Change describes one difference between two schemas.
+//
+// A change that makes a value valid under the old schema invalid under
+// the new one breaks producers, whose existing documents and output may
+// no longer validate. A change that lets the new schema accept a value
+// the old one rejected breaks consumers, which may receive values they
+// were not written to handle.
+type Change struct {
+	// Pointer is a JSON Pointer to the changed schema, the same in both.
+	Pointer         string
+	Keyword         string
+	Kind            ChangeKind
+	Message         string
+	BreaksProducers bool
+	BreaksConsumers bool
+}
+
+// Breaking reports whether the change breaks producers or consumers.
+func (self *Change) Breaking() bool {
+	return self.BreaksProducers || self.BreaksConsumers
+}
+
+func (self *Change) String() string {
+	path := self.Pointer
+	if path == "" {
+		path = "/"
+	}
+	var breaks []string
+	if self.BreaksProducers {
+		breaks = append(breaks, "producers")
+	}
+	if self.BreaksConsumers {
+		breaks = append(breaks, "consumers")
+	}
+	if len(breaks) == 0 {
+		return fmt.Sprintf("%s: %s", path, self.Message)
+	}
+	return fmt.Sprintf("%s: %s (breaks %s)", path, self.Message, strings.Join(breaks, " and "))
+}
+
+// Changes is the list of changes returned by Diff.
+type Changes []*Change
+
+// Breaking returns the changes that break producers or consumers.
+func (self Changes) Breaking() Changes {
+	var out Changes
+	for _, c := range self {
+		if c.Breaking() {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// Diff compares two versions of a schema keyword by keyword, and returns
+// the changes in the order of the keywords of the new schema. References
+// are compared as strings and not followed; changes to the definitions
+// they point to are reported under /definitions. Titles, descriptions,
+// comments and examples are ignored.
+func Diff(old, new *Schema) Changes {
+	d := &differ{}
+	d.combined("", NewCombinedWithSchema(old), NewCombinedWithSchema(new), false)
+	return d.changes
+}
+
+// effect tells which side a change breaks.
+type effect int
+
+const (
+	neither effect = iota
+	tightened
+	loosened
+	both
+)
+
+type differ struct {
+	changes Changes
+}
+
+// add records a change; inverted swaps tightened and loosened, for
+// changes inside not.
+func (self *differ) add(ptr, keyword string, kind ChangeKind, eff effect, inverted bool, format string, args ...interface{}) {
+	if inverted {
+		switch eff {
+		case tightened:
+			eff = loosened
+		case loosened:
+			eff = tightened
+		default:
+		}
+		switch kind {
+		case ConstraintTightened:
+			kind = ConstraintLoosened
+		case ConstraintLoosened:
+			kind = ConstraintTightened
+		default:
+		}
+	}
+	self.changes = append(self.changes, &Change{
+		Pointer:         ptr,
+		Keyword:         keyword,
+		Kind:            kind,
+		Message:         fmt.Sprintf(format, args...),
+		BreaksProducers: eff == tightened || eff == both,
+		BreaksConsumers: eff == loosened || eff == both,
+	})
+}
+
+// isTrue tells whether a subschema is absent or true, and isFalse whether
+// it is false.
+func isTrue(c *Combined) bool {
+	return c == nil || (c.Schema == nil && (c.Boolean == nil || *c.Boolean))
+}
+
+func isFalse(c *Combined) bool {
+	return c != nil && c.Schema == nil && c.Boolean != nil && !*c.Boolean
+}
+
+func (self *differ) combined(ptr string, old, new *Combined, inv bool) {
+	switch {
+	case isTrue(old) && isTrue(new), isFalse(old) && isFalse(new):
+		return
+	case isFalse(old):
+		self.add(ptr, "", SchemaChanged, loosened, inv, "false schema now allows values")
+		return
+	case isFalse(new):
+		self.add(ptr, "", SchemaChanged, tightened, inv, "schema changed to false")
+		return
+	default:
+	}
+
+	oldSchema, newSchema := &Schema{}, &Schema{}
+	if old != nil && old.Schema != nil {
+		oldSchema = old.Schema
+	}
+	if new != nil && new.Schema != nil {
+		newSchema = new.Schema
+	}
+	self.schema(ptr, oldSchema, newSchema, inv)
+}
+
+func (self *differ) schema(ptr string, old, new *Schema, inv bool) {
+	if stringValue(old.Ref) != stringValue(new.Ref) {
+		switch {
+		case old.Ref == nil:
+			self.add(ptr, "$ref", SchemaChanged, both, inv, "$ref %q added", *new.Ref)
+		case new.Ref == nil:
+			self.add(ptr, "$ref", SchemaChanged, both, inv, "$ref %q removed", *old.Ref)
+		default:
+			self.add(ptr, "$ref", SchemaChanged, both, inv, "$ref changed from %q to %q", *old.Ref, *new.Ref)
+		}
+	}
+
+	self.types(ptr, old, new, inv)
+	self.values(ptr, old, new, inv)
+
+	self.number(ptr, "minimum", old.Minimum, new.Minimum, 1, inv)
+	self.number(ptr, "exclusiveMinimum", old.ExclusiveMinimum, new.ExclusiveMinimum, 1, inv)
+	self.number(ptr, "maximum", old.Maximum, new.Maximum, -1, inv)
+	self.number(ptr, "exclusiveMaximum", old.ExclusiveMaximum, new.ExclusiveMaximum, -1, inv)
+	self.multipleOf(ptr, old.MultipleOf, new.MultipleOf, inv)
+
+	self.count(ptr, "minLength", old.MinLength, new.MinLength, 1, inv)
+	self.count(ptr, "maxLength", old.MaxLength, new.MaxLength, -1, inv)
+	self.text(ptr, "pattern", old.Pattern, new.Pattern, inv)
+	self.text(ptr, "format", old.Format, new.Format, inv)
+
+	self.count(ptr, "minItems", old.MinItems, new.MinItems, 1, inv)
+	self.count(ptr, "maxItems", old.MaxItems, new.MaxItems, -1, inv)
+	if oldUnique, newUnique := old.UniqueItems != nil && *old.UniqueItems, new.UniqueItems != nil && *new.UniqueItems; oldUnique != newUnique {
+		if newUnique {
+			self.add(ptr, "uniqueItems", ConstraintTightened, tightened, inv, "uniqueItems added")
+		} else {
+			self.add(ptr, "uniqueItems", ConstraintLoosened, loosened, inv, "uniqueItems removed")
+		}
+	}
+	self.items(ptr, old, new, inv)
+	self.combined(pointerAppend(ptr, "contains"), old.Contains, new.Contains, inv)
+
+	self.count(ptr, "minProperties", old.MinProperties, new.MinProperties, 1, inv)
+	self.count(ptr, "maxProperties", old.MaxProperties, new.MaxProperties, -1, inv)
+	self.required(ptr, old, new, inv)
+	self.properties(ptr, "properties", old, new, inv)
+	self.properties(ptr, "patternProperties", old, new, inv)
+	self.combined(pointerAppend(ptr, "additionalProperties"), old.AdditionalProperties, new.AdditionalProperties, inv)
+	self.combined(pointerAppend(ptr, "propertyNames"), old.PropertyNames, new.PropertyNames, inv)
+	self.dependencies(ptr, old, new, inv)
+
+	// a changed condition may move values between then and else
+	if !jsonSame(old.If, new.If) {
+		self.add(pointerAppend(ptr, "if"), "if", SchemaChanged, both, inv, "if changed")
+	}
+	self.combined(pointerAppend(ptr, "then"), old.Then, new.Then, inv)
+	self.combined(pointerAppend(ptr, "else"), old.Else, new.Else, inv)
+	self.branches(ptr, "allOf", old.AllOf, new.AllOf, tightened, inv)
+	self.branches(ptr, "anyOf", old.AnyOf, new.AnyOf, loosened, inv)
+	self.branches(ptr, "oneOf", old.OneOf, new.OneOf, both, inv)
+	self.combined(pointerAppend(ptr, "not"), old.Not, new.Not, !inv)
+
+	if !jsonSame(old.Default, new.Default) {
+		self.add(ptr, "default", DefaultChanged, neither, inv, "default changed from %s to %s", rawText(old.Default), rawText(new.Default))
+	}
+	self.definitions(ptr, old, new, inv)
+}
+
+// typeSet returns the types s allows, or nil for any type.
+func typeSet(s *Schema) map[string]bool {
+	if s.Type == nil {
+		return nil
+	}
+	set := make(map[string]bool)
+	if s.Type.String != nil {
+		set[*s.Type.String] = true
+	} else if s.Type.StringArray != nil {
+		for _, t := range *s.Type.StringArray {
+			set[t] = true
+		}
+	}
+	return set
+}
+
+func (self *differ) types(ptr string, old, new *Schema, inv bool) {
+	oldSet, newSet := typeSet(old), typeSet(new)
+	switch {
+	case oldSet == nil && newSet == nil:
+		return
+	case oldSet == nil:
+		self.add(ptr, "type", TypeChanged, tightened, inv, "type %s added", typeText(new))
+		return
+	case newSet == nil:
+		self.add(ptr, "type", TypeChanged, loosened, inv, "type %s removed", typeText(old))
+		return
+	default:
+	}
+
+	// integer is a subset of number
+	has := func(set map[string]bool, t string) bool {
+		return set[t] || (t == "integer" && set["number"])
+	}
+	eff := neither
+	for t := range oldSet {
+		if !has(newSet, t) {
+			eff |= tightened
+		}
+	}
+	for t := range newSet {
+		if !has(oldSet, t) {
+			eff |= loosened
+		}
+	}
+	if eff == neither {
+		if typeText(old) != typeText(new) {
+			self.add(ptr, "type", TypeChanged, neither, inv, "type changed from %s to %s", typeText(old), typeText(new))
+		}
+		return
+	}
+	self.add(ptr, "type", TypeChanged, eff, inv, "type changed from %s to %s", typeText(old), typeText(new))
+}
+
+func typeText(s *Schema) string {
+	if s.Type.String != nil {
+		return *s.Type.String
+	}
+	if s.Type.StringArray != nil {
+		return "[" + strings.Join(*s.Type.StringArray, ", ") + "]"
+	}
+	return "[]"
+}
+
+// values compares enum and const.
+func (self *differ) values(ptr string, old, new *Schema, inv bool) {
+	switch {
+	case old.Const == nil && new.Const == nil:
+	case old.Const == nil:
+		self.add(ptr, "const", ConstraintTightened, tightened, inv, "const %s added", rawText(new.Const))
+	case new.Const == nil:
+		self.add(ptr, "const", ConstraintLoosened, loosened, inv, "const %s removed", rawText(old.Const))
+	case !jsonSame(old.Const, new.Const):
+		self.add(ptr, "const", ConstraintChanged, both, inv, "const changed from %s to %s", rawText(old.Const), rawText(new.Const))
+	default:
+	}
+
+	switch {
+	case old.Enumeration == nil && new.Enumeration == nil:
+		return
+	case old.Enumeration == nil:
+		self.add(ptr, "enum", ConstraintTightened, tightened, inv, "enum %s added", enumText(new.Enumeration))
+		return
+	case new.Enumeration == nil:
+		self.add(ptr, "enum", ConstraintLoosened, loosened, inv, "enum %s removed", enumText(old.Enumeration))
+		return
+	default:
+	}
+	in := func(list []SchemaEnumValue, v SchemaEnumValue) bool {
+		for _, x := range list {
+			if jsonEqual(enumToInterface(x), enumToInterface(v)) {
+				return true
+			}
+		}
+		return false
+	}
+	for _, v := range old.Enumeration {
+		if !in(new.Enumeration, v) {
+			self.add(ptr, "enum", EnumValueRemoved, tightened, inv, "enum value %s removed", valueText(v))
+		}
+	}
+	for _, v := range new.Enumeration {
+		if !in(old.Enumeration, v) {
+			self.add(ptr, "enum", EnumValueAdded, loosened, inv, "enum value %s added", valueText(v))
+		}
+	}
+}
+
+func valueText(v SchemaEnumValue) string {
+	bs, err := json.Marshal(&v)
+	if err != nil {
+		return "?"
+	}
+	return string(bs)
+}
+
+func enumText(values []SchemaEnumValue) string {
+	bs, err := json.Marshal(values)
+	if err != nil {
+		return "[]"
+	}
+	return string(bs)
+}
+
+// number compares a numeric bound; sign is 1 for lower bounds, which
+// tighten when raised, and -1 for upper bounds.
+func (self *differ) number(ptr, keyword string, old, new *IntegerOrFloat, sign int, inv bool) {
+	switch {
+	case old == nil && new == nil:
+	case old == nil:
+		self.add(ptr, keyword, ConstraintTightened, tightened, inv, "%s %s added", keyword, numberText(new))
+	case new == nil:
+		self.add(ptr, keyword, ConstraintLoosened, loosened, inv, "%s %s removed", keyword, numberText(old))
+	default:
+		self.bound(ptr, keyword, integerOrFloatToRat(old).Cmp(integerOrFloatToRat(new))*sign, numberText(old), numberText(new), inv)
+	}
+}
+
+func (self *differ) count(ptr, keyword string, old, new *int64, sign int, inv bool) {
+	switch {
+	case old == nil && new == nil:
+	case old == nil:
+		self.add(ptr, keyword, ConstraintTightened, tightened, inv, "%s %d added", keyword, *new)
+	case new == nil:
+		self.add(ptr, keyword, ConstraintLoosened, loosened, inv, "%s %d removed", keyword, *old)
+	default:
+		cmp := 0
+		if *old < *new {
+			cmp = -1
+		} else if *old > *new {
+			cmp = 1
+		}
+		self.bound(ptr, keyword, cmp*sign, fmt.Sprint(*old), fmt.Sprint(*new), inv)
+	}
+}
+
+// bound records a changed bound; cmp is negative when it tightened.
+func (self *differ) bound(ptr, keyword string, cmp int, old, new string, inv bool) {
+	switch {
+	case cmp < 0:
+		self.add(ptr, keyword, ConstraintTightened, tightened, inv, "%s changed from %s to %s", keyword, old, new)
+	case cmp > 0:
+		self.add(ptr, keyword, ConstraintLoosened, loosened, inv, "%s changed from %s to %s", keyword, old, new)
+	default:
+	}
+}
+
+func (self *differ) multipleOf(ptr string, old, new *IntegerOrFloat, inv bool) {
+	switch {
+	case old == nil && new == nil:
+		return
+	case old == nil:
+		self.add(ptr, "multipleOf", ConstraintTightened, tightened, inv, "multipleOf %s added", numberText(new))
+		return
+	case new == nil:
+		self.add(ptr, "multipleOf", ConstraintLoosened, loosened, inv, "multipleOf %s removed", numberText(old))
+		return
+	default:
+	}
+	o, n := integerOrFloatToRat(old), integerOrFloatToRat(new)
+	if o.Cmp(n) == 0 {
+		return
+	}
+	divides := func(a, b *big.Rat) bool {
+		return (&big.Rat{}).Quo(a, b).IsInt()
+	}
+	switch {
+	case divides(n, o):
+		self.add(ptr, "multipleOf", ConstraintTightened, tightened, inv, "multipleOf changed from %s to %s", numberText(old), numberText(new))
+	case divides(o, n):
+		self.add(ptr, "multipleOf", ConstraintLoosened, loosened, inv, "multipleOf changed from %s to %s", numberText(old), numberText(new))
+	default:
+		self.add(ptr, "multipleOf", ConstraintChanged, both, inv, "multipleOf changed from %s to %s", numberText(old), numberText(new))
+	}
+}
+
+func numberText(v *IntegerOrFloat) string {
+	bs, err := json.Marshal(v)
+	if err != nil {
+		return "?"
+	}
+	return string(bs)
+}
+
+func (self *differ) text(ptr, keyword string, old, new *string, inv bool) {
+	switch {
+	case old == nil && new == nil:
+	case old == nil:
+		self.add(ptr, keyword, ConstraintTightened, tightened, inv, "%s %q added", keyword, *new)
+	case new == nil:
+		self.add(ptr, keyword, ConstraintLoosened, loosened, inv, "%s %q removed", keyword, *old)
+	case *old != *new:
+		self.add(ptr, keyword, ConstraintChanged, both, inv, "%s changed from %q to %q", keyword, *old, *new)
+	default:
+	}
+}
+
+func (self *differ) items(ptr string, old, new *Schema, inv bool) {
+	oldItems, newItems := old.Items, new.Items
+	if oldItems == nil {
+		oldItems = &CombinedOrCombinedArray{}
+	}
+	if newItems == nil {
+		newItems = &CombinedOrCombinedArray{}
+	}
+	itemsPtr := pointerAppend(ptr, "items")
+
+	switch {
+	case oldItems.CombinedArray == nil && newItems.CombinedArray == nil:
+		self.combined(itemsPtr, oldItems.Combined, newItems.Combined, inv)
+	case oldItems.CombinedArray != nil && newItems.CombinedArray != nil:
+		oldTuple, newTuple := *oldItems.CombinedArray, *newItems.CombinedArray
+		// items beyond a tuple are checked against additionalItems
+		at := func(tuple []*Combined, extra *Combined, i int) *Combined {
+			if i < len(tuple) {
+				return tuple[i]
+			}
+			return extra
+		}
+		for i := 0; i < len(oldTuple) || i < len(newTuple); i++ {
+			self.combined(pointerAppend(itemsPtr, fmt.Sprint(i)), at(oldTuple, old.AdditionalItems, i), at(newTuple, new.AdditionalItems, i), inv)
+		}
+		self.combined(pointerAppend(ptr, "additionalItems"), old.AdditionalItems, new.AdditionalItems, inv)
+	default:
+		self.add(itemsPtr, "items", SchemaChanged, both, inv, "items changed between a schema and an array of schemas")
+	}
+}
+
+func (self *differ) required(ptr string, old, new *Schema, inv bool) {
+	oldSet := make(map[string]bool)
+	for _, k := range old.Required {
+		oldSet[k] = true
+	}
+	newSet := make(map[string]bool)
+	for _, k := range new.Required {
+		newSet[k] = true
+		if !oldSet[k] {
+			self.add(ptr, "required", RequiredAdded, tightened, inv, "property %q is now required", k)
+			oldSet[k] = true
+		}
+	}
+	for _, k := range old.Required {
+		if !newSet[k] {
+			self.add(ptr, "required", RequiredRemoved, loosened, inv, "property %q is no longer required", k)
+			newSet[k] = true
+		}
+	}
+}
+
+// properties compares properties or patternProperties. A property on
+// one side only is compared with the schema the other side applies to
+// its name, so adding a typed property to an open object breaks
+// producers, while adding or removing an unconstrained one breaks
+// nobody.
+func (self *differ) properties(ptr, keyword string, old, new *Schema, inv bool) {
+	oldMap, newMap := old.Properties, new.Properties
+	if keyword == "patternProperties" {
+		oldMap, newMap = old.PatternProperties, new.PatternProperties
+	}
+	kind := "property"
+	if keyword == "patternProperties" {
+		kind = "pattern property"
+	}
+	// a pattern is compared with additionalProperties, as the values
+	// it matches are
+	member := func(s *Schema, k string) *Combined {
+		if keyword == "patternProperties" {
+			return s.AdditionalProperties
+		}
+		return memberSchema(s, k)
+	}
+
+	for _, k := range new.OrderedKeys(keyword) {
+		p := pointerAppend(pointerAppend(ptr, keyword), k)
+		if oc, ok := oldMap[k]; ok {
+			self.combined(p, oc, newMap[k], inv)
+			continue
+		}
+		eff := self.effect(func() { self.combined(p, member(old, k), newMap[k], inv) })
+		self.add(p, keyword, PropertyAdded, eff, false, "%s %q added", kind, k)
+	}
+	for _, k := range old.OrderedKeys(keyword) {
+		if _, ok := newMap[k]; ok {
+			continue
+		}
+		p := pointerAppend(pointerAppend(ptr, keyword), k)
+		eff := self.effect(func() { self.combined(p, oldMap[k], member(new, k), inv) })
+		self.add(p, keyword, PropertyRemoved, eff, false, "%s %q removed", kind, k)
+	}
+}
+
+// effect runs fn and folds the changes it records into one effect,
+// which already accounts for inversion.
+func (self *differ) effect(fn func()) effect {
+	n := len(self.changes)
+	fn()
+	eff := neither
+	for _, c := range self.changes[n:] {
+		if c.BreaksProducers {
+			eff |= tightened
+		}
+		if c.BreaksConsumers {
+			eff |= loosened
+		}
+	}
+	self.changes = self.changes[:n]
+	return eff
+}
+
+// memberSchema returns the schema s applies to a property not in
+// properties named k.
+func memberSchema(s *Schema, k string) *Combined {
+	for pattern, c := range s.PatternProperties {
+		if re, err := regexp.Compile(pattern); err == nil && re.MatchString(k) {
+			return c
+		}
+	}
+	return s.AdditionalProperties
+}
+
+func (self *differ) dependencies(ptr string, old, new *Schema, inv bool) {
+	for _, k := range new.OrderedKeys("dependencies") {
+		p := pointerAppend(pointerAppend(ptr, "dependencies"), k)
+		oldDep, newDep := old.Dependencies[k], new.Dependencies[k]
+		switch {
+		case newDep == nil:
+			continue
+		case oldDep == nil:
+			self.add(p, "dependencies", ConstraintTightened, tightened, inv, "dependency of %q added", k)
+		case oldDep.StringArray != nil && newDep.StringArray != nil:
+			self.required(p, &Schema{SchemaObject: SchemaObject{Required: *oldDep.StringArray}}, &Schema{SchemaObject: SchemaObject{Required: *newDep.StringArray}}, inv)
+		case oldDep.Combined != nil && newDep.Combined != nil:
+			self.combined(p, oldDep.Combined, newDep.Combined, inv)
+		default:
+			self.add(p, "dependencies", ConstraintChanged, both, inv, "dependency of %q changed between names and a schema", k)
+		}
+	}
+	for _, k := range old.OrderedKeys("dependencies") {
+		if old.Dependencies[k] != nil && new.Dependencies[k] == nil {
+			self.add(pointerAppend(pointerAppend(ptr, "dependencies"), k), "dependencies", ConstraintLoosened, loosened, inv, "dependency of %q removed", k)
+		}
+	}
+}
+
+// branches compares allOf, anyOf or oneOf entry by entry; an added entry
+// has the effect given, and a removed one the opposite.
+func (self *differ) branches(ptr, keyword string, old, new []*Combined, added effect, inv bool) {
+	removed := both
+	switch added {
+	case tightened:
+		removed = loosened
+	case loosened:
+		removed = tightened
+	default:
+	}
+
+	for i := 0; i < len(old) || i < len(new); i++ {
+		p := pointerAppend(pointerAppend(ptr, keyword), fmt.Sprint(i))
+		switch {
+		case i >= len(old):
+			self.add(p, keyword, SchemaChanged, added, inv, "%s entry %d added", keyword, i)
+		case i >= len(new):
+			self.add(p, keyword, SchemaChanged, removed, inv, "%s entry %d removed", keyword, i)
+		case keyword == "allOf":
+			self.combined(p, old[i], new[i], inv)
+		default:
+			// a value may match another oneOf branch once one changes, so
+			// any breaking change there breaks both sides
+			before := len(self.changes)
+			self.combined(p, old[i], new[i], inv)
+			if keyword == "oneOf" {
+				for _, c := range self.changes[before:] {
+					breaking := c.Breaking()
+					c.BreaksProducers, c.BreaksConsumers = breaking, breaking
+				}
+			}
+		}
+	}
+}
+
+func (self *differ) definitions(ptr string, old, new *Schema, inv bool) {
+	for _, k := range new.OrderedKeys("definitions") {
+		p := pointerAppend(pointerAppend(ptr, "definitions"), k)
+		if oc, ok := old.Definitions[k]; ok {
+			self.combined(p, oc, new.Definitions[k], inv)
+			continue
+		}
+		self.add(p, "definitions", DefinitionAdded, neither, inv, "definition %q added", k)
+	}
+	for _, k := range old.OrderedKeys("definitions") {
+		if _, ok := new.Definitions[k]; !ok {
+			self.add(pointerAppend(pointerAppend(ptr, "definitions"), k), "definitions", DefinitionRemoved, both, inv, "definition %q removed", k)
+		}
+	}
+}
+
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
+
+// jsonSame compares two values by their JSON encoding.
+func jsonSame(a, b interface{}) bool {
+	x, err := json.Marshal(a)
+	if err != nil {
+		return false
+	}
+	y, err := json.Marshal(b)
+	if err != nil {
+		return false
+	}
+	var u, v interface{}
+	if json.Unmarshal(x, &u) != nil || json.Unmarshal(y, &v) != nil {
+		return false
+	}
+	return jsonEqual(u, v)
+}
+
+func rawText(raw *json.RawMessage) string {
+	if raw == nil {
+		return "none"
+	}
+	var v interface{}
+	if json.Unmarshal(*raw, &v) != nil {
+		return string(*raw)
+	}
+	bs, err := json.Marshal(v)
+	if err != nil {
+		return string(*raw)
+	}
+	return string(bs)
+}
@@ -0,0 +1,96 @@
+package jsm07
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name    string
+		old     string
+		new     string
+		changes []string
+	}{
+		{"same", `{"type": "string", "description": "a"}`, `{"type": "string", "description": "b"}`, nil},
+		{"type", `{"type": ["string", "null"]}`, `{"type": "string"}`, []string{"/: type changed from [string, null] to string (breaks producers)"}},
+		{"integer", `{"type": "integer"}`, `{"type": "number"}`, []string{"/: type changed from integer to number (breaks consumers)"}},
+		{"bounds", `{"minimum": 1, "maximum": 10, "maxLength": 5}`, `{"minimum": 2, "maximum": 20}`, []string{
+			"/: minimum changed from 1 to 2 (breaks producers)",
+			"/: maximum changed from 10 to 20 (breaks consumers)",
+			"/: maxLength 5 removed (breaks consumers)",
+		}},
+		{"multipleOf", `{"multipleOf": 2}`, `{"multipleOf": 4}`, []string{"/: multipleOf changed from 2 to 4 (breaks producers)"}},
+		{"enum", `{"enum": ["a", "b"]}`, `{"enum": ["b", "c"]}`, []string{
+			`/: enum value "a" removed (breaks producers)`,
+			`/: enum value "c" added (breaks consumers)`,
+		}},
+		{"required", `{"required": ["a"], "properties": {"a": {}, "b": {}}}`, `{"required": ["b"], "properties": {"a": {}, "b": {}}}`, []string{
+			`/: property "b" is now required (breaks producers)`,
+			`/: property "a" is no longer required (breaks consumers)`,
+		}},
+		{"properties", `{"properties": {"a": {"type": "string"}, "b": {}}}`, `{"properties": {"a": {"type": "string", "pattern": "^x"}, "c": {}}}`, []string{
+			`/properties/a: pattern "^x" added (breaks producers)`,
+			`/properties/c: property "c" added`,
+			`/properties/b: property "b" removed`,
+		}},
+		{"typed", `{"properties": {"a": {}, "b": {"type": "string"}}}`, `{"properties": {"a": {}, "port": {"type": "integer"}}}`, []string{
+			`/properties/port: property "port" added (breaks producers)`,
+			`/properties/b: property "b" removed (breaks consumers)`,
+		}},
+		{"patterned", `{"patternProperties": {"^x-": {"type": "string"}}}`, `{"properties": {"x-a": {"type": "string", "maxLength": 3}}, "patternProperties": {"^x-": {"type": "string"}}}`, []string{
+			`/properties/x-a: property "x-a" added (breaks producers)`,
+		}},
+		{"closed", `{"properties": {"a": {}}, "additionalProperties": false}`, `{"properties": {"b": {}}, "additionalProperties": false}`, []string{
+			`/properties/b: property "b" added (breaks consumers)`,
+			`/properties/a: property "a" removed (breaks producers)`,
+		}},
+		{"not", `{"not": {"maximum": 5}}`, `{"not": {"maximum": 10}}`, []string{"/not: maximum changed from 5 to 10 (breaks producers)"}},
+		{"items", `{"items": {"$ref": "#/definitions/a"}, "definitions": {"a": {"type": "string"}}}`, `{"items": {"$ref": "#/definitions/a"}, "definitions": {"a": {"type": "integer"}, "b": {}}}`, []string{
+			"/definitions/a: type changed from string to integer (breaks producers and consumers)",
+			`/definitions/b: definition "b" added`,
+		}},
+		{"ref", `{"$ref": "#/definitions/a"}`, `{"$ref": "#/definitions/b"}`, []string{`/: $ref changed from "#/definitions/a" to "#/definitions/b" (breaks producers and consumers)`}},
+		{"anyOf", `{"anyOf": [{"type": "string"}]}`, `{"anyOf": [{"type": "string"}, {"type": "null"}]}`, []string{"/anyOf/1: anyOf entry 1 added (breaks consumers)"}},
+		{"oneOf", `{"oneOf": [{"maximum": 5}, {"minimum": 10}]}`, `{"oneOf": [{"maximum": 5}, {"minimum": 3}]}`, []string{"/oneOf/1: minimum changed from 10 to 3 (breaks producers and consumers)"}},
+		{"false", `{"additionalItems": false, "items": [{}]}`, `{"additionalItems": {"type": "string"}, "items": [{}]}`, []string{"/additionalItems: false schema now allows values (breaks consumers)"}},
+		{"default", `{"default": 1}`, `{"default": 2}`, []string{"/: default changed from 1 to 2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			old, new := new(Schema), new(Schema)
+			if err := json.Unmarshal([]byte(tt.old), old); err != nil {
+				t.Fatalf("Failed to unmarshal old schema: %v", err)
+			}
+			if err := json.Unmarshal([]byte(tt.new), new); err != nil {
+				t.Fatalf("Failed to unmarshal new schema: %v", err)
+			}
+			var got []string
+			for _, c := range Diff(old, new) {
+				got = append(got, c.String())
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.changes, "\n") {
+				t.Errorf("Expected\n%s\ngot\n%s", strings.Join(tt.changes, "\n"), strings.Join(got, "\n"))
+			}
+		})
+	}
+}
+
+func TestDiffBreaking(t *testing.T) {
+	old, new := new(Schema), new(Schema)
+	if err := json.Unmarshal([]byte(`{"properties": {"a": {"enum": [1, 2]}}}`), old); err != nil {
+		t.Fatalf("Failed to unmarshal old schema: %v", err)
+	}
+	if err := json.Unmarshal([]byte(`{"properties": {"a": {"enum": [1, 2]}, "b": {}}, "definitions": {"x": {}}}`), new); err != nil {
+		t.Fatalf("Failed to unmarshal new schema: %v", err)
+	}
+	changes := Diff(old, new)
+	if len(changes) != 2 || len(changes.Breaking()) != 0 {
+		t.Errorf("Expected 2 non-breaking changes, got %v", changes)
+	}
+	if changes := Diff(new, old).Breaking(); len(changes) != 1 || changes[0].Kind != DefinitionRemoved {
+		t.Errorf("Expected only a removed definition to break, got %v", changes)
+	}
+}
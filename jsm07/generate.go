@@ -0,0 +1,791 @@
+package jsm07
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"regexp/syntax"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// GenerateOptions controls Generate.
+type GenerateOptions struct {
+	// Invalid asks for an instance that violates the schema.
+	Invalid bool
+	// MaxItems bounds the length of arrays without maxItems; the
+	// default is 3.
+	MaxItems int
+	// MaxLength bounds the length of strings without maxLength; the
+	// default is 12.
+	MaxLength int
+	// MaxDepth is the nesting of arrays and objects beyond which optional
+	// items and properties are left out; the default is 5.
+	MaxDepth int
+	// Attempts is the number of instances tried before giving up; the
+	// default is 100.
+	Attempts int
+}
+
+// Generate returns a random instance of the schema, as json.Unmarshal
+// decodes into an interface{}. The instance satisfies type, enum, const,
+// the numeric, string, array and object keywords, and one branch of oneOf
+// or anyOf, and is checked with Validate. With opts.Invalid, the instance
+// is one made to fail the schema by breaking one of its keywords, for
+// negative tests.
+//
+// The same rnd gives the same instance. An error reports a schema for
+// which no instance was found within opts.Attempts, such as one allowing
+// no value, or with opts.Invalid, one allowing every value.
+func Generate(schema *Schema, rnd *rand.Rand, opts *GenerateOptions) (interface{}, error) {
+	if opts == nil {
+		opts = &GenerateOptions{}
+	}
+	g := &generator{rnd: rnd, opts: *opts}
+	if g.opts.MaxItems <= 0 {
+		g.opts.MaxItems = 3
+	}
+	if g.opts.MaxLength <= 0 {
+		g.opts.MaxLength = 12
+	}
+	if g.opts.MaxDepth <= 0 {
+		g.opts.MaxDepth = 5
+	}
+	if g.opts.Attempts <= 0 {
+		g.opts.Attempts = 100
+	}
+
+	r, err := NewResolver(schema, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	g.resolver = r
+	g.validator = newValidator(r)
+	g.merger = newMerger(schema)
+	root := NewCombinedWithSchema(schema)
+
+	for i := 0; i < g.opts.Attempts; i++ {
+		instance := g.value(root, 0)
+		if !g.valid(root, instance) {
+			continue
+		}
+		if !opts.Invalid {
+			return instance, nil
+		}
+		if bad, ok := g.invalidate(root, instance, 0, func(x interface{}) bool { return !g.valid(root, x) }); ok {
+			return bad, nil
+		}
+	}
+	if opts.Invalid {
+		return nil, fmt.Errorf("no invalid instance found in %d attempts", g.opts.Attempts)
+	}
+	return nil, fmt.Errorf("no valid instance found in %d attempts", g.opts.Attempts)
+}
+
+type generator struct {
+	rnd       *rand.Rand
+	opts      GenerateOptions
+	resolver  *Resolver
+	validator *validator
+	merger    *merger
+}
+
+func (self *generator) valid(c *Combined, instance interface{}) bool {
+	return len(self.validator.validateCombined(c, instance, "")) == 0
+}
+
+// value returns an instance of c, retrying a few times if the keywords
+// that the generator does not follow, such as not or if, reject it.
+func (self *generator) value(c *Combined, depth int) interface{} {
+	var instance interface{}
+	for i := 0; i < 3; i++ {
+		instance = self.candidate(c, depth)
+		if self.valid(c, instance) {
+			break
+		}
+	}
+	return instance
+}
+
+func (self *generator) candidate(c *Combined, depth int) interface{} {
+	if c == nil || (c.Schema == nil && (c.Boolean == nil || *c.Boolean)) {
+		return self.anyValue(depth)
+	}
+	if c.Schema == nil || depth > 3*self.opts.MaxDepth {
+		return nil
+	}
+
+	s := self.flatten(c.Schema)
+	if s.Const != nil {
+		var v interface{}
+		if json.Unmarshal(*s.Const, &v) == nil {
+			return v
+		}
+	}
+	if len(s.Enumeration) > 0 {
+		return enumToInterface(s.Enumeration[self.rnd.Intn(len(s.Enumeration))])
+	}
+	if len(s.OneOf) > 0 || len(s.AnyOf) > 0 {
+		alts := s.OneOf
+		if len(alts) == 0 {
+			alts = s.AnyOf
+		}
+		merged := *s
+		merged.OneOf, merged.AnyOf = nil, nil
+		branch := alts[self.rnd.Intn(len(alts))]
+		if branch != nil && branch.Schema != nil {
+			return self.candidate(NewCombinedWithSchema(self.merge(&merged, self.flatten(branch.Schema))), depth)
+		}
+		return self.candidate(NewCombinedWithSchema(&merged), depth)
+	}
+
+	switch t := self.pickType(s); t {
+	case "null":
+		return nil
+	case "boolean":
+		return self.rnd.Intn(2) == 0
+	case "integer", "number":
+		return self.number(s, t == "integer")
+	case "string":
+		return self.str(s)
+	case "array":
+		return self.array(s, depth)
+	case "object":
+		return self.object(s, depth)
+	default:
+	}
+	return self.anyValue(depth)
+}
+
+// flatten follows $ref and merges allOf into a copy of s.
+func (self *generator) flatten(s *Schema) *Schema {
+	for i := 0; s.Ref != nil && i < 32; i++ {
+		target, _, err := self.resolver.Resolve(self.resolver.BaseURI(s), *s.Ref)
+		if err != nil || target.Schema == nil {
+			return &Schema{}
+		}
+		s = target.Schema
+	}
+	if len(s.AllOf) == 0 {
+		return s
+	}
+
+	merged := *s
+	merged.AllOf = nil
+	for _, sub := range s.AllOf {
+		if sub != nil && sub.Schema != nil {
+			merged = *self.merge(&merged, self.flatten(sub.Schema))
+		}
+	}
+	return &merged
+}
+
+// merge returns a copy of a with the keywords of b merged in, as
+// MergeAllOf does. If they contradict each other, a is returned, and
+// validation rejects what is generated from it.
+func (self *generator) merge(a, b *Schema) *Schema {
+	out, err := self.merger.merge("", a, b)
+	if err != nil {
+		return a
+	}
+	return out
+}
+
+// pickType returns one of the types of s, or one inferred from its
+// keywords.
+func (self *generator) pickType(s *Schema) string {
+	if s.Type != nil {
+		if s.Type.String != nil {
+			return *s.Type.String
+		}
+		if s.Type.StringArray != nil && len(*s.Type.StringArray) > 0 {
+			types := *s.Type.StringArray
+			return types[self.rnd.Intn(len(types))]
+		}
+	}
+	switch {
+	case s.Properties != nil || s.Required != nil || s.AdditionalProperties != nil || s.PatternProperties != nil ||
+		s.MinProperties != nil || s.MaxProperties != nil || s.Dependencies != nil:
+		return "object"
+	case s.Items != nil || s.MinItems != nil || s.MaxItems != nil || s.Contains != nil || s.UniqueItems != nil:
+		return "array"
+	case s.Pattern != nil || s.MinLength != nil || s.MaxLength != nil || s.Format != nil:
+		return "string"
+	case s.Minimum != nil || s.Maximum != nil || s.ExclusiveMinimum != nil || s.ExclusiveMaximum != nil || s.MultipleOf != nil:
+		return "number"
+	default:
+	}
+	return ""
+}
+
+func (self *generator) anyValue(depth int) interface{} {
+	switch self.rnd.Intn(4) {
+	case 0:
+		return self.letters(1 + self.rnd.Intn(self.opts.MaxLength))
+	case 1:
+		return float64(self.rnd.Intn(201) - 100)
+	case 2:
+		return self.rnd.Intn(2) == 0
+	default:
+	}
+	return nil
+}
+
+func (self *generator) number(s *Schema, integer bool) interface{} {
+	lo, hi := math.Inf(-1), math.Inf(1)
+	loOpen, hiOpen := false, false
+	if s.Minimum != nil {
+		lo = toFloat(s.Minimum)
+	}
+	if s.ExclusiveMinimum != nil && toFloat(s.ExclusiveMinimum) >= lo {
+		lo, loOpen = toFloat(s.ExclusiveMinimum), true
+	}
+	if s.Maximum != nil {
+		hi = toFloat(s.Maximum)
+	}
+	if s.ExclusiveMaximum != nil && toFloat(s.ExclusiveMaximum) <= hi {
+		hi, hiOpen = toFloat(s.ExclusiveMaximum), true
+	}
+	switch {
+	case math.IsInf(lo, -1) && math.IsInf(hi, 1):
+		lo, hi = -100, 100
+	case math.IsInf(lo, -1):
+		lo = hi - 100
+	case math.IsInf(hi, 1):
+		hi = lo + 100
+	default:
+	}
+
+	step := 0.0
+	if integer {
+		step = 1
+	}
+	if s.MultipleOf != nil && toFloat(s.MultipleOf) > 0 {
+		m := toFloat(s.MultipleOf)
+		if integer && m != math.Trunc(m) {
+			// a multiple of both
+			m *= 1 / (m - math.Trunc(m))
+		}
+		step = m
+	}
+
+	if step > 0 {
+		first, last := math.Ceil(lo/step), math.Floor(hi/step)
+		if loOpen && first*step <= lo {
+			first++
+		}
+		if hiOpen && last*step >= hi {
+			last--
+		}
+		if last < first {
+			return lo
+		}
+		n := first + float64(self.rnd.Int63n(int64(math.Min(last-first, 1<<30))+1))
+		return n * step
+	}
+
+	x := lo + self.rnd.Float64()*(hi-lo)
+	if (loOpen && x <= lo) || (hiOpen && x >= hi) {
+		x = (lo + hi) / 2
+	}
+	return math.Round(x*100) / 100
+}
+
+func (self *generator) str(s *Schema) interface{} {
+	min, max := 0, -1
+	if s.MinLength != nil {
+		min = int(*s.MinLength)
+	}
+	if s.MaxLength != nil {
+		max = int(*s.MaxLength)
+	}
+	if max < 0 {
+		max = min + self.opts.MaxLength
+	}
+
+	if s.Pattern != nil {
+		re, err := syntax.Parse(*s.Pattern, syntax.Perl)
+		if err == nil {
+			re = re.Simplify()
+			for i := 0; i < 10; i++ {
+				var b strings.Builder
+				self.regexString(&b, re)
+				if n := len([]rune(b.String())); n >= min && n <= max {
+					return b.String()
+				}
+			}
+		}
+	}
+	if s.Format != nil {
+		if str, ok := self.format(*s.Format); ok {
+			return str
+		}
+	}
+
+	n := min
+	if max > min {
+		n += self.rnd.Intn(max - min + 1)
+	}
+	return self.letters(n)
+}
+
+func (self *generator) format(format string) (string, bool) {
+	switch format {
+	case "date-time":
+		return fmt.Sprintf("2024-%02d-%02dT%02d:%02d:00Z", 1+self.rnd.Intn(12), 1+self.rnd.Intn(28), self.rnd.Intn(24), self.rnd.Intn(60)), true
+	case "date":
+		return fmt.Sprintf("2024-%02d-%02d", 1+self.rnd.Intn(12), 1+self.rnd.Intn(28)), true
+	case "time":
+		return fmt.Sprintf("%02d:%02d:00Z", self.rnd.Intn(24), self.rnd.Intn(60)), true
+	case "email":
+		return self.letters(6) + "@example.com", true
+	case "hostname":
+		return self.letters(6) + ".example.com", true
+	case "uri", "uri-reference", "iri", "iri-reference":
+		return "https://example.com/" + self.letters(6), true
+	case "ipv4":
+		return fmt.Sprintf("192.0.2.%d", 1+self.rnd.Intn(254)), true
+	case "ipv6":
+		return fmt.Sprintf("2001:db8::%x", 1+self.rnd.Intn(0xfffe)), true
+	case "uuid":
+		b := make([]byte, 16)
+		self.rnd.Read(b)
+		b[6] = b[6]&0x0f | 0x40
+		b[8] = b[8]&0x3f | 0x80
+		return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), true
+	default:
+	}
+	return "", false
+}
+
+func (self *generator) letters(n int) string {
+	const alphabet = "abcdefghijklmnopqrstuvwxyz"
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = alphabet[self.rnd.Intn(len(alphabet))]
+	}
+	return string(b)
+}
+
+// regexString writes a random string matching re.
+func (self *generator) regexString(b *strings.Builder, re *syntax.Regexp) {
+	repeat := func(min, max int) {
+		if max < 0 {
+			max = min + 3
+		}
+		n := min
+		if max > min {
+			n += self.rnd.Intn(max - min + 1)
+		}
+		for i := 0; i < n; i++ {
+			self.regexString(b, re.Sub[0])
+		}
+	}
+
+	switch re.Op {
+	case syntax.OpLiteral:
+		b.WriteString(string(re.Rune))
+	case syntax.OpCharClass:
+		b.WriteRune(self.classRune(re.Rune))
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		b.WriteString(self.letters(1))
+	case syntax.OpCapture:
+		self.regexString(b, re.Sub[0])
+	case syntax.OpStar:
+		repeat(0, 3)
+	case syntax.OpPlus:
+		repeat(1, 4)
+	case syntax.OpQuest:
+		repeat(0, 1)
+	case syntax.OpRepeat:
+		repeat(re.Min, re.Max)
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			self.regexString(b, sub)
+		}
+	case syntax.OpAlternate:
+		self.regexString(b, re.Sub[self.rnd.Intn(len(re.Sub))])
+	default:
+		// empty matches and anchors
+	}
+}
+
+// classRune returns a rune of a character class given as ranges,
+// preferring printable ASCII.
+func (self *generator) classRune(ranges []rune) rune {
+	if len(ranges) == 0 {
+		return 'a'
+	}
+	in := func(r rune) bool {
+		for i := 0; i+1 < len(ranges); i += 2 {
+			if r >= ranges[i] && r <= ranges[i+1] {
+				return true
+			}
+		}
+		return false
+	}
+	for i := 0; i < 20; i++ {
+		if r := rune(0x21 + self.rnd.Intn(0x7e-0x21+1)); in(r) {
+			return r
+		}
+	}
+	i := 2 * self.rnd.Intn(len(ranges)/2)
+	lo, hi := ranges[i], ranges[i+1]
+	r := lo + rune(self.rnd.Intn(int(min(hi-lo, 1000))+1))
+	if !unicode.IsPrint(r) {
+		return lo
+	}
+	return r
+}
+
+func (self *generator) array(s *Schema, depth int) interface{} {
+	var tuple []*Combined
+	var items *Combined
+	if s.Items != nil {
+		if s.Items.CombinedArray != nil {
+			tuple = *s.Items.CombinedArray
+			items = s.AdditionalItems
+		} else {
+			items = s.Items.Combined
+		}
+	}
+
+	lo, hi := 0, -1
+	if s.MinItems != nil {
+		lo = int(*s.MinItems)
+	}
+	if s.MaxItems != nil {
+		hi = int(*s.MaxItems)
+	}
+	if hi < 0 {
+		hi = max(lo, len(tuple)) + self.opts.MaxItems
+	}
+	if tuple != nil && items != nil && items.Boolean != nil && !*items.Boolean {
+		hi = min(hi, len(tuple))
+	}
+	n := lo
+	if depth < self.opts.MaxDepth && hi > lo {
+		n += self.rnd.Intn(hi - lo + 1)
+	}
+
+	unique := s.UniqueItems != nil && *s.UniqueItems
+	arr := make([]interface{}, 0, n)
+	for i := 0; i < n; i++ {
+		c := items
+		if i < len(tuple) {
+			c = tuple[i]
+		}
+		item := self.value(c, depth+1)
+		for j := 0; unique && j < 10 && containsJSON(arr, item); j++ {
+			item = self.value(c, depth+1)
+		}
+		arr = append(arr, item)
+	}
+
+	if s.Contains != nil {
+		found := false
+		for _, item := range arr {
+			if self.valid(s.Contains, item) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			item := self.value(s.Contains, depth+1)
+			if len(arr) > 0 && len(arr) >= hi {
+				arr[self.rnd.Intn(len(arr))] = item
+			} else {
+				arr = append(arr, item)
+			}
+		}
+	}
+	return arr
+}
+
+func (self *generator) object(s *Schema, depth int) interface{} {
+	obj := make(map[string]interface{})
+	required := make(map[string]bool)
+	var queue []string
+	for _, k := range s.Required {
+		if !required[k] {
+			required[k] = true
+			queue = append(queue, k)
+		}
+	}
+
+	maxProps := -1
+	if s.MaxProperties != nil {
+		maxProps = int(*s.MaxProperties)
+	}
+	for _, k := range s.OrderedKeys("properties") {
+		if !required[k] && depth < self.opts.MaxDepth && self.rnd.Intn(2) == 0 {
+			queue = append(queue, k)
+		}
+	}
+
+	for len(queue) > 0 {
+		k := queue[0]
+		queue = queue[1:]
+		if _, ok := obj[k]; ok {
+			continue
+		}
+		if !required[k] && maxProps >= 0 && len(obj) >= maxProps {
+			continue
+		}
+		obj[k] = self.value(self.propertySchema(s, k), depth+1)
+		if dep := s.Dependencies[k]; dep != nil && dep.StringArray != nil {
+			for _, name := range *dep.StringArray {
+				required[name] = true
+				queue = append(queue, name)
+			}
+		}
+	}
+
+	if s.MinProperties != nil {
+		for _, k := range s.OrderedKeys("properties") {
+			if len(obj) >= int(*s.MinProperties) {
+				break
+			}
+			if _, ok := obj[k]; !ok {
+				obj[k] = self.value(s.Properties[k], depth+1)
+			}
+		}
+		allowed := s.AdditionalProperties == nil || s.AdditionalProperties.Boolean == nil || *s.AdditionalProperties.Boolean
+		for i := 1; allowed && len(obj) < int(*s.MinProperties); i++ {
+			k := "property" + strconv.Itoa(i)
+			if _, ok := obj[k]; !ok {
+				obj[k] = self.value(s.AdditionalProperties, depth+1)
+			}
+		}
+	}
+	return obj
+}
+
+// propertySchema returns the schema of property k, which is the
+// additionalProperties schema for a property not listed.
+func (self *generator) propertySchema(s *Schema, k string) *Combined {
+	if c, ok := s.Properties[k]; ok {
+		return c
+	}
+	return s.AdditionalProperties
+}
+
+// invalidate breaks one keyword of c in instance, or in one of its items
+// or properties, and returns the result if check accepts it.
+func (self *generator) invalidate(c *Combined, instance interface{}, depth int, check func(interface{}) bool) (interface{}, bool) {
+	if c == nil || c.Schema == nil || depth > self.opts.MaxDepth {
+		return nil, false
+	}
+	s := self.flatten(c.Schema)
+	if alts := append(append([]*Combined{}, s.OneOf...), s.AnyOf...); len(alts) > 0 {
+		// break the keywords of the branch the instance matches
+		merged := *s
+		merged.OneOf, merged.AnyOf = nil, nil
+		for _, i := range self.rnd.Perm(len(alts)) {
+			if alts[i] != nil && alts[i].Schema != nil && self.valid(alts[i], instance) {
+				if bad, ok := self.invalidate(NewCombinedWithSchema(self.merge(&merged, self.flatten(alts[i].Schema))), instance, depth+1, check); ok {
+					return bad, true
+				}
+			}
+		}
+		s = &merged
+	}
+
+	candidates := self.mutations(s, instance)
+	self.rnd.Shuffle(len(candidates), func(i, j int) { candidates[i], candidates[j] = candidates[j], candidates[i] })
+	for _, bad := range candidates {
+		if check(bad) {
+			return bad, true
+		}
+	}
+
+	// otherwise break a child
+	switch t := instance.(type) {
+	case map[string]interface{}:
+		keys := sortedKeys(t)
+		self.rnd.Shuffle(len(keys), func(i, j int) { keys[i], keys[j] = keys[j], keys[i] })
+		for _, k := range keys {
+			child := self.propertySchema(s, k)
+			bad, ok := self.invalidate(child, t[k], depth+1, func(x interface{}) bool {
+				copied := make(map[string]interface{}, len(t))
+				for key, v := range t {
+					copied[key] = v
+				}
+				copied[k] = x
+				return check(copied)
+			})
+			if ok {
+				return bad, true
+			}
+		}
+	case []interface{}:
+		for _, i := range self.rnd.Perm(len(t)) {
+			var child *Combined
+			if s.Items != nil {
+				child = s.Items.Combined
+				if s.Items.CombinedArray != nil {
+					child = s.AdditionalItems
+					if i < len(*s.Items.CombinedArray) {
+						child = (*s.Items.CombinedArray)[i]
+					}
+				}
+			}
+			bad, ok := self.invalidate(child, t[i], depth+1, func(x interface{}) bool {
+				copied := append([]interface{}{}, t...)
+				copied[i] = x
+				return check(copied)
+			})
+			if ok {
+				return bad, true
+			}
+		}
+	default:
+	}
+	return nil, false
+}
+
+// mutations returns changes of instance each breaking a keyword of s.
+func (self *generator) mutations(s *Schema, instance interface{}) []interface{} {
+	var out []interface{}
+	if s.Type != nil {
+		for _, v := range []interface{}{"not-" + self.letters(4), 12345.0, true, nil, []interface{}{}, map[string]interface{}{}} {
+			out = append(out, v)
+		}
+	}
+	if len(s.Enumeration) > 0 || s.Const != nil {
+		out = append(out, "not-"+self.letters(8), -98765.0)
+	}
+
+	switch t := instance.(type) {
+	case string:
+		if s.MinLength != nil && *s.MinLength > 0 {
+			out = append(out, string([]rune(t)[:*s.MinLength-1]))
+		}
+		if s.MaxLength != nil {
+			out = append(out, t+self.letters(int(*s.MaxLength)+1))
+		}
+		if s.Pattern != nil {
+			out = append(out, "", " "+self.letters(3)+"\n", "0")
+		}
+	case float64:
+		if s.Minimum != nil {
+			out = append(out, toFloat(s.Minimum)-1)
+		}
+		if s.ExclusiveMinimum != nil {
+			out = append(out, toFloat(s.ExclusiveMinimum))
+		}
+		if s.Maximum != nil {
+			out = append(out, toFloat(s.Maximum)+1)
+		}
+		if s.ExclusiveMaximum != nil {
+			out = append(out, toFloat(s.ExclusiveMaximum))
+		}
+		if s.MultipleOf != nil {
+			out = append(out, t+toFloat(s.MultipleOf)/2)
+		}
+		if isOfTypes(s, "integer") {
+			out = append(out, t+0.5)
+		}
+	case []interface{}:
+		if s.MinItems != nil && *s.MinItems > 0 {
+			out = append(out, append([]interface{}{}, t[:*s.MinItems-1]...))
+		}
+		if len(t) > 0 {
+			if s.MaxItems != nil || (s.UniqueItems != nil && *s.UniqueItems) {
+				grown := append([]interface{}{}, t...)
+				for int64(len(grown)) <= maxInt64(s.MaxItems) {
+					grown = append(grown, t[0])
+				}
+				out = append(out, grown)
+			}
+		}
+		if s.Contains != nil {
+			out = append(out, []interface{}{})
+		}
+	case map[string]interface{}:
+		for _, k := range s.Required {
+			out = append(out, without(t, k))
+		}
+		if ap := s.AdditionalProperties; ap != nil && ap.Boolean != nil && !*ap.Boolean {
+			out = append(out, with(t, "unexpected"+strconv.Itoa(self.rnd.Intn(1000)), true))
+		}
+		if s.MaxProperties != nil {
+			grown := t
+			for i := 0; int64(len(grown)) <= *s.MaxProperties; i++ {
+				grown = with(grown, "extra"+strconv.Itoa(i), true)
+			}
+			out = append(out, grown)
+		}
+		if s.MinProperties != nil && *s.MinProperties > 0 {
+			out = append(out, map[string]interface{}{})
+		}
+		for _, k := range sortedKeys(s.Dependencies) {
+			if dep := s.Dependencies[k]; dep != nil && dep.StringArray != nil {
+				for _, name := range *dep.StringArray {
+					if _, ok := t[name]; ok {
+						out = append(out, with(without(t, name), k, t[k]))
+					}
+				}
+			}
+		}
+	default:
+	}
+	return out
+}
+
+func isOfTypes(s *Schema, t string) bool {
+	if s.Type == nil {
+		return false
+	}
+	if s.Type.String != nil {
+		return *s.Type.String == t
+	}
+	return s.Type.StringArray != nil && len(*s.Type.StringArray) == 1 && (*s.Type.StringArray)[0] == t
+}
+
+func toFloat(v *IntegerOrFloat) float64 {
+	if v.Integer != nil {
+		return float64(*v.Integer)
+	}
+	if v.Float != nil {
+		return *v.Float
+	}
+	return 0
+}
+
+func maxInt64(v *int64) int64 {
+	if v == nil {
+		return 1
+	}
+	return *v
+}
+
+func containsJSON(arr []interface{}, v interface{}) bool {
+	for _, x := range arr {
+		if jsonEqual(x, v) {
+			return true
+		}
+	}
+	return false
+}
+
+func with(obj map[string]interface{}, k string, v interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(obj)+1)
+	for key, x := range obj {
+		out[key] = x
+	}
+	out[k] = v
+	return out
+}
+
+func without(obj map[string]interface{}, k string) map[string]interface{} {
+	out := make(map[string]interface{}, len(obj))
+	for key, x := range obj {
+		if key != k {
+			out[key] = x
+		}
+	}
+	return out
+}
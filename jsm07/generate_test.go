@@ -0,0 +1,141 @@
+package jsm07
+
+import (
+	"encoding/json"
+	"math/rand"
+	"os"
+	"testing"
+)
+
+func TestGenerate(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+	}{
+		{"type", `{"type": ["string", "integer", "null"]}`},
+		{"enum", `{"enum": ["a", 1, null]}`},
+		{"const", `{"const": {"a": [1, 2]}}`},
+		{"number", `{"type": "number", "minimum": 2.5, "exclusiveMaximum": 3}`},
+		{"integer", `{"type": "integer", "minimum": -3, "maximum": 40, "multipleOf": 7}`},
+		{"string", `{"type": "string", "minLength": 3, "maxLength": 5}`},
+		{"pattern", `{"type": "string", "pattern": "^[A-Z]{2}-\\d{3,4}(x|yz)?$"}`},
+		{"format", `{"type": "string", "format": "date-time"}`},
+		{"array", `{"type": "array", "items": {"type": "integer", "minimum": 0, "maximum": 3}, "minItems": 2, "maxItems": 4, "uniqueItems": true}`},
+		{"tuple", `{"items": [{"type": "string"}, {"type": "boolean"}], "additionalItems": false, "minItems": 2}`},
+		{"contains", `{"type": "array", "items": {"type": "integer"}, "contains": {"const": 5}}`},
+		{"object", `{"type": "object", "required": ["a"], "properties": {"a": {"type": "string"}, "b": {"type": "number"}}, "additionalProperties": false}`},
+		{"minProperties", `{"type": "object", "minProperties": 2}`},
+		{"dependencies", `{"properties": {"a": {"type": "integer"}, "b": {"type": "string"}}, "required": ["a"], "dependencies": {"a": ["b"]}}`},
+		{"oneOf", `{"oneOf": [{"type": "string", "maxLength": 2}, {"type": "integer"}]}`},
+		{"allOf", `{"allOf": [{"$ref": "#/definitions/named"}, {"required": ["id"]}], "definitions": {"named": {"properties": {"id": {"type": "integer"}, "name": {"type": "string"}}, "required": ["name"]}}}`},
+		{"recursive", `{"$ref": "#/definitions/node", "definitions": {"node": {"type": "object", "required": ["value"], "properties": {"value": {"type": "integer"}, "children": {"type": "array", "items": {"$ref": "#/definitions/node"}}}}}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := new(Schema)
+			if err := json.Unmarshal([]byte(tt.schema), s); err != nil {
+				t.Fatalf("Failed to unmarshal schema: %v", err)
+			}
+			rnd := rand.New(rand.NewSource(1))
+			for i := 0; i < 20; i++ {
+				instance, err := Generate(s, rnd, nil)
+				if err != nil {
+					t.Fatalf("Failed to generate instance: %v", err)
+				}
+				if err := s.Validate(instance); err != nil {
+					t.Fatalf("Expected %v to be valid: %v", instance, err)
+				}
+
+				bad, err := Generate(s, rnd, &GenerateOptions{Invalid: true})
+				if err != nil {
+					t.Fatalf("Failed to generate invalid instance: %v", err)
+				}
+				if err := s.Validate(bad); err == nil {
+					t.Fatalf("Expected %v to be invalid", bad)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateDeterministic(t *testing.T) {
+	s := new(Schema)
+	if err := json.Unmarshal([]byte(`{"type": "object", "properties": {"a": {"type": "string"}, "b": {"type": "array"}}}`), s); err != nil {
+		t.Fatalf("Failed to unmarshal schema: %v", err)
+	}
+	first, err := Generate(s, rand.New(rand.NewSource(7)), nil)
+	if err != nil {
+		t.Fatalf("Failed to generate instance: %v", err)
+	}
+	second, err := Generate(s, rand.New(rand.NewSource(7)), nil)
+	if err != nil {
+		t.Fatalf("Failed to generate instance: %v", err)
+	}
+	if !jsonEqual(first, second) {
+		t.Errorf("Expected the same instance for the same seed, got %v and %v", first, second)
+	}
+}
+
+func TestGenerateAllOf(t *testing.T) {
+	s := new(Schema)
+	if err := json.Unmarshal([]byte(`{"allOf": [{"type": "integer", "minimum": 0, "maximum": 1000}, {"minimum": 990}, {"exclusiveMaximum": 995}]}`), s); err != nil {
+		t.Fatalf("Failed to unmarshal schema: %v", err)
+	}
+	rnd := rand.New(rand.NewSource(1))
+	for i := 0; i < 20; i++ {
+		// the merged bounds give a valid instance at the first attempt
+		if _, err := Generate(s, rnd, &GenerateOptions{Attempts: 1}); err != nil {
+			t.Fatalf("Failed to generate instance: %v", err)
+		}
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	s := new(Schema)
+	if err := json.Unmarshal([]byte(`{"type": "string", "minLength": 3, "maxLength": 2}`), s); err != nil {
+		t.Fatalf("Failed to unmarshal schema: %v", err)
+	}
+	if _, err := Generate(s, rand.New(rand.NewSource(1)), &GenerateOptions{Attempts: 5}); err == nil {
+		t.Errorf("Expected an error for a schema allowing no value")
+	}
+
+	if _, err := Generate(&Schema{}, rand.New(rand.NewSource(1)), &GenerateOptions{Invalid: true, Attempts: 5}); err == nil {
+		t.Errorf("Expected an error for a schema allowing every value")
+	}
+}
+
+func TestGenerateMCP(t *testing.T) {
+	bs, err := os.ReadFile("samples/mcp.json")
+	if err != nil {
+		t.Fatalf("Failed to read mcp.json: %v", err)
+	}
+	mcp := new(Schema)
+	if err := json.Unmarshal(bs, mcp); err != nil {
+		t.Fatalf("Failed to unmarshal mcp.json: %v", err)
+	}
+
+	rnd := rand.New(rand.NewSource(2024))
+	for _, name := range sortedKeys(mcp.Definitions) {
+		ref := "#/definitions/" + name
+		s := &Schema{Ref: &ref, Definitions: mcp.Definitions}
+
+		instance, err := Generate(s, rnd, nil)
+		if err != nil {
+			t.Errorf("Failed to generate %s: %v", name, err)
+			continue
+		}
+		if err := s.Validate(instance); err != nil {
+			t.Errorf("Expected generated %s to be valid: %v", name, err)
+		}
+
+		bad, err := Generate(s, rnd, &GenerateOptions{Invalid: true})
+		if err != nil {
+			t.Errorf("Failed to generate invalid %s: %v", name, err)
+			continue
+		}
+		if err := s.Validate(bad); err == nil {
+			t.Errorf("Expected generated %s to be invalid", name)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package jsm07
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+)
+
+// schemaKeywords are the JSON names of the fields of Schema.
+var schemaKeywords = jsonNames(reflect.TypeOf(Schema{}))
+
+func jsonNames(t reflect.Type) map[string]bool {
+	names := make(map[string]bool)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous {
+			for k := range jsonNames(field.Type) {
+				names[k] = true
+			}
+			continue
+		}
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name != "" && name != "-" {
+			names[name] = true
+		}
+	}
+	return names
+}
+
+// UnmarshalJSON decodes a schema, keeping keywords that are not fields of
+// Schema in Extensions.
+//
+// A schema whose $schema declares draft-04 or draft-06 is upgraded to
+// draft-07 on the way in, including all its subschemas: id becomes $id,
+// boolean exclusiveMaximum and exclusiveMinimum are folded into numeric
+// ones, and $schema is set to Draft07. Draft-06 needs no other change.
+func (self *Schema) UnmarshalJSON(data []byte) error {
+	type plain Schema
+
+	draft := legacyDraft(data)
+	if draft == Draft04 {
+		upgraded, err := upgradeDraft04(data)
+		if err != nil {
+			return err
+		}
+		data = upgraded
+	}
+
+	if err := json.Unmarshal(data, (*plain)(self)); err != nil {
+		return err
+	}
+	if draft != "" {
+		s := Draft07
+		self.Schema = &s
+	}
+
+	members, _, err := objectMembers(data)
+	if err != nil {
+		return err
+	}
+	self.Extensions = nil
+	self.KeyOrder = nil
+	for _, m := range members {
+		if orderedKeywords[m.key] {
+			entries, _, err := objectMembers(m.value)
+			if err != nil {
+				return err
+			}
+			keys := make([]string, len(entries))
+			for i, e := range entries {
+				keys[i] = e.key
+			}
+			self.setKeyOrder(m.key, keys)
+		}
+		if schemaKeywords[m.key] {
+			continue
+		}
+		if self.Extensions == nil {
+			self.Extensions = make(map[string]json.RawMessage)
+		}
+		self.Extensions[m.key] = m.value
+	}
+	return nil
+}
+
+// MarshalJSON encodes a schema, listing the keys of properties,
+// patternProperties, dependencies and definitions in the order of
+// KeyOrder, followed by its Extensions in key order. An extension named
+// like a field of Schema is skipped.
+func (self *Schema) MarshalJSON() ([]byte, error) {
+	type plain Schema
+
+	bs, err := json.Marshal((*plain)(self))
+	if err != nil || (len(self.KeyOrder) == 0 && len(self.Extensions) == 0) {
+		return bs, err
+	}
+
+	members, _, err := objectMembers(bs)
+	if err != nil {
+		return nil, err
+	}
+	if err := self.reorderMembers(members); err != nil {
+		return nil, err
+	}
+	for _, k := range sortedKeys(self.Extensions) {
+		if !schemaKeywords[k] {
+			members = append(members, member{key: k, value: self.Extensions[k]})
+		}
+	}
+	return writeObject(members)
+}
@@ -0,0 +1,146 @@
+package jsm07
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/genelet/determined/dethcl"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestExtensionsJSON(t *testing.T) {
+	data := `{"type":"object","properties":{"id":{"type":"string","x-go-type":"uuid.UUID","x-order":1}},"markdownDescription":"**bold**","x-tags":["a",{"b":null}]}`
+	s := new(Schema)
+	if err := json.Unmarshal([]byte(data), s); err != nil {
+		t.Fatalf("Failed to unmarshal schema: %v", err)
+	}
+	if string(s.Extensions["markdownDescription"]) != `"**bold**"` {
+		t.Errorf("Expected markdownDescription extension, got %v", s.Extensions)
+	}
+	id := s.Properties["id"].Schema
+	if len(id.Extensions) != 2 || string(id.Extensions["x-go-type"]) != `"uuid.UUID"` {
+		t.Errorf("Expected nested extensions, got %v", id.Extensions)
+	}
+
+	bs, err := json.Marshal(s)
+	if err != nil {
+		t.Fatalf("Failed to marshal schema: %v", err)
+	}
+	expected := `{"type":"object","properties":{"id":{"type":"string","properties":null,"x-go-type":"uuid.UUID","x-order":1}},"markdownDescription":"**bold**","x-tags":["a",{"b":null}]}`
+	if string(bs) != expected {
+		t.Errorf("Expected %s, got %s", expected, bs)
+	}
+
+	// a field wins over an extension of the same name
+	s.Extensions["type"] = json.RawMessage(`"array"`)
+	bs, err = json.Marshal(s)
+	if err != nil {
+		t.Fatalf("Failed to marshal schema: %v", err)
+	}
+	if strings.Contains(string(bs), "array") {
+		t.Errorf("Expected extension named type to be skipped, got %s", bs)
+	}
+}
+
+func TestExtensionsHCL(t *testing.T) {
+	s := new(Schema)
+	if err := json.Unmarshal([]byte(`{"type":"string","x-go-type":"uuid.UUID","x-order":-1,"x-meta":{"k":[true,2.5]},"$comment2":"c"}`), s); err != nil {
+		t.Fatalf("Failed to unmarshal schema: %v", err)
+	}
+	bs, err := dethcl.Marshal(s)
+	if err != nil {
+		t.Fatalf("Failed to marshal schema to HCL: %v", err)
+	}
+	if !strings.Contains(string(bs), `x-go-type = "uuid.UUID"`) || !strings.Contains(string(bs), "_comment2") {
+		t.Errorf("Expected extensions in HCL, got %s", bs)
+	}
+	parsed, err := ParseSchema(bs)
+	if err != nil {
+		t.Fatalf("Failed to parse HCL: %v\n%s", err, bs)
+	}
+	if diff := cmp.Diff(s.Extensions, parsed.Extensions); diff != "" {
+		t.Errorf("Extensions mismatch (-want +got):\n%s", diff)
+	}
+
+	// names that are not HCL identifiers are skipped
+	s.Extensions["@type"] = json.RawMessage(`"Thing"`)
+	s.Extensions["x.y"] = json.RawMessage(`1`)
+	if err := s.Set("/properties/a", NewCombinedWithSchema(&Schema{Extensions: map[string]json.RawMessage{"not valid": json.RawMessage(`1`)}})); err != nil {
+		t.Fatalf("Failed to set property: %v", err)
+	}
+	bs, err = dethcl.Marshal(s)
+	if err != nil {
+		t.Fatalf("Failed to marshal schema to HCL: %v", err)
+	}
+	if _, err := ParseSchema(bs); err != nil {
+		t.Fatalf("Failed to parse HCL: %v\n%s", err, bs)
+	}
+	if strings.Contains(string(bs), "Thing") {
+		t.Errorf("Expected @type to be skipped, got %s", bs)
+	}
+	skipped := SkippedExtensions(s)
+	if len(skipped) != 3 || skipped[0] != "/@type" || skipped[1] != "/x.y" || skipped[2] != "/properties/a/not valid" {
+		t.Errorf("Expected the skipped extensions, got %v", skipped)
+	}
+}
+
+func TestExtensionsDiagnostics(t *testing.T) {
+	s, diags := ParseSchemaFile([]byte(`
+type = "string"
+x-go-type = "uuid.UUID"
+properties "a" {
+  x-order = 2
+  markdownDescription = "text"
+}
+`), &ParseOptions{Filename: "ext.hcl"})
+	if diags.HasErrors() {
+		t.Fatalf("Failed to parse schema: %v", diags)
+	}
+	if len(diags) != 1 || !strings.Contains(diags[0].Detail, "markdownDescription") {
+		t.Errorf("Expected one warning for markdownDescription, got %v", diags)
+	}
+	a := s.Properties["a"].Schema
+	if string(a.Extensions["x-order"]) != "2" || string(a.Extensions["markdownDescription"]) != `"text"` {
+		t.Errorf("Expected extensions, got %v", a.Extensions)
+	}
+	if string(s.Extensions["x-go-type"]) != `"uuid.UUID"` {
+		t.Errorf("Expected x-go-type extension, got %v", s.Extensions)
+	}
+}
+
+func TestExtensionsHCLValues(t *testing.T) {
+	cases := []struct {
+		name  string
+		value string
+	}{
+		{"null", `null`},
+		{"fraction", `1e-7`},
+		{"float", `2.5`},
+		{"negative", `-0.125`},
+		{"large", `1e300`},
+		{"precise", `123456789.123456789`},
+		{"nested null", `[1,null,{"a":null,"b":1e-7}]`},
+	}
+	for _, c := range cases {
+		s := new(Schema)
+		if err := json.Unmarshal([]byte(`{"type":"string","x-k":`+c.value+`}`), s); err != nil {
+			t.Fatalf("Failed to unmarshal schema: %v", err)
+		}
+		bs, err := dethcl.Marshal(s)
+		if err != nil {
+			t.Fatalf("Failed to marshal schema to HCL: %v", err)
+		}
+		parsed, err := ParseSchema(bs)
+		if err != nil {
+			t.Fatalf("%s: failed to parse HCL: %v\n%s", c.name, err, bs)
+		}
+		got, err := json.Marshal(parsed)
+		if err != nil {
+			t.Fatalf("Failed to marshal schema: %v", err)
+		}
+		if expected := `{"type":"string","properties":null,"x-k":` + c.value + `}`; string(got) != expected {
+			t.Errorf("%s: expected %s, got %s\n%s", c.name, expected, got, bs)
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package jsm07
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// The $schema URIs of the drafts read by this package.
+const (
+	Draft04 = "http://json-schema.org/draft-04/schema#"
+	Draft06 = "http://json-schema.org/draft-06/schema#"
+	Draft07 = "http://json-schema.org/draft-07/schema#"
+)
+
+// legacyDraft returns Draft04 or Draft06 if data is a schema object
+// declaring it, or else an empty string.
+func legacyDraft(data []byte) string {
+	if !bytes.Contains(data, []byte("draft-0")) {
+		return ""
+	}
+	var probe struct {
+		Schema *string `json:"$schema"`
+	}
+	if err := json.Unmarshal(data, &probe); err != nil || probe.Schema == nil {
+		return ""
+	}
+
+	uri := strings.TrimSuffix(*probe.Schema, "#")
+	uri = strings.TrimPrefix(strings.TrimPrefix(uri, "http://"), "https://")
+	switch uri {
+	case "json-schema.org/draft-04/schema":
+		return Draft04
+	case "json-schema.org/draft-06/schema":
+		return Draft06
+	default:
+	}
+	return ""
+}
+
+func upgradeDraft04(data []byte) ([]byte, error) {
+	return upgradeNode04(data, true)
+}
+
+// upgradeNode04 rewrites the draft-04 keywords of a schema object and its
+// subschemas, keeping the order of their keys. A nested schema declaring
+// its own $schema is left to its own UnmarshalJSON.
+func upgradeNode04(data json.RawMessage, top bool) (json.RawMessage, error) {
+	members, ok, err := objectMembers(data)
+	if err != nil || !ok {
+		return data, err
+	}
+	find := func(key string) int {
+		for i, m := range members {
+			if m.key == key {
+				return i
+			}
+		}
+		return -1
+	}
+	if !top && find("$schema") >= 0 {
+		return data, nil
+	}
+
+	if i := find("id"); i >= 0 && bytes.HasPrefix(bytes.TrimSpace(members[i].value), []byte(`"`)) {
+		if find("$id") < 0 {
+			members[i].key = "$id"
+		} else {
+			members = append(members[:i], members[i+1:]...)
+		}
+	}
+	for _, pair := range [][2]string{{"exclusiveMaximum", "maximum"}, {"exclusiveMinimum", "minimum"}} {
+		i := find(pair[0])
+		if i < 0 {
+			continue
+		}
+		var exclusive bool
+		if json.Unmarshal(members[i].value, &exclusive) != nil {
+			continue
+		}
+		if j := find(pair[1]); j >= 0 && exclusive {
+			members[i].value = members[j].value
+			members = append(members[:j], members[j+1:]...)
+		} else {
+			members = append(members[:i], members[i+1:]...)
+		}
+	}
+
+	for i, m := range members {
+		switch m.key {
+		case "additionalItems", "additionalProperties", "not", "contains", "propertyNames", "if", "then", "else", "items":
+			members[i].value, err = upgradeNode04(m.value, false)
+		case "properties", "patternProperties", "definitions", "dependencies":
+			var entries []member
+			entries, ok, err = objectMembers(m.value)
+			if err != nil || !ok {
+				break
+			}
+			for j, e := range entries {
+				if entries[j].value, err = upgradeNode04(e.value, false); err != nil {
+					return nil, err
+				}
+			}
+			members[i].value, err = writeObject(entries)
+		default:
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch m.key {
+		case "items", "allOf", "anyOf", "oneOf":
+			var arr []json.RawMessage
+			if json.Unmarshal(members[i].value, &arr) != nil {
+				continue
+			}
+			for j, v := range arr {
+				if arr[j], err = upgradeNode04(v, false); err != nil {
+					return nil, err
+				}
+			}
+			if members[i].value, err = json.Marshal(arr); err != nil {
+				return nil, err
+			}
+		default:
+		}
+	}
+	return writeObject(members)
+}
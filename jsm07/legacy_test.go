@@ -0,0 +1,90 @@
+package jsm07
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLegacyDrafts(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected string
+	}{
+		{
+			name: "draft-04",
+			input: `{
+				"$schema": "http://json-schema.org/draft-04/schema#",
+				"id": "http://example.com/root.json",
+				"type": "object",
+				"properties": {
+					"id": {"type": "integer", "minimum": 0, "exclusiveMinimum": true},
+					"ratio": {"maximum": 1.5, "exclusiveMaximum": false},
+					"list": {"items": [{"id": "#item", "maximum": 10, "exclusiveMaximum": true}]}
+				},
+				"definitions": {
+					"embedded": {"$schema": "http://json-schema.org/draft-06/schema#", "id": "kept"}
+				}
+			}`,
+			expected: `{
+				"$schema": "http://json-schema.org/draft-07/schema#",
+				"$id": "http://example.com/root.json",
+				"type": "object",
+				"properties": {
+					"id": {"type": "integer", "exclusiveMinimum": 0},
+					"ratio": {"maximum": 1.5},
+					"list": {"items": [{"$id": "#item", "exclusiveMaximum": 10}]}
+				},
+				"definitions": {
+					"embedded": {"$schema": "http://json-schema.org/draft-07/schema#", "id": "kept"}
+				}
+			}`,
+		},
+		{
+			name:     "draft-06",
+			input:    `{"$schema": "https://json-schema.org/draft-06/schema", "$id": "http://example.com/x", "exclusiveMaximum": 5}`,
+			expected: `{"$schema": "http://json-schema.org/draft-07/schema#", "$id": "http://example.com/x", "exclusiveMaximum": 5}`,
+		},
+		{
+			name:     "draft-07",
+			input:    `{"$schema": "http://json-schema.org/draft-07/schema#", "properties": {"id": {"type": "string"}}}`,
+			expected: `{"$schema": "http://json-schema.org/draft-07/schema#", "properties": {"id": {"type": "string"}}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := new(Schema)
+			if err := json.Unmarshal([]byte(tt.input), got); err != nil {
+				t.Fatalf("Failed to unmarshal schema: %v", err)
+			}
+			expected := new(Schema)
+			if err := json.Unmarshal([]byte(tt.expected), expected); err != nil {
+				t.Fatalf("Failed to unmarshal expected schema: %v", err)
+			}
+			if diff := cmp.Diff(expected, got); diff != "" {
+				t.Errorf("Schema mismatch (-want +got):\n%s", diff)
+			}
+		})
+	}
+}
+
+func TestLegacyValidate(t *testing.T) {
+	s := new(Schema)
+	if err := json.Unmarshal([]byte(`{
+		"$schema": "http://json-schema.org/draft-04/schema#",
+		"type": "number",
+		"minimum": 0,
+		"exclusiveMinimum": true
+	}`), s); err != nil {
+		t.Fatalf("Failed to unmarshal schema: %v", err)
+	}
+	if err := s.Validate(0.0); err == nil {
+		t.Error("Expected 0 to be invalid")
+	}
+	if err := s.Validate(0.5); err != nil {
+		t.Errorf("Expected 0.5 to be valid, got %v", err)
+	}
+}
@@ -0,0 +1,119 @@
+package jsm07
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+)
+
+// Loader fetches the schema document identified by an absolute URI
+// without fragment, such as file:///schemas/common.hcl or
+// https://example.com/other.json.
+type Loader interface {
+	Load(uri string) (*Schema, error)
+}
+
+// MapLoader serves schemas from memory, keyed by URI.
+type MapLoader map[string]*Schema
+
+// Load implements the Loader interface for MapLoader.
+func (self MapLoader) Load(uri string) (*Schema, error) {
+	if s, ok := self[uri]; ok {
+		return s, nil
+	}
+	return nil, fmt.Errorf("schema %s not found", uri)
+}
+
+// SchemeLoader dispatches to a Loader chosen by the URI scheme,
+// e.g. "file", "http" or "https".
+type SchemeLoader map[string]Loader
+
+// Load implements the Loader interface for SchemeLoader.
+func (self SchemeLoader) Load(uri string) (*Schema, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, err
+	}
+	if loader, ok := self[u.Scheme]; ok {
+		return loader.Load(uri)
+	}
+	return nil, fmt.Errorf("no loader for scheme %q of %s", u.Scheme, uri)
+}
+
+// NewDefaultLoader returns a loader for file, http and https URIs.
+func NewDefaultLoader() Loader {
+	httpLoader := &HTTPLoader{}
+	return SchemeLoader{
+		"file":  FileLoader{},
+		"http":  httpLoader,
+		"https": httpLoader,
+	}
+}
+
+// FileLoader reads file: URIs from the local filesystem. Files ending in
+// .hcl are parsed as HCL, everything else as JSON.
+type FileLoader struct{}
+
+// Load implements the Loader interface for FileLoader.
+func (self FileLoader) Load(uri string) (*Schema, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "file" && u.Scheme != "" {
+		return nil, fmt.Errorf("%s is not a file URI", uri)
+	}
+	data, err := os.ReadFile(u.Path)
+	if err != nil {
+		return nil, err
+	}
+	return parseDocument(data, u.Path)
+}
+
+// HTTPLoader fetches http: and https: URIs. Documents whose path ends in
+// .hcl are parsed as HCL, everything else as JSON.
+type HTTPLoader struct {
+	// Transport performs the requests; http.DefaultTransport is used if nil.
+	Transport http.RoundTripper
+}
+
+// Load implements the Loader interface for HTTPLoader.
+func (self *HTTPLoader) Load(uri string) (*Schema, error) {
+	client := &http.Client{Transport: self.Transport}
+	resp, err := client.Get(uri)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s: %s", uri, resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return parseDocument(data, resp.Request.URL.Path)
+}
+
+// parseDocument parses data as HCL if name ends in .hcl, or else as JSON.
+func parseDocument(data []byte, name string) (*Schema, error) {
+	if strings.EqualFold(path.Ext(name), ".hcl") {
+		s, diags := ParseSchemaFile(data, &ParseOptions{Filename: name})
+		if diags.HasErrors() {
+			return nil, diags
+		}
+		return s, nil
+	}
+
+	s := new(Schema)
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", name, err)
+	}
+	return s, nil
+}
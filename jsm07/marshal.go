@@ -1,22 +1,32 @@
 package jsm07
 
 import (
+	"bytes"
 	"encoding/json"
+	"strconv"
 	"strings"
 
 	"github.com/genelet/determined/dethcl"
 	"github.com/genelet/hcllight/light"
+	"github.com/genelet/hclschema/internal/lightexpr"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
 )
 
-func assignRaw(attrs map[string]*light.Attribute, key string, val *json.RawMessage) bool {
+func assignRaw(attrs map[string]*light.Attribute, key string, val *json.RawMessage) (bool, error) {
 	if val == nil {
-		return false
+		return false, nil
+	}
+	expr, err := lightexpr.FromRaw(*val)
+	if err != nil {
+		return false, err
 	}
 	attrs[key] = &light.Attribute{
 		Name: key,
-		Expr: light.StringToTextValueExpr(string(*val)),
+		Expr: expr,
 	}
-	return true
+	return true, nil
 }
 
 func assignInt64(attrs map[string]*light.Attribute, key string, val *int64) bool {
@@ -45,20 +55,21 @@ func assignIntegerOrFloat(attrs map[string]*light.Attribute, key string, val *In
 	if val == nil {
 		return false
 	}
-	if val.Float != nil {
-		attrs[key] = &light.Attribute{
-			Name: key,
-			Expr: light.Float64ToLiteralValueExpr(*val.Float),
-		}
-	} else {
-		attrs[key] = &light.Attribute{
-			Name: key,
-			Expr: light.Int64ToLiteralValueExpr(*val.Integer),
-		}
+	attrs[key] = &light.Attribute{
+		Name: key,
+		Expr: numberExpr(val),
 	}
 	return true
 }
 
+// numberExpr writes a number with all the digits needed to read it back.
+func numberExpr(val *IntegerOrFloat) *light.Expression {
+	if val.Float != nil {
+		return lightexpr.FromInterface(*val.Float)
+	}
+	return lightexpr.Number(strconv.FormatInt(*val.Integer, 10))
+}
+
 func assignString(attrs map[string]*light.Attribute, key string, val *string) bool {
 	if val == nil {
 		return false
@@ -68,7 +79,7 @@ func assignString(attrs map[string]*light.Attribute, key string, val *string) bo
 	}
 	attrs[key] = &light.Attribute{
 		Name: key,
-		Expr: light.StringToTextValueExpr(*val),
+		Expr: lightexpr.String(*val),
 	}
 	return true
 }
@@ -93,17 +104,13 @@ func assignEnum(attrs map[string]*light.Attribute, key string, val []SchemaEnumV
 	var exprs []*light.Expression
 	for _, v := range val {
 		if v.String != nil {
-			exprs = append(exprs, light.StringToTextValueExpr(*v.String))
+			exprs = append(exprs, lightexpr.String(*v.String))
 		} else if v.Bool != nil {
 			exprs = append(exprs, light.BooleanToLiteralValueExpr(*v.Bool))
 		} else if v.Number != nil {
-			if v.Number.Float != nil {
-				exprs = append(exprs, light.Float64ToLiteralValueExpr(*v.Number.Float))
-			} else if v.Number.Integer != nil {
-				exprs = append(exprs, light.Int64ToLiteralValueExpr(*v.Number.Integer))
-			}
+			exprs = append(exprs, numberExpr(v.Number))
 		} else if v.Null != nil && *v.Null {
-			exprs = append(exprs, light.StringToTextValueExpr("null"))
+			exprs = append(exprs, lightexpr.FromInterface(nil))
 		}
 	}
 
@@ -201,13 +208,19 @@ func (self *Schema) MarshalHCL() ([]byte, error) {
 		trimmed.UniqueItems = nil
 	}
 
-	if assignRaw(attrs, "const", trimmed.Const) {
+	if ok, err := assignRaw(attrs, "const", trimmed.Const); err != nil {
+		return nil, err
+	} else if ok {
 		trimmed.Const = nil
 	}
-	if assignRaw(attrs, "default", trimmed.Default) {
+	if ok, err := assignRaw(attrs, "default", trimmed.Default); err != nil {
+		return nil, err
+	} else if ok {
 		trimmed.Default = nil
 	}
-	if assignRaw(attrs, "examples", trimmed.Examples) {
+	if ok, err := assignRaw(attrs, "examples", trimmed.Examples); err != nil {
+		return nil, err
+	} else if ok {
 		trimmed.Examples = nil
 	}
 
@@ -256,11 +269,69 @@ func (self *Schema) MarshalHCL() ([]byte, error) {
 		trimmed.Enumeration = nil
 	}
 
+	for _, k := range sortedKeys(trimmed.Extensions) {
+		if schemaKeywords[k] {
+			continue
+		}
+		name, ok := extensionName(k)
+		if !ok {
+			// see SkippedExtensions
+			continue
+		}
+		expr, err := lightexpr.FromRaw(trimmed.Extensions[k])
+		if err != nil {
+			return nil, err
+		}
+		attrs[name] = &light.Attribute{
+			Name: name,
+			Expr: expr,
+		}
+	}
+
+	// the map keywords are written here, in the order of KeyOrder
+	var blocks bytes.Buffer
+	for _, keyword := range []string{"properties", "patternProperties", "definitions"} {
+		if err := self.marshalKeyed(&blocks, keyword); err != nil {
+			return nil, err
+		}
+	}
+	if err := self.marshalDependencies(&blocks); err != nil {
+		return nil, err
+	}
+	trimmed.Properties = nil
+	trimmed.PatternProperties = nil
+	trimmed.Definitions = nil
+	trimmed.Dependencies = nil
+
+	// so are the other subschemas, as dethcl would put a nested block
+	// with several arguments on a single line, which HCL rejects
+	if err := trimmed.marshalSubschemas(&blocks); err != nil {
+		return nil, err
+	}
+	trimmed.Items = nil
+	trimmed.AdditionalItems = nil
+	trimmed.Contains = nil
+	trimmed.AdditionalProperties = nil
+	trimmed.PropertyNames = nil
+	trimmed.If = nil
+	trimmed.Then = nil
+	trimmed.Else = nil
+	trimmed.AllOf = nil
+	trimmed.AnyOf = nil
+	trimmed.OneOf = nil
+	trimmed.Not = nil
+
 	bs, err := dethcl.Marshal(trimmed)
 	if err != nil {
 		return nil, err
 	}
+	if blocks.Len() > 0 {
+		bs = append(blocks.Bytes(), bs...)
+	}
 	if len(attrs) == 0 {
+		if blocks.Len() > 0 {
+			return []byte("  " + strings.TrimSpace(string(bs))), nil
+		}
 		return bs, nil
 	}
 
@@ -277,6 +348,170 @@ func (self *Schema) MarshalHCL() ([]byte, error) {
 		return []byte(str), nil
 	}
 
-	str := "  " + strings.TrimSpace(string(append(data, bs...)))
+	str := "  " + strings.TrimSpace(string(data)+"\n"+string(bs))
 	return []byte(str), nil
 }
+
+// extensionName returns the HCL attribute name of an extension, $ being
+// written as _, and whether it is a valid identifier.
+func extensionName(k string) (string, bool) {
+	name := k
+	if strings.HasPrefix(name, "$") {
+		name = "_" + name[1:]
+	}
+	return name, hclsyntax.ValidIdentifier(name)
+}
+
+// SkippedExtensions returns the JSON Pointers of the extensions in schema
+// and its subschemas that MarshalHCL leaves out, because their names, such
+// as @type or x.y, are not valid HCL attribute names.
+func SkippedExtensions(schema *Schema) []JSONPointer {
+	var skipped []JSONPointer
+	Walk(schema, func(ptr JSONPointer, s *Schema) error {
+		for _, k := range sortedKeys(s.Extensions) {
+			if _, ok := extensionName(k); !ok && !schemaKeywords[k] {
+				skipped = append(skipped, ptr.Append(k))
+			}
+		}
+		return nil
+	})
+	return skipped
+}
+
+// marshalKeyed writes the entries of properties, patternProperties or
+// definitions as blocks labeled by their keys.
+func (self *Schema) marshalKeyed(buf *bytes.Buffer, keyword string) error {
+	var m map[string]*Combined
+	switch keyword {
+	case "properties":
+		m = self.Properties
+	case "patternProperties":
+		m = self.PatternProperties
+	case "definitions":
+		m = self.Definitions
+	default:
+	}
+
+	// an empty properties block stands for an empty map
+	if m != nil && len(m) == 0 && keyword == "properties" {
+		buf.WriteString("properties {\n}\n")
+		return nil
+	}
+	for _, k := range self.OrderedKeys(keyword) {
+		body, err := marshalCombinedBody(m[k])
+		if err != nil {
+			return err
+		}
+		writeBlock(buf, keyword, &k, body)
+	}
+	return nil
+}
+
+// marshalDependencies writes the entries of dependencies as blocks labeled
+// by their keys, the array form as a lone required list.
+func (self *Schema) marshalDependencies(buf *bytes.Buffer) error {
+	for _, k := range self.OrderedKeys("dependencies") {
+		var body []byte
+		var err error
+		switch dep := self.Dependencies[k]; {
+		case dep == nil:
+		case dep.StringArray != nil:
+			body, err = (&light.Body{
+				Attributes: map[string]*light.Attribute{
+					"required": {
+						Name: "required",
+						Expr: light.StringArrayToTupleConsEpr(*dep.StringArray),
+					},
+				},
+			}).MarshalHCL()
+		default:
+			body, err = marshalCombinedBody(dep.Combined)
+		}
+		if err != nil {
+			return err
+		}
+		writeBlock(buf, "dependencies", &k, body)
+	}
+	return nil
+}
+
+// marshalSubschemas writes the subschemas of self other than those of
+// the map keywords as blocks, repeated for array-form items, allOf, anyOf
+// and oneOf. Boolean ones already written as attributes are nil.
+func (self *Schema) marshalSubschemas(buf *bytes.Buffer) error {
+	write := func(keyword string, arr ...*Combined) error {
+		for _, c := range arr {
+			if c == nil {
+				continue
+			}
+			body, err := marshalCombinedBody(c)
+			if err != nil {
+				return err
+			}
+			writeBlock(buf, keyword, nil, body)
+		}
+		return nil
+	}
+
+	var items []*Combined
+	if self.Items != nil && self.Items.CombinedArray != nil {
+		items = *self.Items.CombinedArray
+	} else if self.Items != nil {
+		items = []*Combined{self.Items.Combined}
+	}
+	for _, x := range []struct {
+		keyword string
+		arr     []*Combined
+	}{
+		{"items", items},
+		{"additionalItems", []*Combined{self.AdditionalItems}},
+		{"contains", []*Combined{self.Contains}},
+		{"additionalProperties", []*Combined{self.AdditionalProperties}},
+		{"propertyNames", []*Combined{self.PropertyNames}},
+		{"if", []*Combined{self.If}},
+		{"then", []*Combined{self.Then}},
+		{"else", []*Combined{self.Else}},
+		{"allOf", self.AllOf},
+		{"anyOf", self.AnyOf},
+		{"oneOf", self.OneOf},
+		{"not", []*Combined{self.Not}},
+	} {
+		if err := write(x.keyword, x.arr...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// marshalCombinedBody returns the body of a block holding c. As in
+// ParseSchema, an empty block stands for true and a block with an empty
+// not for false.
+func marshalCombinedBody(c *Combined) ([]byte, error) {
+	switch {
+	case c == nil:
+		return nil, nil
+	case c.Schema != nil:
+		return c.Schema.MarshalHCL()
+	case c.Boolean != nil && !*c.Boolean:
+		return []byte("not {\n}"), nil
+	default:
+	}
+	return nil, nil
+}
+
+func writeBlock(buf *bytes.Buffer, keyword string, label *string, body []byte) {
+	buf.WriteString(keyword + " ")
+	if label != nil {
+		buf.Write(hclwrite.TokensForValue(cty.StringVal(*label)).Bytes())
+		buf.WriteString(" ")
+	}
+	buf.WriteString("{\n")
+	// the body is indented unevenly; format it before indenting it again
+	body = hclwrite.Format([]byte(strings.TrimSpace(string(body))))
+	for _, line := range strings.Split(string(body), "\n") {
+		if line != "" {
+			buf.WriteString("  " + line + "\n")
+		}
+	}
+	buf.WriteString("}\n")
+}
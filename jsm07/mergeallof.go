@@ -0,0 +1,795 @@
+package jsm07
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// UnsatisfiableError reports an allOf whose branches no instance can
+// satisfy together, such as two different types or a minimum greater
+// than the maximum.
+type UnsatisfiableError struct {
+	Pointer string
+	Keyword string
+	Message string
+}
+
+func (self *UnsatisfiableError) Error() string {
+	path := self.Pointer
+	if path == "" {
+		path = "/"
+	}
+	return fmt.Sprintf("%s: %s: %s", path, self.Keyword, self.Message)
+}
+
+// MergeAllOf returns a copy of the schema in which every allOf is folded
+// into the schema that holds it, accepting the same instances. Local
+// $ref branches are resolved and merged. Of the merged keywords,
+//
+//   - type is the intersection of the types, integer being a number;
+//   - required, properties, patternProperties and dependencies are the
+//     union, with the schemas for the same key merged in turn;
+//   - the bounds on numbers, strings, arrays and objects are the tighter
+//     of the two, and multipleOf is the least common multiple;
+//   - enum is the intersection of the values, and const must agree;
+//   - annotations such as title and default are kept from the parent, or
+//     taken from the first branch that has them.
+//
+// Keywords that cannot be written as one, such as two different patterns
+// or two anyOf, stay in an allOf of what is left, as do branches with a
+// remote $ref or a $ref back to a schema being merged. An *UnsatisfiableError is returned
+// if no instance can satisfy the branches together.
+func MergeAllOf(schema *Schema) (*Schema, error) {
+	if schema == nil {
+		return nil, nil
+	}
+	return newMerger(schema).schema("", schema)
+}
+
+type merger struct {
+	root      *Schema
+	refs      map[string]*Combined
+	resolving map[string]bool
+}
+
+// newMerger returns a merger resolving local references against root.
+func newMerger(root *Schema) *merger {
+	return &merger{root: root, refs: make(map[string]*Combined), resolving: make(map[string]bool)}
+}
+
+func (self *merger) combined(ptr string, c *Combined) (*Combined, error) {
+	if c == nil || c.Schema == nil {
+		return c, nil
+	}
+	s, err := self.schema(ptr, c.Schema)
+	if err != nil {
+		return nil, err
+	}
+	return NewCombinedWithSchema(s), nil
+}
+
+// schema merges the allOf of s and of all its subschemas.
+func (self *merger) schema(ptr string, s *Schema) (*Schema, error) {
+	tokens := make(map[*Combined][]string)
+	forEachChild(s, func(t []string, c *Combined) bool {
+		tokens[c] = t
+		return true
+	})
+	out, err := mapChildren(s, func(c *Combined) (*Combined, error) {
+		p := ptr
+		for _, t := range tokens[c] {
+			p = pointerAppend(p, t)
+		}
+		return self.combined(p, c)
+	})
+	if err != nil {
+		return nil, err
+	}
+	// keywords next to $ref are ignored, so there is nothing to merge into
+	if len(out.AllOf) == 0 || out.Ref != nil {
+		return out, nil
+	}
+
+	branches := out.AllOf
+	out.AllOf = nil
+	for i, branch := range branches {
+		p := pointerAppend(pointerAppend(ptr, "allOf"), strconv.Itoa(i))
+		target, err := self.deref(p, branch)
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case target == nil:
+			out.AllOf = append(out.AllOf, branch)
+		case isFalse(target):
+			return nil, &UnsatisfiableError{Pointer: p, Message: "schema is false"}
+		case isTrivial(target):
+		case target.Schema.ID != nil && !strings.HasPrefix(*target.Schema.ID, "#"):
+			// a branch with its own base URI is kept whole
+			out.AllOf = append(out.AllOf, branch)
+		default:
+			if out, err = self.merge(ptr, out, target.Schema); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return out, nil
+}
+
+// deref follows the local references of c to the merged schema they
+// point to. It returns nil for a remote reference, or one that leads
+// back to a schema being merged.
+func (self *merger) deref(ptr string, c *Combined) (*Combined, error) {
+	seen := make(map[string]bool)
+	for c != nil && c.Schema != nil && c.Schema.Ref != nil {
+		ref := *c.Schema.Ref
+		if seen[ref] || self.resolving[ref] {
+			return nil, nil
+		}
+		seen[ref] = true
+		if done, ok := self.refs[ref]; ok {
+			c = done
+			continue
+		}
+		if !strings.HasPrefix(ref, "#") {
+			return nil, nil
+		}
+		target, err := self.root.Resolve(ref)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", ptr, err)
+		}
+		self.resolving[ref] = true
+		done, err := self.combined(strings.TrimPrefix(ref, "#"), target)
+		delete(self.resolving, ref)
+		if err != nil {
+			return nil, err
+		}
+		self.refs[ref] = done
+		c = done
+	}
+	return c, nil
+}
+
+// pair merges two subschemas into one that both must hold.
+func (self *merger) pair(ptr string, x, y *Combined) (*Combined, error) {
+	switch {
+	case x == nil:
+		return y, nil
+	case y == nil:
+		return x, nil
+	case isFalse(x) || isFalse(y):
+		return NewCombinedWithBoolean(false), nil
+	case isTrivial(y):
+		return x, nil
+	case isTrivial(x):
+		return y, nil
+	default:
+	}
+
+	xs, err := self.deref(ptr, x)
+	if err != nil {
+		return nil, err
+	}
+	ys, err := self.deref(ptr, y)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case xs == nil || ys == nil:
+		return NewCombinedWithSchema(&Schema{AllOf: []*Combined{x, y}}), nil
+	case isFalse(xs) || isFalse(ys):
+		return NewCombinedWithBoolean(false), nil
+	case isTrivial(ys):
+		return x, nil
+	case isTrivial(xs):
+		return y, nil
+	default:
+	}
+	s, err := self.merge(ptr, xs.Schema, ys.Schema)
+	if err != nil {
+		return nil, err
+	}
+	return NewCombinedWithSchema(s), nil
+}
+
+// merge returns a new schema holding the keywords of both a and b. What
+// cannot be merged is added to its allOf.
+func (self *merger) merge(ptr string, a, b *Schema) (*Schema, error) {
+	out, _ := mapChildren(a, func(c *Combined) (*Combined, error) { return c, nil })
+	rest := new(Schema)
+	var err error
+
+	for _, kw := range []struct {
+		a **string
+		b *string
+	}{
+		{&out.Comment, b.Comment}, {&out.Title, b.Title}, {&out.Description, b.Description},
+	} {
+		if *kw.a == nil {
+			*kw.a = kw.b
+		}
+	}
+	if out.Default == nil {
+		out.Default = b.Default
+	}
+	if out.Examples == nil {
+		out.Examples = b.Examples
+	}
+	for _, kw := range []struct {
+		a **bool
+		b *bool
+	}{
+		{&out.ReadOnly, b.ReadOnly}, {&out.WriteOnly, b.WriteOnly}, {&out.UniqueItems, b.UniqueItems},
+	} {
+		if *kw.a == nil || (kw.b != nil && *kw.b) {
+			*kw.a = kw.b
+		}
+	}
+	for k, v := range b.Extensions {
+		if _, ok := out.Extensions[k]; !ok {
+			if out.Extensions == nil {
+				out.Extensions = make(map[string]json.RawMessage)
+			}
+			out.Extensions[k] = v
+		}
+	}
+	for _, kw := range []struct {
+		a    **string
+		b    *string
+		rest **string
+	}{
+		{&out.Format, b.Format, &rest.Format},
+		{&out.ContentMediaType, b.ContentMediaType, &rest.ContentMediaType},
+		{&out.ContentEncoding, b.ContentEncoding, &rest.ContentEncoding},
+		{&out.Pattern, b.Pattern, &rest.Pattern},
+	} {
+		switch {
+		case kw.b == nil:
+		case *kw.a == nil:
+			*kw.a = kw.b
+		case **kw.a != *kw.b:
+			*kw.rest = kw.b
+		default:
+		}
+	}
+
+	if out.Type, err = mergeTypes(ptr, a, b); err != nil {
+		return nil, err
+	}
+	if err := mergeValues(ptr, out, b); err != nil {
+		return nil, err
+	}
+
+	out.Maximum = tighter(out.Maximum, b.Maximum, false)
+	out.ExclusiveMaximum = tighter(out.ExclusiveMaximum, b.ExclusiveMaximum, false)
+	out.Minimum = tighter(out.Minimum, b.Minimum, true)
+	out.ExclusiveMinimum = tighter(out.ExclusiveMinimum, b.ExclusiveMinimum, true)
+	out.MultipleOf = multiple(out.MultipleOf, b.MultipleOf)
+	out.MaxLength = tighterCount(out.MaxLength, b.MaxLength, false)
+	out.MinLength = tighterCount(out.MinLength, b.MinLength, true)
+	out.MaxItems = tighterCount(out.MaxItems, b.MaxItems, false)
+	out.MinItems = tighterCount(out.MinItems, b.MinItems, true)
+	out.MaxProperties = tighterCount(out.MaxProperties, b.MaxProperties, false)
+	out.MinProperties = tighterCount(out.MinProperties, b.MinProperties, true)
+
+	if err := self.items(ptr, out, a, b); err != nil {
+		return nil, err
+	}
+	switch {
+	case b.Contains == nil:
+	case out.Contains == nil:
+		out.Contains = b.Contains
+	case !jsonSame(out.Contains, b.Contains):
+		rest.Contains = b.Contains
+	default:
+	}
+
+	for _, name := range b.Required {
+		if !hasString(out.Required, name) {
+			out.Required = append(out.Required, name)
+		}
+	}
+	if err := self.properties(ptr, out, a, b, rest); err != nil {
+		return nil, err
+	}
+	if out.PropertyNames, err = self.pair(pointerAppend(ptr, "propertyNames"), out.PropertyNames, b.PropertyNames); err != nil {
+		return nil, err
+	}
+	if err := self.dependencies(ptr, out, a, b); err != nil {
+		return nil, err
+	}
+	for _, k := range b.OrderedKeys("definitions") {
+		if _, ok := out.Definitions[k]; !ok {
+			if out.Definitions == nil {
+				out.Definitions = make(map[string]*Combined)
+			}
+			out.Definitions[k] = b.Definitions[k]
+		}
+	}
+	if out.Definitions != nil {
+		out.setKeyOrder("definitions", appendKeys(a.OrderedKeys("definitions"), b.OrderedKeys("definitions")))
+	}
+
+	switch {
+	case b.If == nil && b.Then == nil && b.Else == nil:
+	case out.If == nil && out.Then == nil && out.Else == nil:
+		out.If, out.Then, out.Else = b.If, b.Then, b.Else
+	case !jsonSame(out.If, b.If) || !jsonSame(out.Then, b.Then) || !jsonSame(out.Else, b.Else):
+		rest.If, rest.Then, rest.Else = b.If, b.Then, b.Else
+	default:
+	}
+	for _, kw := range []struct {
+		a    *[]*Combined
+		b    []*Combined
+		rest *[]*Combined
+	}{
+		{&out.AnyOf, b.AnyOf, &rest.AnyOf}, {&out.OneOf, b.OneOf, &rest.OneOf},
+	} {
+		switch {
+		case kw.b == nil:
+		case *kw.a == nil:
+			*kw.a = kw.b
+		case !jsonSame(*kw.a, kw.b):
+			*kw.rest = kw.b
+		default:
+		}
+	}
+	switch {
+	case b.Not == nil:
+	case out.Not == nil:
+		out.Not = b.Not
+	case !jsonSame(out.Not, b.Not):
+		// neither a nor b is the same as not (a or b)
+		out.Not = NewCombinedWithSchema(&Schema{AnyOf: []*Combined{out.Not, b.Not}})
+	default:
+	}
+
+	out.AllOf = append(out.AllOf, b.AllOf...)
+	if !isTrivial(NewCombinedWithSchema(rest)) {
+		out.AllOf = append(out.AllOf, NewCombinedWithSchema(rest))
+	}
+	return out, checkSatisfiable(ptr, out)
+}
+
+// mergeTypes intersects the types of a and b.
+func mergeTypes(ptr string, a, b *Schema) (*StringOrStringArray, error) {
+	switch {
+	case b.Type == nil:
+		return a.Type, nil
+	case a.Type == nil:
+		return b.Type, nil
+	default:
+	}
+	bSet := typeSet(b)
+	var types []string
+	for _, t := range typeList(a) {
+		switch {
+		case bSet[t]:
+		case t == "integer" && bSet["number"], t == "number" && bSet["integer"]:
+			t = "integer"
+		default:
+			continue
+		}
+		if !hasString(types, t) {
+			types = append(types, t)
+		}
+	}
+	switch len(types) {
+	case 0:
+		return nil, &UnsatisfiableError{Pointer: ptr, Keyword: "type", Message: fmt.Sprintf("types %s and %s have none in common", typeText(a), typeText(b))}
+	case 1:
+		return NewStringOrStringArrayWithString(types[0]), nil
+	default:
+	}
+	return NewStringOrStringArrayWithStringArray(types), nil
+}
+
+func typeList(s *Schema) []string {
+	if s.Type.String != nil {
+		return []string{*s.Type.String}
+	}
+	if s.Type.StringArray != nil {
+		return *s.Type.StringArray
+	}
+	return nil
+}
+
+// mergeValues intersects the enum and const of b into out.
+func mergeValues(ptr string, out, b *Schema) error {
+	if b.Const != nil {
+		if out.Const != nil && !jsonSame(out.Const, b.Const) {
+			return &UnsatisfiableError{Pointer: ptr, Keyword: "const", Message: fmt.Sprintf("const %s differs from %s", rawText(out.Const), rawText(b.Const))}
+		}
+		out.Const = b.Const
+	}
+	if b.Enumeration == nil {
+		return nil
+	}
+	if out.Enumeration == nil {
+		out.Enumeration = append([]SchemaEnumValue{}, b.Enumeration...)
+		return nil
+	}
+	var values []SchemaEnumValue
+	for _, x := range out.Enumeration {
+		for _, y := range b.Enumeration {
+			if jsonEqual(enumToInterface(x), enumToInterface(y)) {
+				values = append(values, x)
+				break
+			}
+		}
+	}
+	if len(values) == 0 {
+		return &UnsatisfiableError{Pointer: ptr, Keyword: "enum", Message: "enums have no value in common"}
+	}
+	out.Enumeration = values
+	return nil
+}
+
+// tighter returns the larger of two lower bounds, or the smaller of two
+// upper bounds.
+func tighter(x, y *IntegerOrFloat, lower bool) *IntegerOrFloat {
+	if x == nil {
+		return y
+	}
+	rx, ry := integerOrFloatToRat(x), integerOrFloatToRat(y)
+	if rx == nil || ry == nil {
+		return x
+	}
+	if c := rx.Cmp(ry); (lower && c < 0) || (!lower && c > 0) {
+		return y
+	}
+	return x
+}
+
+func tighterCount(x, y *int64, lower bool) *int64 {
+	if x == nil {
+		return y
+	}
+	if y != nil && ((lower && *y > *x) || (!lower && *y < *x)) {
+		return y
+	}
+	return x
+}
+
+// multiple returns the least common multiple of two multipleOf values.
+func multiple(x, y *IntegerOrFloat) *IntegerOrFloat {
+	rx, ry := integerOrFloatToRat(x), integerOrFloatToRat(y)
+	if rx == nil || ry == nil || rx.Sign() <= 0 || ry.Sign() <= 0 {
+		if x == nil {
+			return y
+		}
+		return x
+	}
+	if new(big.Rat).Quo(rx, ry).IsInt() {
+		return x
+	}
+	if new(big.Rat).Quo(ry, rx).IsInt() {
+		return y
+	}
+	// lcm(p/q, r/s) = lcm(p, r) / gcd(q, s) for fractions in lowest terms
+	num := new(big.Int).GCD(nil, nil, rx.Num(), ry.Num())
+	num.Div(new(big.Int).Mul(rx.Num(), ry.Num()), num)
+	den := new(big.Int).GCD(nil, nil, rx.Denom(), ry.Denom())
+	lcm := new(big.Rat).SetFrac(num, den)
+	if lcm.IsInt() && lcm.Num().IsInt64() {
+		return NewIntegerOrFloatWithInteger(lcm.Num().Int64())
+	}
+	f, _ := lcm.Float64()
+	return NewIntegerOrFloatWithFloat(f)
+}
+
+func hasString(arr []string, s string) bool {
+	for _, v := range arr {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// appendKeys appends to keys those of more not already in it.
+func appendKeys(keys, more []string) []string {
+	out := append([]string{}, keys...)
+	for _, k := range more {
+		if !hasString(out, k) {
+			out = append(out, k)
+		}
+	}
+	return out
+}
+
+// itemAt returns the schema s applies to the array item at index i, or
+// past the tuple if i is negative.
+func itemAt(s *Schema, i int) *Combined {
+	switch {
+	case s.Items == nil:
+		return nil
+	case s.Items.CombinedArray == nil:
+		return s.Items.Combined
+	case i >= 0 && i < len(*s.Items.CombinedArray):
+		return (*s.Items.CombinedArray)[i]
+	default:
+	}
+	return s.AdditionalItems
+}
+
+func tupleLength(s *Schema) int {
+	if s.Items == nil || s.Items.CombinedArray == nil {
+		return -1
+	}
+	return len(*s.Items.CombinedArray)
+}
+
+func (self *merger) items(ptr string, out, a, b *Schema) error {
+	p := pointerAppend(ptr, "items")
+	n := max(tupleLength(a), tupleLength(b))
+	if n < 0 {
+		item, err := self.pair(p, itemAt(a, -1), itemAt(b, -1))
+		if err != nil {
+			return err
+		}
+		if item != nil {
+			out.Items = NewCombinedOrCombinedArrayWithCombined(item)
+		}
+		return nil
+	}
+
+	tuple := make([]*Combined, n)
+	for i := range tuple {
+		item, err := self.pair(pointerAppend(p, strconv.Itoa(i)), itemAt(a, i), itemAt(b, i))
+		if err != nil {
+			return err
+		}
+		if item == nil {
+			item = NewCombinedWithBoolean(true)
+		}
+		tuple[i] = item
+	}
+	additional, err := self.pair(pointerAppend(ptr, "additionalItems"), itemAt(a, -1), itemAt(b, -1))
+	if err != nil {
+		return err
+	}
+	out.Items = NewCombinedOrCombinedArrayWithCombinedArray(tuple)
+	out.AdditionalItems = additional
+	return nil
+}
+
+// memberSchemas returns the schemas s applies to a property not in
+// properties named k.
+func memberSchemas(s *Schema, k string) []*Combined {
+	var found []*Combined
+	for _, pattern := range s.OrderedKeys("patternProperties") {
+		if re, err := regexp.Compile(pattern); err == nil && re.MatchString(k) {
+			found = append(found, s.PatternProperties[pattern])
+		}
+	}
+	if found == nil {
+		return []*Combined{s.AdditionalProperties}
+	}
+	return found
+}
+
+// mergesMembers tells whether the properties of a and b can be merged
+// into one properties, patternProperties and additionalProperties. This
+// is so if they have the same patterns, or one of them allows any
+// property it does not name.
+func mergesMembers(a, b *Schema) bool {
+	open := func(s *Schema) bool {
+		return len(s.PatternProperties) == 0 && isTrivial(s.AdditionalProperties)
+	}
+	if open(a) || open(b) {
+		return true
+	}
+	if len(a.PatternProperties) != len(b.PatternProperties) {
+		return false
+	}
+	for k := range a.PatternProperties {
+		if _, ok := b.PatternProperties[k]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func (self *merger) properties(ptr string, out, a, b, rest *Schema) error {
+	if b.Properties == nil && b.PatternProperties == nil && b.AdditionalProperties == nil {
+		return nil
+	}
+	if !mergesMembers(a, b) {
+		rest.Properties, rest.PatternProperties, rest.AdditionalProperties = b.Properties, b.PatternProperties, b.AdditionalProperties
+		return nil
+	}
+
+	keys := appendKeys(a.OrderedKeys("properties"), b.OrderedKeys("properties"))
+	properties := make(map[string]*Combined, len(keys))
+	for _, k := range keys {
+		var parts []*Combined
+		for _, s := range []*Schema{a, b} {
+			if c, ok := s.Properties[k]; ok {
+				parts = append(parts, c)
+			} else {
+				parts = append(parts, memberSchemas(s, k)...)
+			}
+		}
+		p := pointerAppend(pointerAppend(ptr, "properties"), k)
+		var merged *Combined
+		for _, c := range parts {
+			var err error
+			if merged, err = self.pair(p, merged, c); err != nil {
+				return err
+			}
+		}
+		if merged == nil {
+			merged = NewCombinedWithSchema(new(Schema))
+		}
+		properties[k] = merged
+	}
+	out.Properties = properties
+	out.setKeyOrder("properties", keys)
+
+	patterns := appendKeys(a.OrderedKeys("patternProperties"), b.OrderedKeys("patternProperties"))
+	if len(patterns) > 0 {
+		out.PatternProperties = make(map[string]*Combined, len(patterns))
+		for _, k := range patterns {
+			merged, err := self.pair(pointerAppend(pointerAppend(ptr, "patternProperties"), k), a.PatternProperties[k], b.PatternProperties[k])
+			if err != nil {
+				return err
+			}
+			out.PatternProperties[k] = merged
+		}
+		out.setKeyOrder("patternProperties", patterns)
+	}
+
+	var err error
+	out.AdditionalProperties, err = self.pair(pointerAppend(ptr, "additionalProperties"), a.AdditionalProperties, b.AdditionalProperties)
+	return err
+}
+
+func (self *merger) dependencies(ptr string, out, a, b *Schema) error {
+	if b.Dependencies == nil {
+		return nil
+	}
+	asSchema := func(dep *CombinedOrStringArray) *Combined {
+		if dep.StringArray != nil {
+			return NewCombinedWithSchema(&Schema{SchemaObject: SchemaObject{Required: *dep.StringArray}})
+		}
+		return dep.Combined
+	}
+
+	keys := appendKeys(a.OrderedKeys("dependencies"), b.OrderedKeys("dependencies"))
+	if out.Dependencies == nil {
+		out.Dependencies = make(map[string]*CombinedOrStringArray, len(keys))
+	}
+	for _, k := range keys {
+		x, y := a.Dependencies[k], b.Dependencies[k]
+		switch {
+		case y == nil:
+		case x == nil:
+			out.Dependencies[k] = y
+		case x.StringArray != nil && y.StringArray != nil:
+			out.Dependencies[k] = NewCombinedOrStringArrayWithStringArray(appendKeys(*x.StringArray, *y.StringArray))
+		default:
+			merged, err := self.pair(pointerAppend(pointerAppend(ptr, "dependencies"), k), asSchema(x), asSchema(y))
+			if err != nil {
+				return err
+			}
+			out.Dependencies[k] = NewCombinedOrStringArrayWithCombined(merged)
+		}
+	}
+	out.setKeyOrder("dependencies", keys)
+	return nil
+}
+
+// checkSatisfiable reports the bounds and values of s that no instance
+// can meet. Bounds on a kind of value only make s unsatisfiable if that
+// is the only kind its type allows.
+func checkSatisfiable(ptr string, s *Schema) error {
+	types := typeSet(s)
+	only := func(kinds ...string) bool {
+		if types == nil {
+			return false
+		}
+		for t := range types {
+			if !hasString(kinds, t) {
+				return false
+			}
+		}
+		return true
+	}
+	fail := func(keyword, format string, args ...interface{}) error {
+		return &UnsatisfiableError{Pointer: ptr, Keyword: keyword, Message: fmt.Sprintf(format, args...)}
+	}
+
+	allowed := func(v interface{}) bool {
+		if types == nil {
+			return true
+		}
+		for t := range types {
+			if isOfType(v, t) {
+				return true
+			}
+		}
+		return false
+	}
+	if s.Const != nil {
+		var v interface{}
+		if err := json.Unmarshal(*s.Const, &v); err == nil {
+			if !allowed(v) {
+				return fail("const", "const %s is not of type %s", rawText(s.Const), typeText(s))
+			}
+			if s.Enumeration != nil {
+				found := false
+				for _, e := range s.Enumeration {
+					found = found || jsonEqual(enumToInterface(e), v)
+				}
+				if !found {
+					return fail("const", "const %s is not in enum", rawText(s.Const))
+				}
+			}
+		}
+	}
+	if s.Enumeration != nil {
+		var values []SchemaEnumValue
+		for _, e := range s.Enumeration {
+			if allowed(enumToInterface(e)) {
+				values = append(values, e)
+			}
+		}
+		if len(values) == 0 {
+			return fail("enum", "no enum value is of type %s", typeText(s))
+		}
+		s.Enumeration = values
+	}
+
+	if only("number", "integer") {
+		for _, bound := range []struct {
+			lower, upper     *IntegerOrFloat
+			lowerKw, upperKw string
+			strict           bool
+		}{
+			{s.Minimum, s.Maximum, "minimum", "maximum", false},
+			{s.Minimum, s.ExclusiveMaximum, "minimum", "exclusiveMaximum", true},
+			{s.ExclusiveMinimum, s.Maximum, "exclusiveMinimum", "maximum", true},
+			{s.ExclusiveMinimum, s.ExclusiveMaximum, "exclusiveMinimum", "exclusiveMaximum", true},
+		} {
+			lo, hi := integerOrFloatToRat(bound.lower), integerOrFloatToRat(bound.upper)
+			if lo == nil || hi == nil {
+				continue
+			}
+			if c := lo.Cmp(hi); c > 0 || (c == 0 && bound.strict) {
+				return fail(bound.lowerKw, "%s %s and %s %s allow no number", bound.lowerKw, ratString(lo), bound.upperKw, ratString(hi))
+			}
+		}
+	}
+	for _, count := range []struct {
+		kind             string
+		lower, upper     *int64
+		lowerKw, upperKw string
+	}{
+		{"string", s.MinLength, s.MaxLength, "minLength", "maxLength"},
+		{"array", s.MinItems, s.MaxItems, "minItems", "maxItems"},
+		{"object", s.MinProperties, s.MaxProperties, "minProperties", "maxProperties"},
+	} {
+		if count.lower != nil && count.upper != nil && *count.lower > *count.upper && only(count.kind) {
+			return fail(count.lowerKw, "%s %d is greater than %s %d", count.lowerKw, *count.lower, count.upperKw, *count.upper)
+		}
+	}
+	if only("object") {
+		for _, name := range s.Required {
+			c, ok := s.Properties[name]
+			if !ok {
+				if members := memberSchemas(s, name); len(members) == 1 {
+					c = members[0]
+				}
+			}
+			if isFalse(c) {
+				return fail("required", "required property %q is not allowed", name)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,179 @@
+package jsm07
+
+import (
+	"encoding/json"
+	"errors"
+	"math/rand"
+	"os"
+	"testing"
+)
+
+func TestMergeAllOf(t *testing.T) {
+	tests := []struct {
+		name   string
+		schema string
+		want   string
+	}{
+		{
+			"types",
+			`{"allOf": [{"type": ["string", "number", "null"]}, {"type": ["integer", "string"]}]}`,
+			`{"type": ["string", "integer"]}`,
+		},
+		{
+			"bounds",
+			`{"type": "number", "minimum": 1, "maximum": 10, "allOf": [{"minimum": 3, "exclusiveMaximum": 8}, {"maximum": 7, "multipleOf": 4}, {"multipleOf": 6}]}`,
+			`{"type": "number", "minimum": 3, "maximum": 7, "exclusiveMaximum": 8, "multipleOf": 12}`,
+		},
+		{
+			"lengths",
+			`{"allOf": [{"minLength": 2, "maxItems": 5, "uniqueItems": true}, {"minLength": 4, "maxItems": 3}]}`,
+			`{"minLength": 4, "maxItems": 3, "uniqueItems": true}`,
+		},
+		{
+			"enum",
+			`{"allOf": [{"enum": ["a", "b", 1]}, {"enum": [1, "b", "c"]}]}`,
+			`{"enum": ["b", 1]}`,
+		},
+		{
+			"properties",
+			`{"type": "object", "title": "Named", "required": ["id"], "properties": {"id": {"type": "integer"}}, "allOf": [{"title": "Other", "required": ["name", "id"], "properties": {"id": {"minimum": 0}, "name": {"type": "string"}}}]}`,
+			`{"type": "object", "title": "Named", "required": ["id", "name"], "properties": {"id": {"type": "integer", "minimum": 0}, "name": {"type": "string"}}}`,
+		},
+		{
+			"additionalProperties",
+			`{"allOf": [{"properties": {"a": {"type": "string"}}, "additionalProperties": {"type": "integer"}}, {"properties": {"b": {"minimum": 1}}}]}`,
+			`{"properties": {"a": {"type": "string"}, "b": {"type": "integer", "minimum": 1}}, "additionalProperties": {"type": "integer"}}`,
+		},
+		{
+			"ref",
+			`{"allOf": [{"$ref": "#/definitions/base"}, {"properties": {"b": {"type": "string"}}}], "definitions": {"base": {"type": "object", "properties": {"a": {"type": "integer"}}}}}`,
+			`{"type": "object", "properties": {"a": {"type": "integer"}, "b": {"type": "string"}}, "definitions": {"base": {"type": "object", "properties": {"a": {"type": "integer"}}}}}`,
+		},
+		{
+			"nested",
+			`{"properties": {"a": {"allOf": [{"type": "string"}, {"maxLength": 3}]}}}`,
+			`{"properties": {"a": {"type": "string", "maxLength": 3}}}`,
+		},
+		{
+			"tuple",
+			`{"allOf": [{"items": [{"type": "string"}], "additionalItems": false}, {"items": {"minLength": 1}}]}`,
+			`{"items": [{"type": "string", "minLength": 1}], "additionalItems": false}`,
+		},
+		{
+			"dependencies",
+			`{"allOf": [{"dependencies": {"a": ["b"]}}, {"dependencies": {"a": ["c"], "d": {"required": ["e"]}}}]}`,
+			`{"dependencies": {"a": ["b", "c"], "d": {"required": ["e"]}}}`,
+		},
+		{
+			"not",
+			`{"not": {"type": "null"}, "allOf": [{"not": {"const": 0}}]}`,
+			`{"not": {"anyOf": [{"type": "null"}, {"const": 0}]}}`,
+		},
+		{
+			"rest",
+			`{"pattern": "^a", "anyOf": [{"type": "string"}], "allOf": [{"pattern": "b$", "minLength": 2, "anyOf": [{"maxLength": 5}]}]}`,
+			`{"pattern": "^a", "minLength": 2, "anyOf": [{"type": "string"}], "allOf": [{"pattern": "b$", "anyOf": [{"maxLength": 5}]}]}`,
+		},
+		{
+			"recursive",
+			`{"$ref": "#/definitions/node", "definitions": {"node": {"allOf": [{"properties": {"next": {"$ref": "#/definitions/node"}}}, {"type": "object"}]}}}`,
+			`{"$ref": "#/definitions/node", "definitions": {"node": {"type": "object", "properties": {"next": {"$ref": "#/definitions/node"}}}}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := new(Schema)
+			if err := json.Unmarshal([]byte(tt.schema), s); err != nil {
+				t.Fatalf("Failed to unmarshal schema: %v", err)
+			}
+			merged, err := MergeAllOf(s)
+			if err != nil {
+				t.Fatalf("Failed to merge allOf: %v", err)
+			}
+			want := new(Schema)
+			if err := json.Unmarshal([]byte(tt.want), want); err != nil {
+				t.Fatalf("Failed to unmarshal expected schema: %v", err)
+			}
+			if !jsonSame(merged, want) {
+				got, _ := json.Marshal(merged)
+				t.Errorf("Expected %s, got %s", tt.want, got)
+			}
+
+			rnd := rand.New(rand.NewSource(1))
+			for i := 0; i < 20; i++ {
+				for _, invalid := range []bool{false, true} {
+					instance, err := Generate(s, rnd, &GenerateOptions{Invalid: invalid})
+					if err != nil {
+						continue
+					}
+					if (s.Validate(instance) == nil) != (merged.Validate(instance) == nil) {
+						t.Fatalf("Expected %v to be as valid for the merged schema", instance)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestMergeAllOfUnsatisfiable(t *testing.T) {
+	tests := []struct {
+		name    string
+		schema  string
+		pointer string
+		keyword string
+	}{
+		{"type", `{"allOf": [{"type": "string"}, {"type": ["integer", "null"]}]}`, "", "type"},
+		{"bounds", `{"type": "integer", "allOf": [{"minimum": 5}, {"exclusiveMaximum": 5}]}`, "", "minimum"},
+		{"length", `{"type": "string", "allOf": [{"minLength": 5}, {"maxLength": 3}]}`, "", "minLength"},
+		{"enum", `{"allOf": [{"enum": ["a"]}, {"enum": ["b"]}]}`, "", "enum"},
+		{"const", `{"allOf": [{"const": 1}, {"type": "string"}]}`, "", "const"},
+		{"required", `{"type": "object", "required": ["a"], "allOf": [{"additionalProperties": false}]}`, "", "required"},
+		{"false", `{"allOf": [{"type": "string"}, false]}`, "/allOf/1", ""},
+		{"nested", `{"properties": {"a/b": {"allOf": [{"const": 1}, {"const": 2}]}}}`, "/properties/a~1b", "const"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := new(Schema)
+			if err := json.Unmarshal([]byte(tt.schema), s); err != nil {
+				t.Fatalf("Failed to unmarshal schema: %v", err)
+			}
+			_, err := MergeAllOf(s)
+			var unsat *UnsatisfiableError
+			if !errors.As(err, &unsat) {
+				t.Fatalf("Expected an UnsatisfiableError, got %v", err)
+			}
+			if unsat.Pointer != tt.pointer || unsat.Keyword != tt.keyword {
+				t.Errorf("Expected %q %q, got %q %q", tt.pointer, tt.keyword, unsat.Pointer, unsat.Keyword)
+			}
+		})
+	}
+
+	// bounds on numbers do not matter when other types are allowed
+	s := new(Schema)
+	if err := json.Unmarshal([]byte(`{"allOf": [{"minimum": 5}, {"maximum": 3}]}`), s); err != nil {
+		t.Fatalf("Failed to unmarshal schema: %v", err)
+	}
+	if _, err := MergeAllOf(s); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+}
+
+func TestMergeAllOfMCP(t *testing.T) {
+	bs, err := os.ReadFile("samples/mcp.json")
+	if err != nil {
+		t.Fatalf("Failed to read mcp.json: %v", err)
+	}
+	mcp := new(Schema)
+	if err := json.Unmarshal(bs, mcp); err != nil {
+		t.Fatalf("Failed to unmarshal mcp.json: %v", err)
+	}
+	merged, err := MergeAllOf(mcp)
+	if err != nil {
+		t.Fatalf("Failed to merge allOf: %v", err)
+	}
+	if !jsonSame(merged, mcp) {
+		t.Errorf("Expected the schema without allOf to be unchanged")
+	}
+}
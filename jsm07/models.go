@@ -78,4 +78,15 @@ type Schema struct {
 	AnyOf []*Combined `json:"anyOf,omitempty" hcl:"anyOf,block"`
 	OneOf []*Combined `json:"oneOf,omitempty" hcl:"oneOf,block"`
 	Not   *Combined   `json:"not,omitempty" hcl:"not,block"`
+
+	// Extensions holds keywords that are not fields above, such as
+	// x-go-type or markdownDescription, keyed by their JSON names. HCL
+	// leaves out those whose names are not identifiers; see
+	// SkippedExtensions.
+	Extensions map[string]json.RawMessage `json:"-" hcl:"-"`
+
+	// KeyOrder records the order in which the keys of properties,
+	// patternProperties, dependencies and definitions were written, keyed
+	// by keyword. Keys missing from it are written after, sorted.
+	KeyOrder map[string][]string `json:"-" hcl:"-"`
 }
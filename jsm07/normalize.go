@@ -0,0 +1,271 @@
+package jsm07
+
+import (
+	"encoding/json"
+	"math"
+	"sort"
+)
+
+// Normalize returns a canonical copy of the schema, accepting the same
+// instances, in which
+//
+//   - a type array is sorted and de-duplicated, and a single type is
+//     written as a string;
+//   - required is sorted and de-duplicated;
+//   - items written as an array of equal schemas, or as an empty array,
+//     is written as a single schema, and additionalItems is dropped where
+//     it has no effect;
+//   - true and empty subschemas are removed where they allow everything,
+//     such as in additionalProperties, items, allOf, then or else, and an
+//     anyOf with such a branch is removed;
+//   - an allOf of one schema is merged into its parent if they share no
+//     keywords;
+//   - numbers that are integers are stored in IntegerOrFloat.Integer;
+//   - the recorded key order is dropped, so that properties and
+//     definitions are written sorted.
+//
+// Two schemas that differ only in these respects normalize to the same
+// JSON and HCL output.
+func Normalize(schema *Schema) *Schema {
+	if schema == nil {
+		return nil
+	}
+	return normalizeSchema(schema)
+}
+
+func normalizeCombined(c *Combined) *Combined {
+	if c == nil {
+		return nil
+	}
+	if c.Schema != nil {
+		return NewCombinedWithSchema(normalizeSchema(c.Schema))
+	}
+	if c.Boolean != nil {
+		return NewCombinedWithBoolean(*c.Boolean)
+	}
+	return &Combined{}
+}
+
+func normalizeSchema(s *Schema) *Schema {
+	out, _ := mapChildren(s, func(c *Combined) (*Combined, error) {
+		return normalizeCombined(c), nil
+	})
+	out.KeyOrder = nil
+
+	if out.Type != nil && out.Type.StringArray != nil {
+		types := uniqueSorted(*out.Type.StringArray)
+		if len(types) == 1 {
+			out.Type = &StringOrStringArray{String: &types[0]}
+		} else {
+			out.Type = &StringOrStringArray{StringArray: &types}
+		}
+	}
+	if out.Required != nil {
+		out.Required = uniqueSorted(out.Required)
+	}
+	for _, n := range []**IntegerOrFloat{&out.MultipleOf, &out.Maximum, &out.ExclusiveMaximum, &out.Minimum, &out.ExclusiveMinimum} {
+		*n = normalizeNumber(*n)
+	}
+
+	normalizeItems(out)
+	removeTrivial(out)
+	return inlineAllOf(out)
+}
+
+func uniqueSorted(arr []string) []string {
+	out := append([]string{}, arr...)
+	sort.Strings(out)
+	n := 0
+	for i, v := range out {
+		if i == 0 || v != out[n-1] {
+			out[n] = v
+			n++
+		}
+	}
+	return out[:n]
+}
+
+func normalizeNumber(n *IntegerOrFloat) *IntegerOrFloat {
+	if n == nil || n.Float == nil {
+		return n
+	}
+	f := *n.Float
+	if f == math.Trunc(f) && f >= math.MinInt64 && f < math.MaxInt64 {
+		return NewIntegerOrFloatWithInteger(int64(f))
+	}
+	return n
+}
+
+// isTrivial reports whether c allows every instance.
+func isTrivial(c *Combined) bool {
+	if c == nil {
+		return true
+	}
+	if c.Schema == nil {
+		return c.Boolean == nil || *c.Boolean
+	}
+	return len(schemaKeys(c.Schema)) == 0
+}
+
+// schemaKeys returns the keywords set in s as JSON would write them.
+func schemaKeys(s *Schema) map[string]json.RawMessage {
+	bs, err := json.Marshal(s)
+	if err != nil {
+		return map[string]json.RawMessage{"": nil}
+	}
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(bs, &m); err != nil {
+		return map[string]json.RawMessage{"": nil}
+	}
+	for k, v := range m {
+		if string(v) == "null" {
+			delete(m, k)
+		}
+	}
+	return m
+}
+
+// normalizeItems writes a tuple of schemas equal to additionalItems, or
+// an empty tuple, as a single items schema.
+func normalizeItems(s *Schema) {
+	if s.Items != nil && s.Items.CombinedArray != nil {
+		tuple := *s.Items.CombinedArray
+		same := true
+		for _, c := range tuple {
+			if !jsonSame(c, s.AdditionalItems) && !(isTrivial(c) && isTrivial(s.AdditionalItems)) {
+				same = false
+				break
+			}
+		}
+		if same {
+			s.Items = NewCombinedOrCombinedArrayWithCombined(s.AdditionalItems)
+			s.AdditionalItems = nil
+		}
+	}
+	if s.Items != nil && s.Items.CombinedArray == nil && isTrivial(s.Items.Combined) {
+		s.Items = nil
+	}
+	// additionalItems only applies after a tuple
+	if s.Items == nil || s.Items.CombinedArray == nil {
+		s.AdditionalItems = nil
+	}
+}
+
+// removeTrivial drops subschemas that allow everything where dropping
+// them does not change what s accepts.
+func removeTrivial(s *Schema) {
+	if isTrivial(s.AdditionalProperties) {
+		s.AdditionalProperties = nil
+		for k, c := range s.PatternProperties {
+			if isTrivial(c) {
+				delete(s.PatternProperties, k)
+			}
+		}
+		if len(s.PatternProperties) == 0 {
+			s.PatternProperties = nil
+		}
+	}
+	if s.Items != nil && s.Items.CombinedArray != nil && isTrivial(s.AdditionalItems) {
+		s.AdditionalItems = nil
+	}
+	if isTrivial(s.PropertyNames) {
+		s.PropertyNames = nil
+	}
+	for k, dep := range s.Dependencies {
+		if dep == nil || (dep.StringArray != nil && len(*dep.StringArray) == 0) || (dep.StringArray == nil && isTrivial(dep.Combined)) {
+			delete(s.Dependencies, k)
+		}
+	}
+	if len(s.Dependencies) == 0 {
+		s.Dependencies = nil
+	}
+
+	if isTrivial(s.Then) {
+		s.Then = nil
+	}
+	if isTrivial(s.Else) {
+		s.Else = nil
+	}
+	if s.Then == nil && s.Else == nil {
+		s.If = nil
+	}
+
+	var allOf []*Combined
+	for _, c := range s.AllOf {
+		if !isTrivial(c) {
+			allOf = append(allOf, c)
+		}
+	}
+	s.AllOf = allOf
+	for _, c := range s.AnyOf {
+		if isTrivial(c) {
+			s.AnyOf = nil
+			break
+		}
+	}
+}
+
+// annotations are the keywords that may stand next to $ref without
+// changing what the schema accepts.
+var annotations = map[string]bool{
+	"$comment": true, "title": true, "description": true, "default": true, "examples": true, "readOnly": true, "writeOnly": true,
+}
+
+// siblings maps keywords to those whose values change what they apply to.
+var siblings = map[string][]string{
+	"additionalProperties": {"properties", "patternProperties"},
+	"additionalItems":      {"items"},
+}
+
+// inlineAllOf merges the only allOf schema into s when they share no
+// keywords, neither one's $ref would hide the other's keywords, and
+// neither one's additionalProperties or additionalItems would then apply
+// to fewer members.
+func inlineAllOf(s *Schema) *Schema {
+	if len(s.AllOf) != 1 || s.AllOf[0].Schema == nil || s.Ref != nil {
+		return s
+	}
+	parent := *s
+	parent.AllOf = nil
+	outer, inner := schemaKeys(&parent), schemaKeys(s.AllOf[0].Schema)
+	if _, ok := inner["$id"]; ok {
+		return s
+	}
+	for k := range inner {
+		if _, ok := outer[k]; ok {
+			return s
+		}
+	}
+	for k, others := range siblings {
+		for _, other := range others {
+			_, outerKey := outer[k]
+			_, innerKey := inner[k]
+			_, outerOther := outer[other]
+			_, innerOther := inner[other]
+			if (outerKey && innerOther) || (innerKey && outerOther) {
+				return s
+			}
+		}
+	}
+	if _, ok := inner["$ref"]; ok {
+		for k := range outer {
+			if !annotations[k] {
+				return s
+			}
+		}
+	}
+
+	for k, v := range inner {
+		outer[k] = v
+	}
+	bs, err := json.Marshal(outer)
+	if err != nil {
+		return s
+	}
+	merged := new(Schema)
+	if err := json.Unmarshal(bs, merged); err != nil {
+		return s
+	}
+	merged.KeyOrder = nil
+	return merged
+}
@@ -0,0 +1,174 @@
+package jsm07
+
+import (
+	"encoding/json"
+	"math/rand"
+	"os"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name     string
+		schema   string
+		expected string
+	}{
+		{"type", `{"type": ["string"]}`, `{"type": "string"}`},
+		{"types", `{"type": ["string", "null", "string"]}`, `{"type": ["null", "string"]}`},
+		{"required", `{"required": ["b", "a", "b"]}`, `{"required": ["a", "b"]}`},
+		{"integers", `{"minimum": 1.0, "maximum": 2.5, "multipleOf": 0.5}`, `{"minimum": 1, "maximum": 2.5, "multipleOf": 0.5}`},
+		{"tuple", `{"items": [{"type": "string"}, {"type": "string"}], "additionalItems": {"type": "string"}}`, `{"items": {"type": "string"}}`},
+		{"empty tuple", `{"items": [], "additionalItems": {"type": "string"}}`, `{"items": {"type": "string"}}`},
+		{"tuple kept", `{"items": [{"type": "string"}], "additionalItems": true}`, `{"items": [{"type": "string"}]}`},
+		{"additionalItems", `{"items": {"type": "string"}, "additionalItems": false}`, `{"items": {"type": "string"}}`},
+		{"trivial", `{"additionalProperties": {}, "patternProperties": {"^x": true}, "items": true, "propertyNames": {}, "dependencies": {"a": [], "b": {}}}`, `{}`},
+		{"pattern kept", `{"additionalProperties": false, "patternProperties": {"^x": true}}`, `{"additionalProperties": false, "patternProperties": {"^x": true}}`},
+		{"if", `{"if": {"type": "string"}, "then": {}, "else": true}`, `{}`},
+		{"anyOf", `{"anyOf": [{"type": "string"}, {}]}`, `{}`},
+		{"allOf", `{"description": "d", "allOf": [{"type": "string"}, true]}`, `{"description": "d", "type": "string"}`},
+		{"allOf ref", `{"description": "d", "allOf": [{"$ref": "#/definitions/a"}], "definitions": {"a": {}}}`, `{"description": "d", "allOf": [{"$ref": "#/definitions/a"}], "definitions": {"a": {}}}`},
+		{"allOf ref alone", `{"title": "t", "allOf": [{"$ref": "#/definitions/a"}]}`, `{"title": "t", "$ref": "#/definitions/a"}`},
+		{"allOf shared", `{"type": "object", "allOf": [{"type": "object", "required": ["a"]}]}`, `{"type": "object", "allOf": [{"type": "object", "required": ["a"]}]}`},
+		{"nested", `{"properties": {"a": {"type": ["integer"], "allOf": [{"minimum": 0.0}]}}}`, `{"properties": {"a": {"type": "integer", "minimum": 0}}}`},
+		{"allOf properties", `{"type": "object", "allOf": [{"properties": {"a": {"type": "integer"}}}], "additionalProperties": false}`, `{"type": "object", "allOf": [{"properties": {"a": {"type": "integer"}}}], "additionalProperties": false}`},
+		{"allOf additionalProperties", `{"patternProperties": {"^a": {"type": "integer"}}, "allOf": [{"additionalProperties": false}]}`, `{"patternProperties": {"^a": {"type": "integer"}}, "allOf": [{"additionalProperties": false}]}`},
+		{"allOf patternProperties", `{"additionalProperties": false, "allOf": [{"patternProperties": {"^a": {"type": "integer"}}}]}`, `{"additionalProperties": false, "allOf": [{"patternProperties": {"^a": {"type": "integer"}}}]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := new(Schema)
+			if err := json.Unmarshal([]byte(tt.schema), s); err != nil {
+				t.Fatalf("Failed to unmarshal schema: %v", err)
+			}
+			before, err := json.Marshal(s)
+			if err != nil {
+				t.Fatalf("Failed to marshal schema: %v", err)
+			}
+			got, err := json.Marshal(Normalize(s))
+			if err != nil {
+				t.Fatalf("Failed to marshal normalized schema: %v", err)
+			}
+
+			expected := new(Schema)
+			if err := json.Unmarshal([]byte(tt.expected), expected); err != nil {
+				t.Fatalf("Failed to unmarshal expected schema: %v", err)
+			}
+			want, err := json.Marshal(expected)
+			if err != nil {
+				t.Fatalf("Failed to marshal expected schema: %v", err)
+			}
+			if string(got) != string(want) {
+				t.Errorf("Expected\n%s\ngot\n%s", want, got)
+			}
+
+			if after, _ := json.Marshal(s); string(after) != string(before) {
+				t.Errorf("Expected the schema unchanged, got %s", after)
+			}
+		})
+	}
+}
+
+func TestNormalizeSameInstances(t *testing.T) {
+	tests := []struct {
+		schema    string
+		instances []string
+	}{
+		{`{"type": "object", "allOf": [{"properties": {"a": {"type": "integer"}}}], "additionalProperties": false}`, []string{`{}`, `{"a": 1}`, `{"b": 1}`}},
+		{`{"patternProperties": {"^a": {"type": "integer"}}, "allOf": [{"additionalProperties": false}]}`, []string{`{}`, `{"ab": 1}`, `{"b": 1}`}},
+		{`{"additionalProperties": false, "allOf": [{"patternProperties": {"^a": {"type": "integer"}}}]}`, []string{`{}`, `{"ab": 1}`, `{"b": 1}`}},
+		{`{"items": [{"type": "string"}], "allOf": [{"additionalItems": false}]}`, []string{`[]`, `["x"]`, `["x", 1]`}},
+		{`{"items": {"type": "string"}, "allOf": [{"additionalItems": false}]}`, []string{`[]`, `["x", "y"]`}},
+	}
+
+	for _, tt := range tests {
+		s := new(Schema)
+		if err := json.Unmarshal([]byte(tt.schema), s); err != nil {
+			t.Fatalf("Failed to unmarshal schema: %v", err)
+		}
+		normalized := Normalize(s)
+		for _, text := range tt.instances {
+			var instance interface{}
+			if err := json.Unmarshal([]byte(text), &instance); err != nil {
+				t.Fatalf("Failed to unmarshal instance: %v", err)
+			}
+			if (s.Validate(instance) == nil) != (normalized.Validate(instance) == nil) {
+				t.Errorf("Expected %s to be as valid for the normalized %s", text, tt.schema)
+			}
+		}
+	}
+}
+
+func TestNormalizeCanonical(t *testing.T) {
+	a, b := new(Schema), new(Schema)
+	if err := json.Unmarshal([]byte(`{"properties": {"z": {"type": ["string"]}, "a": {"maximum": 3.0}}, "required": ["z", "a"]}`), a); err != nil {
+		t.Fatalf("Failed to unmarshal schema: %v", err)
+	}
+	if err := json.Unmarshal([]byte(`{"required": ["a", "z", "a"], "properties": {"a": {"maximum": 3}, "z": {"allOf": [{"type": "string"}]}}}`), b); err != nil {
+		t.Fatalf("Failed to unmarshal schema: %v", err)
+	}
+
+	x, err := json.Marshal(Normalize(a))
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+	y, err := json.Marshal(Normalize(b))
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+	if string(x) != string(y) {
+		t.Errorf("Expected the same JSON, got\n%s\n%s", x, y)
+	}
+
+	p, err := Normalize(a).MarshalHCL()
+	if err != nil {
+		t.Fatalf("Failed to marshal HCL: %v", err)
+	}
+	q, err := Normalize(b).MarshalHCL()
+	if err != nil {
+		t.Fatalf("Failed to marshal HCL: %v", err)
+	}
+	if string(p) != string(q) {
+		t.Errorf("Expected the same HCL, got\n%s\n%s", p, q)
+	}
+}
+
+func TestNormalizeMCP(t *testing.T) {
+	bs, err := os.ReadFile("samples/mcp.json")
+	if err != nil {
+		t.Fatalf("Failed to read mcp.json: %v", err)
+	}
+	mcp := new(Schema)
+	if err := json.Unmarshal(bs, mcp); err != nil {
+		t.Fatalf("Failed to unmarshal mcp.json: %v", err)
+	}
+	once := Normalize(mcp)
+	x, err := json.Marshal(once)
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+	y, err := json.Marshal(Normalize(once))
+	if err != nil {
+		t.Fatalf("Failed to marshal: %v", err)
+	}
+	if string(x) != string(y) {
+		t.Errorf("Expected Normalize to be idempotent")
+	}
+
+	// the normalized schema accepts and rejects the same instances
+	rnd := rand.New(rand.NewSource(1))
+	for _, name := range sortedKeys(mcp.Definitions) {
+		ref := "#/definitions/" + name
+		s := &Schema{Ref: &ref, Definitions: mcp.Definitions}
+		normalized := &Schema{Ref: &ref, Definitions: once.Definitions}
+		for _, invalid := range []bool{false, true} {
+			instance, err := Generate(s, rnd, &GenerateOptions{Invalid: invalid})
+			if err != nil {
+				t.Fatalf("Failed to generate %s: %v", name, err)
+			}
+			if err := normalized.Validate(instance); (err != nil) != invalid {
+				t.Errorf("Expected the normalized %s to give the same result for %v, got %v", name, instance, err)
+			}
+		}
+	}
+}
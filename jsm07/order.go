@@ -0,0 +1,166 @@
+package jsm07
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+)
+
+// orderedKeywords are the keywords holding maps whose key order is kept
+// in Schema.KeyOrder.
+var orderedKeywords = map[string]bool{
+	"properties":        true,
+	"patternProperties": true,
+	"dependencies":      true,
+	"definitions":       true,
+}
+
+// member is one key and value of a JSON object.
+type member struct {
+	key   string
+	value json.RawMessage
+}
+
+// objectMembers splits a JSON object into its members in document order.
+// ok is false if data is not an object.
+func objectMembers(data []byte) (members []member, ok bool, err error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, false, err
+	}
+	if delim, isDelim := tok.(json.Delim); !isDelim || delim != '{' {
+		return nil, false, nil
+	}
+
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, false, err
+		}
+		key, isString := tok.(string)
+		if !isString {
+			return nil, false, fmt.Errorf("invalid object key %v", tok)
+		}
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, false, err
+		}
+		members = append(members, member{key: key, value: value})
+	}
+	if _, err := dec.Token(); err != nil {
+		return nil, false, err
+	}
+	return members, true, nil
+}
+
+func writeObject(members []member) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, m := range members {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(m.key)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		if err := json.Compact(&buf, m.value); err != nil {
+			return nil, err
+		}
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// orderKeys sorts keys by their position in order; keys not in order
+// follow, sorted.
+func orderKeys(keys, order []string) []string {
+	pos := make(map[string]int, len(order))
+	for i, k := range order {
+		if _, ok := pos[k]; !ok {
+			pos[k] = i
+		}
+	}
+	sort.SliceStable(keys, func(i, j int) bool {
+		pi, iok := pos[keys[i]]
+		pj, jok := pos[keys[j]]
+		if iok && jok {
+			return pi < pj
+		}
+		if iok != jok {
+			return iok
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
+
+// OrderedKeys returns the keys of properties, patternProperties,
+// dependencies or definitions in the order of KeyOrder.
+func (self *Schema) OrderedKeys(keyword string) []string {
+	var keys []string
+	switch keyword {
+	case "properties":
+		keys = sortedKeys(self.Properties)
+	case "patternProperties":
+		keys = sortedKeys(self.PatternProperties)
+	case "dependencies":
+		keys = sortedKeys(self.Dependencies)
+	case "definitions":
+		keys = sortedKeys(self.Definitions)
+	default:
+		return nil
+	}
+	return orderKeys(keys, self.KeyOrder[keyword])
+}
+
+// setKeyOrder records the order of the keys of keyword, unless they are
+// already sorted.
+func (self *Schema) setKeyOrder(keyword string, keys []string) {
+	if sort.StringsAreSorted(keys) {
+		return
+	}
+	if self.KeyOrder == nil {
+		self.KeyOrder = make(map[string][]string)
+	}
+	self.KeyOrder[keyword] = keys
+}
+
+// reorderMembers puts the members of the ordered keywords in the order of
+// KeyOrder.
+func (self *Schema) reorderMembers(members []member) error {
+	for i, m := range members {
+		order, ok := self.KeyOrder[m.key]
+		if !ok || !orderedKeywords[m.key] {
+			continue
+		}
+		entries, isObject, err := objectMembers(m.value)
+		if err != nil {
+			return err
+		}
+		if !isObject {
+			continue
+		}
+
+		byKey := make(map[string]json.RawMessage, len(entries))
+		keys := make([]string, 0, len(entries))
+		for _, e := range entries {
+			byKey[e.key] = e.value
+			keys = append(keys, e.key)
+		}
+		sorted := make([]member, 0, len(entries))
+		for _, k := range orderKeys(keys, order) {
+			sorted = append(sorted, member{key: k, value: byKey[k]})
+		}
+		value, err := writeObject(sorted)
+		if err != nil {
+			return err
+		}
+		members[i].value = value
+	}
+	return nil
+}
@@ -0,0 +1,192 @@
+package jsm07
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+// memberKeys returns the keys of the object found at path in data.
+func memberKeys(t *testing.T, data []byte, path ...string) string {
+	t.Helper()
+	for _, key := range path {
+		var m map[string]json.RawMessage
+		if err := json.Unmarshal(data, &m); err != nil {
+			t.Fatalf("Failed to unmarshal %s: %v", data, err)
+		}
+		data = m[key]
+	}
+	members, _, err := objectMembers(data)
+	if err != nil {
+		t.Fatalf("Failed to read members of %s: %v", data, err)
+	}
+	var keys []string
+	for _, m := range members {
+		keys = append(keys, m.key)
+	}
+	return strings.Join(keys, ",")
+}
+
+func TestKeyOrderJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		path     []string
+		expected string
+	}{
+		{
+			name:     "properties",
+			input:    `{"type":"object","properties":{"name":{"type":"string"},"age":{"type":"integer"},"email":{"type":"string"}}}`,
+			path:     []string{"properties"},
+			expected: "name,age,email",
+		},
+		{
+			name:     "nested properties",
+			input:    `{"definitions":{"Z":{"properties":{"y":true,"x":false}},"A":{"type":"string"}}}`,
+			path:     []string{"definitions", "Z", "properties"},
+			expected: "y,x",
+		},
+		{
+			name:     "definitions",
+			input:    `{"definitions":{"Z":{"properties":{"y":true,"x":false}},"A":{"type":"string"}}}`,
+			path:     []string{"definitions"},
+			expected: "Z,A",
+		},
+		{
+			name:     "dependencies",
+			input:    `{"dependencies":{"b":["a"],"a":{"required":["b"]}}}`,
+			path:     []string{"dependencies"},
+			expected: "b,a",
+		},
+		{
+			name:     "patternProperties",
+			input:    `{"patternProperties":{"^z":{"type":"string"},"^a":{"type":"number"}}}`,
+			path:     []string{"patternProperties"},
+			expected: "^z,^a",
+		},
+		{
+			name:     "draft-04",
+			input:    `{"$schema":"http://json-schema.org/draft-04/schema#","properties":{"z":{"id":"#z","maximum":3,"exclusiveMaximum":true},"a":{"type":"string"}}}`,
+			path:     []string{"properties"},
+			expected: "z,a",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := new(Schema)
+			if err := json.Unmarshal([]byte(tt.input), s); err != nil {
+				t.Fatalf("Failed to unmarshal schema: %v", err)
+			}
+			bs, err := json.Marshal(s)
+			if err != nil {
+				t.Fatalf("Failed to marshal schema: %v", err)
+			}
+			if got := memberKeys(t, bs, tt.path...); got != tt.expected {
+				t.Errorf("Expected keys %s, got %s in %s", tt.expected, got, bs)
+			}
+		})
+	}
+}
+
+func TestKeyOrderHCL(t *testing.T) {
+	data := `
+type = "object"
+properties "name" {
+  type = "string"
+}
+properties "age" {
+  type = "integer"
+}
+properties "email" {
+  type = "string"
+}
+dependencies "email" {
+  required = ["name"]
+}
+dependencies "age" {
+  required = ["name"]
+}
+`
+	s, err := ParseSchema([]byte(data))
+	if err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+	if got := strings.Join(s.KeyOrder["properties"], ","); got != "name,age,email" {
+		t.Errorf("Expected properties order name,age,email, got %s", got)
+	}
+	if got := strings.Join(s.KeyOrder["dependencies"], ","); got != "email,age" {
+		t.Errorf("Expected dependencies order email,age, got %s", got)
+	}
+
+	bs, err := s.MarshalHCL()
+	if err != nil {
+		t.Fatalf("Failed to marshal schema: %v", err)
+	}
+	str := string(bs)
+	var last int
+	for _, block := range []string{`properties "name"`, `properties "age"`, `properties "email"`, `dependencies "email"`, `dependencies "age"`} {
+		i := strings.Index(str, block)
+		if i < last {
+			t.Fatalf("Expected %s after position %d in\n%s", block, last, str)
+		}
+		last = i
+	}
+
+	s2, err := ParseSchema(bs)
+	if err != nil {
+		t.Fatalf("Failed to parse marshaled schema: %v\n%s", err, str)
+	}
+	js, err := json.Marshal(s2)
+	if err != nil {
+		t.Fatalf("Failed to marshal schema to JSON: %v", err)
+	}
+	if got := memberKeys(t, js, "properties"); got != "name,age,email" {
+		t.Errorf("Expected properties name,age,email, got %s", got)
+	}
+	if got := memberKeys(t, js, "dependencies"); got != "email,age" {
+		t.Errorf("Expected dependencies email,age, got %s", got)
+	}
+}
+
+func TestDependenciesHCL(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{"array", `{"dependencies":{"card":["billing","name"]}}`},
+		{"schema", `{"dependencies":{"card":{"required":["billing"],"properties":{"billing":{"type":"string","minLength":3}},"minProperties":2}}}`},
+		{"single attribute", `{"dependencies":{"card":{"minProperties":2}}}`},
+		{"boolean", `{"dependencies":{"card":true,"cash":false}}`},
+		{"mixed", `{"dependencies":{"z":false,"card":["billing"],"a":{"not":{"required":["x"]}}}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := new(Schema)
+			if err := json.Unmarshal([]byte(tt.input), s); err != nil {
+				t.Fatalf("Failed to unmarshal schema: %v", err)
+			}
+			bs, err := s.MarshalHCL()
+			if err != nil {
+				t.Fatalf("Failed to marshal schema: %v", err)
+			}
+			s2, err := ParseSchema(bs)
+			if err != nil {
+				t.Fatalf("Failed to parse marshaled schema: %v\n%s", err, bs)
+			}
+			if !jsonSame(s, s2) {
+				js, _ := json.Marshal(s2)
+				t.Errorf("Expected %s, got %s from\n%s", tt.input, js, bs)
+			}
+			for k, dep := range s.Dependencies {
+				if dep.Combined != nil && dep.Combined.Boolean != nil {
+					got := s2.Dependencies[k].Combined
+					if got == nil || got.Boolean == nil || *got.Boolean != *dep.Combined.Boolean {
+						t.Errorf("Expected dependency %s to stay a boolean, got %#v", k, got)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,622 @@
+package jsm07
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PatchOperation is one operation of an RFC 6902 JSON Patch: add, remove,
+// replace, move, copy or test.
+type PatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path"`
+	From  string          `json:"from,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// Patch is an RFC 6902 JSON Patch document.
+type Patch []PatchOperation
+
+// ApplyPatch returns a copy of the schema with the JSON Patch applied to
+// its JSON form. The operations are applied in order, and none is if one
+// fails. Key order and extensions are kept, so that the result is
+// written in the same order as the schema, with added keys last.
+func ApplyPatch(schema *Schema, patch Patch) (*Schema, error) {
+	doc, err := schemaNode(schema)
+	if err != nil {
+		return nil, err
+	}
+	for i, op := range patch {
+		if doc, err = applyOperation(doc, op); err != nil {
+			return nil, fmt.Errorf("patch operation %d (%s %s): %w", i, op.Op, op.Path, err)
+		}
+	}
+	return nodeSchema(doc)
+}
+
+// ApplyMergePatch returns a copy of the schema with the RFC 7396 JSON
+// Merge Patch applied: members of the patch replace those of the schema,
+// objects are merged recursively, and null removes a member.
+func ApplyMergePatch(schema *Schema, patch []byte) (*Schema, error) {
+	doc, err := schemaNode(schema)
+	if err != nil {
+		return nil, err
+	}
+	p, err := decodeNode(patch)
+	if err != nil {
+		return nil, fmt.Errorf("invalid merge patch: %w", err)
+	}
+	return nodeSchema(mergeNode(doc, p))
+}
+
+// CreatePatch returns a JSON Patch that turns old into new. Members are
+// added, removed or replaced one by one, and arrays item by item where
+// they share a prefix.
+func CreatePatch(old, new *Schema) (Patch, error) {
+	a, err := schemaNode(old)
+	if err != nil {
+		return nil, err
+	}
+	b, err := schemaNode(new)
+	if err != nil {
+		return nil, err
+	}
+	patch := Patch{}
+	return patch, diffNodes("", a, b, &patch)
+}
+
+// CreateMergePatch returns a JSON Merge Patch that turns old into new.
+func CreateMergePatch(old, new *Schema) ([]byte, error) {
+	a, err := schemaNode(old)
+	if err != nil {
+		return nil, err
+	}
+	b, err := schemaNode(new)
+	if err != nil {
+		return nil, err
+	}
+	return encodeNode(mergeDiff(a, b))
+}
+
+// object is a decoded JSON object that keeps the order of its keys.
+// Decoded values are *object, []interface{}, json.Number, string, bool
+// or nil.
+type object struct {
+	keys   []string
+	values map[string]interface{}
+}
+
+func (self *object) set(key string, v interface{}) {
+	if _, ok := self.values[key]; !ok {
+		self.keys = append(self.keys, key)
+	}
+	self.values[key] = v
+}
+
+func (self *object) remove(key string) {
+	delete(self.values, key)
+	for i, k := range self.keys {
+		if k == key {
+			self.keys = append(self.keys[:i:i], self.keys[i+1:]...)
+			return
+		}
+	}
+}
+
+func decodeNode(data []byte) (interface{}, error) {
+	members, ok, err := objectMembers(data)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		obj := &object{values: make(map[string]interface{}, len(members))}
+		for _, m := range members {
+			v, err := decodeNode(m.value)
+			if err != nil {
+				return nil, err
+			}
+			obj.set(m.key, v)
+		}
+		return obj, nil
+	}
+
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '[' {
+		var raws []json.RawMessage
+		if err := json.Unmarshal(trimmed, &raws); err != nil {
+			return nil, err
+		}
+		arr := make([]interface{}, len(raws))
+		for i, raw := range raws {
+			if arr[i], err = decodeNode(raw); err != nil {
+				return nil, err
+			}
+		}
+		return arr, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func encodeNode(v interface{}) ([]byte, error) {
+	switch t := v.(type) {
+	case *object:
+		members := make([]member, len(t.keys))
+		for i, k := range t.keys {
+			bs, err := encodeNode(t.values[k])
+			if err != nil {
+				return nil, err
+			}
+			members[i] = member{key: k, value: bs}
+		}
+		return writeObject(members)
+	case []interface{}:
+		var buf bytes.Buffer
+		buf.WriteByte('[')
+		for i, item := range t {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			bs, err := encodeNode(item)
+			if err != nil {
+				return nil, err
+			}
+			buf.Write(bs)
+		}
+		buf.WriteByte(']')
+		return buf.Bytes(), nil
+	default:
+	}
+	return json.Marshal(v)
+}
+
+// schemaNode decodes the JSON form of s.
+func schemaNode(s *Schema) (interface{}, error) {
+	if s == nil {
+		return nil, fmt.Errorf("no schema")
+	}
+	bs, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	doc, err := decodeNode(bs)
+	if err != nil {
+		return nil, err
+	}
+	dropNullProperties(doc)
+	return doc, nil
+}
+
+// dropNullProperties removes the "properties": null that Schema writes
+// when it has no properties, so that patches can add to it. Only schemas
+// are changed, not values such as default or const.
+func dropNullProperties(v interface{}) {
+	t, ok := v.(*object)
+	if !ok {
+		return
+	}
+	if p, ok := t.values["properties"]; ok && p == nil {
+		t.remove("properties")
+	}
+	for _, k := range t.keys {
+		switch k {
+		case "additionalItems", "contains", "additionalProperties", "propertyNames", "if", "then", "else", "not":
+			dropNullProperties(t.values[k])
+		case "items", "allOf", "anyOf", "oneOf":
+			if arr, ok := t.values[k].([]interface{}); ok {
+				for _, item := range arr {
+					dropNullProperties(item)
+				}
+			} else {
+				dropNullProperties(t.values[k])
+			}
+		case "properties", "patternProperties", "dependencies", "definitions":
+			if m, ok := t.values[k].(*object); ok {
+				for _, name := range m.keys {
+					dropNullProperties(m.values[name])
+				}
+			}
+		default:
+		}
+	}
+}
+
+func nodeSchema(doc interface{}) (*Schema, error) {
+	if _, ok := doc.(*object); !ok {
+		return nil, fmt.Errorf("patched schema is not an object")
+	}
+	bs, err := encodeNode(doc)
+	if err != nil {
+		return nil, err
+	}
+	s := new(Schema)
+	if err := json.Unmarshal(bs, s); err != nil {
+		return nil, fmt.Errorf("patched schema: %w", err)
+	}
+	return s, nil
+}
+
+func applyOperation(doc interface{}, op PatchOperation) (interface{}, error) {
+	path, err := splitPointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+	value := func() (interface{}, error) {
+		if op.Value == nil {
+			return nil, fmt.Errorf("missing value")
+		}
+		return decodeNode(op.Value)
+	}
+
+	switch op.Op {
+	case "add":
+		v, err := value()
+		if err != nil {
+			return nil, err
+		}
+		return addNode(doc, path, v)
+	case "remove":
+		_, doc, err = removeNode(doc, path)
+		return doc, err
+	case "replace":
+		v, err := value()
+		if err != nil {
+			return nil, err
+		}
+		return replaceNode(doc, path, v)
+	case "move", "copy":
+		from, err := splitPointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		var v interface{}
+		if op.Op == "move" {
+			if strings.HasPrefix(op.Path, op.From+"/") {
+				return nil, fmt.Errorf("cannot move %s into itself", op.From)
+			}
+			v, doc, err = removeNode(doc, from)
+		} else {
+			v, err = getNode(doc, from)
+			if err == nil {
+				v, err = copyNode(v)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		return addNode(doc, path, v)
+	case "test":
+		v, err := value()
+		if err != nil {
+			return nil, err
+		}
+		got, err := getNode(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if !nodeEqual(got, v) {
+			return nil, fmt.Errorf("test failed")
+		}
+		return doc, nil
+	default:
+	}
+	return nil, fmt.Errorf("unknown operation %q", op.Op)
+}
+
+func getNode(doc interface{}, path []string) (interface{}, error) {
+	for i, token := range path {
+		switch t := doc.(type) {
+		case *object:
+			v, ok := t.values[token]
+			if !ok {
+				return nil, fmt.Errorf("no member at /%s", joinTokens(path[:i+1]))
+			}
+			doc = v
+		case []interface{}:
+			n, err := arrayIndex(token, len(t)-1)
+			if err != nil {
+				return nil, err
+			}
+			doc = t[n]
+		default:
+			return nil, fmt.Errorf("no object or array at /%s", joinTokens(path[:i]))
+		}
+	}
+	return doc, nil
+}
+
+// updateNode calls fn on the object or array holding the last token of
+// path, and returns doc with the array fn returns put in its place.
+func updateNode(doc interface{}, path []string, fn func(parent interface{}, token string) (interface{}, error)) (interface{}, error) {
+	if len(path) == 1 {
+		return fn(doc, path[0])
+	}
+	switch t := doc.(type) {
+	case *object:
+		child, ok := t.values[path[0]]
+		if !ok {
+			return nil, fmt.Errorf("no member %q", path[0])
+		}
+		child, err := updateNode(child, path[1:], fn)
+		if err != nil {
+			return nil, err
+		}
+		t.values[path[0]] = child
+		return t, nil
+	case []interface{}:
+		n, err := arrayIndex(path[0], len(t)-1)
+		if err != nil {
+			return nil, err
+		}
+		if t[n], err = updateNode(t[n], path[1:], fn); err != nil {
+			return nil, err
+		}
+		return t, nil
+	default:
+	}
+	return nil, fmt.Errorf("no object or array at %q", path[0])
+}
+
+func addNode(doc interface{}, path []string, v interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return v, nil
+	}
+	return updateNode(doc, path, func(parent interface{}, token string) (interface{}, error) {
+		switch t := parent.(type) {
+		case *object:
+			t.set(token, v)
+			return t, nil
+		case []interface{}:
+			n := len(t)
+			if token != "-" {
+				var err error
+				if n, err = arrayIndex(token, len(t)); err != nil {
+					return nil, err
+				}
+			}
+			out := append(t[:n:n], v)
+			return append(out, t[n:]...), nil
+		default:
+		}
+		return nil, fmt.Errorf("cannot add %q to a value that is not an object or array", token)
+	})
+}
+
+// replaceNode puts v in place of the existing value at path.
+func replaceNode(doc interface{}, path []string, v interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return v, nil
+	}
+	return updateNode(doc, path, func(parent interface{}, token string) (interface{}, error) {
+		switch t := parent.(type) {
+		case *object:
+			if _, ok := t.values[token]; !ok {
+				return nil, fmt.Errorf("no member %q", token)
+			}
+			t.set(token, v)
+			return t, nil
+		case []interface{}:
+			n, err := arrayIndex(token, len(t)-1)
+			if err != nil {
+				return nil, err
+			}
+			t[n] = v
+			return t, nil
+		default:
+		}
+		return nil, fmt.Errorf("no member %q", token)
+	})
+}
+
+func removeNode(doc interface{}, path []string) (interface{}, interface{}, error) {
+	if len(path) == 0 {
+		return nil, nil, fmt.Errorf("cannot remove the root")
+	}
+	var removed interface{}
+	doc, err := updateNode(doc, path, func(parent interface{}, token string) (interface{}, error) {
+		switch t := parent.(type) {
+		case *object:
+			v, ok := t.values[token]
+			if !ok {
+				return nil, fmt.Errorf("no member %q", token)
+			}
+			removed = v
+			t.remove(token)
+			return t, nil
+		case []interface{}:
+			n, err := arrayIndex(token, len(t)-1)
+			if err != nil {
+				return nil, err
+			}
+			removed = t[n]
+			return append(t[:n:n], t[n+1:]...), nil
+		default:
+		}
+		return nil, fmt.Errorf("no member %q", token)
+	})
+	return removed, doc, err
+}
+
+// arrayIndex parses an array index no greater than limit.
+func arrayIndex(token string, limit int) (int, error) {
+	n, err := strconv.Atoi(token)
+	if err != nil || n < 0 || strconv.Itoa(n) != token {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	if n > limit {
+		return 0, fmt.Errorf("array index %d out of range", n)
+	}
+	return n, nil
+}
+
+func joinTokens(tokens []string) string {
+	ptr := ""
+	for _, t := range tokens {
+		ptr = pointerAppend(ptr, t)
+	}
+	if ptr == "" {
+		return ""
+	}
+	return ptr[1:]
+}
+
+func copyNode(v interface{}) (interface{}, error) {
+	bs, err := encodeNode(v)
+	if err != nil {
+		return nil, err
+	}
+	return decodeNode(bs)
+}
+
+// nodeEqual compares decoded values, objects regardless of key order and
+// numbers by value.
+func nodeEqual(a, b interface{}) bool {
+	switch x := a.(type) {
+	case *object:
+		y, ok := b.(*object)
+		if !ok || len(x.values) != len(y.values) {
+			return false
+		}
+		for k, v := range x.values {
+			w, ok := y.values[k]
+			if !ok || !nodeEqual(v, w) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		y, ok := b.([]interface{})
+		if !ok || len(x) != len(y) {
+			return false
+		}
+		for i := range x {
+			if !nodeEqual(x[i], y[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+	}
+	return jsonEqual(a, b)
+}
+
+func mergeNode(target, patch interface{}) interface{} {
+	p, ok := patch.(*object)
+	if !ok {
+		return patch
+	}
+	t, ok := target.(*object)
+	if !ok {
+		t = &object{values: make(map[string]interface{})}
+	}
+	for _, k := range p.keys {
+		if v := p.values[k]; v == nil {
+			t.remove(k)
+		} else {
+			t.set(k, mergeNode(t.values[k], v))
+		}
+	}
+	return t
+}
+
+func mergeDiff(a, b interface{}) interface{} {
+	x, ok := a.(*object)
+	y, ok2 := b.(*object)
+	if !ok || !ok2 {
+		return b
+	}
+	out := &object{values: make(map[string]interface{})}
+	for _, k := range x.keys {
+		if _, ok := y.values[k]; !ok {
+			out.set(k, nil)
+		}
+	}
+	for _, k := range y.keys {
+		v, ok := x.values[k]
+		switch {
+		case !ok:
+			out.set(k, y.values[k])
+		case !nodeEqual(v, y.values[k]):
+			out.set(k, mergeDiff(v, y.values[k]))
+		default:
+		}
+	}
+	return out
+}
+
+func diffNodes(ptr string, a, b interface{}, patch *Patch) error {
+	if nodeEqual(a, b) {
+		return nil
+	}
+	add := func(op, ptr string, v interface{}) error {
+		var value json.RawMessage
+		if op != "remove" {
+			bs, err := encodeNode(v)
+			if err != nil {
+				return err
+			}
+			value = bs
+		}
+		*patch = append(*patch, PatchOperation{Op: op, Path: ptr, Value: value})
+		return nil
+	}
+
+	switch x := a.(type) {
+	case *object:
+		y, ok := b.(*object)
+		if !ok {
+			break
+		}
+		for _, k := range x.keys {
+			if _, ok := y.values[k]; !ok {
+				if err := add("remove", pointerAppend(ptr, k), nil); err != nil {
+					return err
+				}
+			}
+		}
+		for _, k := range y.keys {
+			var err error
+			if v, ok := x.values[k]; ok {
+				err = diffNodes(pointerAppend(ptr, k), v, y.values[k], patch)
+			} else {
+				err = add("add", pointerAppend(ptr, k), y.values[k])
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	case []interface{}:
+		y, ok := b.([]interface{})
+		if !ok {
+			break
+		}
+		n := min(len(x), len(y))
+		for i := 0; i < n; i++ {
+			if err := diffNodes(pointerAppend(ptr, strconv.Itoa(i)), x[i], y[i], patch); err != nil {
+				return err
+			}
+		}
+		for i := len(x) - 1; i >= n; i-- {
+			if err := add("remove", pointerAppend(ptr, strconv.Itoa(i)), nil); err != nil {
+				return err
+			}
+		}
+		for i := n; i < len(y); i++ {
+			if err := add("add", pointerAppend(ptr, "-"), y[i]); err != nil {
+				return err
+			}
+		}
+		return nil
+	default:
+	}
+	return add("replace", ptr, b)
+}
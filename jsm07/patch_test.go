@@ -0,0 +1,199 @@
+package jsm07
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"testing"
+)
+
+const patchBase = `{
+	"type": "object",
+	"required": ["b", "a"],
+	"properties": {
+		"b": {"type": "string", "maxLength": 10},
+		"a": {"type": "integer", "maximum": 5}
+	},
+	"x-go-type": "Base"
+}`
+
+func TestApplyPatch(t *testing.T) {
+	base := new(Schema)
+	if err := json.Unmarshal([]byte(patchBase), base); err != nil {
+		t.Fatalf("Failed to unmarshal schema: %v", err)
+	}
+
+	var patch Patch
+	if err := json.Unmarshal([]byte(`[
+		{"op": "test", "path": "/properties/a/maximum", "value": 5.0},
+		{"op": "replace", "path": "/properties/a/maximum", "value": 100},
+		{"op": "add", "path": "/properties/c", "value": {"enum": ["x", null]}},
+		{"op": "add", "path": "/definitions", "value": {}},
+		{"op": "copy", "from": "/properties/b", "path": "/definitions/name"},
+		{"op": "move", "from": "/properties/b/maxLength", "path": "/properties/b/minLength"},
+		{"op": "remove", "path": "/required/0"},
+		{"op": "add", "path": "/required/-", "value": "c"}
+	]`), &patch); err != nil {
+		t.Fatalf("Failed to unmarshal patch: %v", err)
+	}
+
+	s, err := ApplyPatch(base, patch)
+	if err != nil {
+		t.Fatalf("Failed to apply patch: %v", err)
+	}
+	want := new(Schema)
+	if err := json.Unmarshal([]byte(`{
+		"type": "object",
+		"required": ["a", "c"],
+		"properties": {
+			"b": {"type": "string", "minLength": 10},
+			"a": {"type": "integer", "maximum": 100},
+			"c": {"enum": ["x", null]}
+		},
+		"definitions": {"name": {"type": "string", "maxLength": 10}},
+		"x-go-type": "Base"
+	}`), want); err != nil {
+		t.Fatalf("Failed to unmarshal expected schema: %v", err)
+	}
+	if !jsonSame(s, want) {
+		got, _ := json.Marshal(s)
+		t.Errorf("Expected the patched schema, got %s", got)
+	}
+	if keys := s.OrderedKeys("properties"); !reflect.DeepEqual(keys, []string{"b", "a", "c"}) {
+		t.Errorf("Expected the key order to be kept, got %v", keys)
+	}
+	if m := s.Properties["a"].Schema.Maximum; m == nil || m.Integer == nil || *m.Integer != 100 {
+		t.Errorf("Expected an integer maximum, got %#v", m)
+	}
+	if string(s.Extensions["x-go-type"]) != `"Base"` {
+		t.Errorf("Expected the extension to be kept, got %v", s.Extensions)
+	}
+	if *base.Properties["a"].Schema.Maximum.Integer != 5 {
+		t.Errorf("Expected the base schema to be unchanged")
+	}
+
+	withDefault := new(Schema)
+	if err := json.Unmarshal([]byte(`{"properties": {"a": {"default": {"properties": null, "x": 1}}}}`), withDefault); err != nil {
+		t.Fatalf("Failed to unmarshal schema: %v", err)
+	}
+	titled, err := ApplyPatch(withDefault, Patch{{Op: "add", Path: "/title", Value: json.RawMessage(`"t"`)}})
+	if err != nil {
+		t.Fatalf("Failed to apply patch: %v", err)
+	}
+	if got := string(*titled.Properties["a"].Schema.Default); got != `{"properties":null,"x":1}` {
+		t.Errorf("Expected the default to be kept, got %s", got)
+	}
+
+	for _, bad := range []string{
+		`[{"op": "test", "path": "/type", "value": "array"}]`,
+		`[{"op": "remove", "path": "/properties/missing"}]`,
+		`[{"op": "replace", "path": "/required/5", "value": "x"}]`,
+		`[{"op": "add", "path": "/missing/x", "value": 1}]`,
+		`[{"op": "move", "from": "/properties", "path": "/properties/a/properties"}]`,
+		`[{"op": "add", "path": "/type", "value": 5}]`,
+		`[{"op": "replace", "path": "", "value": true}]`,
+		`[{"op": "merge", "path": "/type", "value": "string"}]`,
+	} {
+		var p Patch
+		if err := json.Unmarshal([]byte(bad), &p); err != nil {
+			t.Fatalf("Failed to unmarshal patch: %v", err)
+		}
+		if _, err := ApplyPatch(base, p); err == nil {
+			t.Errorf("Expected %s to fail", bad)
+		}
+	}
+}
+
+func TestApplyMergePatch(t *testing.T) {
+	base := new(Schema)
+	if err := json.Unmarshal([]byte(patchBase), base); err != nil {
+		t.Fatalf("Failed to unmarshal schema: %v", err)
+	}
+
+	s, err := ApplyMergePatch(base, []byte(`{"properties": {"a": {"maximum": null, "minimum": 1}, "c": {"type": "boolean"}}, "required": ["a"], "x-go-type": null}`))
+	if err != nil {
+		t.Fatalf("Failed to apply merge patch: %v", err)
+	}
+	want := new(Schema)
+	if err := json.Unmarshal([]byte(`{
+		"type": "object",
+		"required": ["a"],
+		"properties": {
+			"b": {"type": "string", "maxLength": 10},
+			"a": {"type": "integer", "minimum": 1},
+			"c": {"type": "boolean"}
+		}
+	}`), want); err != nil {
+		t.Fatalf("Failed to unmarshal expected schema: %v", err)
+	}
+	if !jsonSame(s, want) || s.Extensions != nil {
+		got, _ := json.Marshal(s)
+		t.Errorf("Expected the patched schema, got %s", got)
+	}
+	if keys := s.OrderedKeys("properties"); !reflect.DeepEqual(keys, []string{"b", "a", "c"}) {
+		t.Errorf("Expected the key order to be kept, got %v", keys)
+	}
+
+	if _, err := ApplyMergePatch(base, []byte(`{"type": 5}`)); err == nil {
+		t.Errorf("Expected an invalid schema to fail")
+	}
+}
+
+func TestCreatePatch(t *testing.T) {
+	bs, err := os.ReadFile("samples/mcp.json")
+	if err != nil {
+		t.Fatalf("Failed to read mcp.json: %v", err)
+	}
+	old := new(Schema)
+	if err := json.Unmarshal(bs, old); err != nil {
+		t.Fatalf("Failed to unmarshal mcp.json: %v", err)
+	}
+	updated := new(Schema)
+	if err := json.Unmarshal(bs, updated); err != nil {
+		t.Fatalf("Failed to unmarshal mcp.json: %v", err)
+	}
+	str := NewCombinedWithSchema(&Schema{Type: NewStringOrStringArrayWithString("string"), Common: Common{Enumeration: []SchemaEnumValue{{String: ptrTo("a")}}}})
+	for _, ptr := range []string{"/definitions/CallToolRequest/properties/params/properties/name", "/definitions/Extra", "/definitions/Role"} {
+		if err := updated.Set(ptr, str); err != nil {
+			t.Fatalf("Failed to set %s: %v", ptr, err)
+		}
+	}
+	if err := updated.Set("/definitions/Cursor", nil); err != nil {
+		t.Fatalf("Failed to remove Cursor: %v", err)
+	}
+
+	patch, err := CreatePatch(old, updated)
+	if err != nil {
+		t.Fatalf("Failed to create patch: %v", err)
+	}
+	if len(patch) == 0 {
+		t.Fatalf("Expected a patch")
+	}
+	patched, err := ApplyPatch(old, patch)
+	if err != nil {
+		t.Fatalf("Failed to apply created patch: %v", err)
+	}
+	if !jsonSame(patched, updated) {
+		t.Errorf("Expected the patch to turn old into new")
+	}
+
+	merge, err := CreateMergePatch(old, updated)
+	if err != nil {
+		t.Fatalf("Failed to create merge patch: %v", err)
+	}
+	patched, err = ApplyMergePatch(old, merge)
+	if err != nil {
+		t.Fatalf("Failed to apply created merge patch: %v", err)
+	}
+	if !jsonSame(patched, updated) {
+		t.Errorf("Expected the merge patch to turn old into new")
+	}
+
+	if patch, err := CreatePatch(old, old); err != nil || len(patch) != 0 {
+		t.Errorf("Expected an empty patch, got %v %v", patch, err)
+	}
+}
+
+func ptrTo[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,342 @@
+package jsm07
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// JSONPointer is an RFC 6901 JSON Pointer into a schema, such as
+// "/properties/name" or "/items/2". The empty pointer is the root.
+type JSONPointer string
+
+// Append returns the pointer extended by one reference token.
+func (self JSONPointer) Append(token string) JSONPointer {
+	return JSONPointer(pointerAppend(string(self), token))
+}
+
+// Tokens returns the unescaped reference tokens of the pointer.
+func (self JSONPointer) Tokens() ([]string, error) {
+	return splitPointer(string(self))
+}
+
+// pointerAppend appends one reference token to a JSON Pointer,
+// escaping "~" and "/" as RFC 6901 requires.
+func pointerAppend(path, token string) string {
+	token = strings.ReplaceAll(token, "~", "~0")
+	token = strings.ReplaceAll(token, "/", "~1")
+	return path + "/" + token
+}
+
+// splitPointer splits a JSON Pointer into its unescaped reference tokens.
+func splitPointer(ptr string) ([]string, error) {
+	if ptr == "" {
+		return nil, nil
+	}
+	if ptr[0] != '/' {
+		return nil, fmt.Errorf("invalid JSON pointer %q: must be empty or start with /", ptr)
+	}
+	tokens := strings.Split(ptr[1:], "/")
+	for i, token := range tokens {
+		token = strings.ReplaceAll(token, "~1", "/")
+		tokens[i] = strings.ReplaceAll(token, "~0", "~")
+	}
+	return tokens, nil
+}
+
+// lookupTokens walks reference tokens from c through the draft-07 keyword
+// layout, e.g. definitions/Role, properties/name, items/0 or allOf/1.
+func lookupTokens(c *Combined, tokens []string) (*Combined, error) {
+	for i := 0; i < len(tokens); i++ {
+		if c == nil || c.Schema == nil {
+			return nil, fmt.Errorf("no schema at /%s", strings.Join(tokens[:i], "/"))
+		}
+		s := c.Schema
+		keyword := tokens[i]
+
+		var next *Combined
+		var err error
+		switch keyword {
+		case "additionalItems":
+			next = s.AdditionalItems
+		case "contains":
+			next = s.Contains
+		case "additionalProperties":
+			next = s.AdditionalProperties
+		case "propertyNames":
+			next = s.PropertyNames
+		case "if":
+			next = s.If
+		case "then":
+			next = s.Then
+		case "else":
+			next = s.Else
+		case "not":
+			next = s.Not
+		case "items":
+			if s.Items == nil {
+				break
+			}
+			if s.Items.Combined != nil {
+				next = s.Items.Combined
+				break
+			}
+			if s.Items.CombinedArray == nil || i+1 >= len(tokens) {
+				break
+			}
+			i++
+			next, err = indexCombined(*s.Items.CombinedArray, tokens[i])
+		case "allOf", "anyOf", "oneOf":
+			if i+1 >= len(tokens) {
+				break
+			}
+			i++
+			arr := map[string][]*Combined{"allOf": s.AllOf, "anyOf": s.AnyOf, "oneOf": s.OneOf}[keyword]
+			next, err = indexCombined(arr, tokens[i])
+		case "definitions", "properties", "patternProperties":
+			if i+1 >= len(tokens) {
+				break
+			}
+			i++
+			m := map[string]map[string]*Combined{"definitions": s.Definitions, "properties": s.Properties, "patternProperties": s.PatternProperties}[keyword]
+			next = m[tokens[i]]
+		case "dependencies":
+			if i+1 >= len(tokens) {
+				break
+			}
+			i++
+			if dep := s.Dependencies[tokens[i]]; dep != nil {
+				next = dep.Combined
+			}
+		default:
+			return nil, fmt.Errorf("%q at /%s is not a subschema keyword", keyword, strings.Join(tokens[:i], "/"))
+		}
+		if err != nil {
+			return nil, err
+		}
+		if next == nil {
+			return nil, fmt.Errorf("no schema at /%s", strings.Join(tokens[:i+1], "/"))
+		}
+		c = next
+	}
+	return c, nil
+}
+
+func indexCombined(arr []*Combined, token string) (*Combined, error) {
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || strconv.Itoa(i) != token {
+		return nil, fmt.Errorf("invalid array index %q", token)
+	}
+	if i >= len(arr) {
+		return nil, fmt.Errorf("array index %d out of range", i)
+	}
+	return arr[i], nil
+}
+
+// Lookup returns the subschema at a JSON Pointer from self, following
+// the draft-07 keyword layout, e.g.
+// "/definitions/CallToolRequest/properties/params", "/items/2" or
+// "/allOf/0". The empty pointer is self.
+func (self *Schema) Lookup(pointer string) (*Combined, error) {
+	tokens, err := splitPointer(pointer)
+	if err != nil {
+		return nil, err
+	}
+	return lookupTokens(NewCombinedWithSchema(self), tokens)
+}
+
+// Set puts c at a JSON Pointer from self, replacing the subschema there.
+// The schema holding it must exist. Maps such as properties are created
+// as needed, and an index of allOf, anyOf, oneOf or an items array may
+// be its length, or "-", to append. A nil c removes the subschema. The
+// empty pointer replaces self itself, which c must then be a schema for.
+func (self *Schema) Set(pointer string, c *Combined) error {
+	tokens, err := splitPointer(pointer)
+	if err != nil {
+		return err
+	}
+	if len(tokens) == 0 {
+		if c == nil || c.Schema == nil {
+			return fmt.Errorf("the root must be a schema")
+		}
+		*self = *c.Schema
+		return nil
+	}
+
+	// find where the last keyword starts
+	last := 0
+	for i := 0; i < len(tokens); {
+		last = i
+		switch tokens[i] {
+		case "additionalItems", "contains", "additionalProperties", "propertyNames", "if", "then", "else", "not":
+			i++
+		case "items":
+			if i+1 < len(tokens) && isIndex(tokens[i+1]) {
+				i++
+			}
+			i++
+		case "allOf", "anyOf", "oneOf", "definitions", "properties", "patternProperties", "dependencies":
+			if i+1 >= len(tokens) {
+				return fmt.Errorf("no key after %q at /%s", tokens[i], strings.Join(tokens[:i], "/"))
+			}
+			i += 2
+		default:
+			return fmt.Errorf("%q at /%s is not a subschema keyword", tokens[i], strings.Join(tokens[:i], "/"))
+		}
+	}
+
+	parent, err := lookupTokens(NewCombinedWithSchema(self), tokens[:last])
+	if err != nil {
+		return err
+	}
+	if parent.Schema == nil {
+		return fmt.Errorf("no schema at /%s", strings.Join(tokens[:last], "/"))
+	}
+	s := parent.Schema
+	keyword, key := tokens[last], ""
+	if last+1 < len(tokens) {
+		key = tokens[last+1]
+	}
+
+	switch keyword {
+	case "additionalItems":
+		s.AdditionalItems = c
+	case "contains":
+		s.Contains = c
+	case "additionalProperties":
+		s.AdditionalProperties = c
+	case "propertyNames":
+		s.PropertyNames = c
+	case "if":
+		s.If = c
+	case "then":
+		s.Then = c
+	case "else":
+		s.Else = c
+	case "not":
+		s.Not = c
+	case "items":
+		switch {
+		case last+1 == len(tokens) && c == nil:
+			s.Items = nil
+		case last+1 == len(tokens):
+			s.Items = NewCombinedOrCombinedArrayWithCombined(c)
+		case s.Items != nil && s.Items.CombinedArray == nil:
+			return fmt.Errorf("items at /%s is not an array", strings.Join(tokens[:last], "/"))
+		default:
+			var tuple []*Combined
+			if s.Items != nil {
+				tuple = *s.Items.CombinedArray
+			}
+			if tuple, err = setIndex(tuple, key, c); err != nil {
+				return err
+			}
+			s.Items = NewCombinedOrCombinedArrayWithCombinedArray(tuple)
+		}
+	case "allOf", "anyOf", "oneOf":
+		arr := map[string]*[]*Combined{"allOf": &s.AllOf, "anyOf": &s.AnyOf, "oneOf": &s.OneOf}[keyword]
+		updated, err := setIndex(*arr, key, c)
+		if err != nil {
+			return err
+		}
+		*arr = updated
+	case "definitions":
+		s.Definitions = setKey(s, keyword, s.Definitions, key, c)
+	case "properties":
+		s.Properties = setKey(s, keyword, s.Properties, key, c)
+	case "patternProperties":
+		s.PatternProperties = setKey(s, keyword, s.PatternProperties, key, c)
+	case "dependencies":
+		if c == nil {
+			delete(s.Dependencies, key)
+			removeKeyOrder(s, keyword, key)
+			break
+		}
+		if s.Dependencies == nil {
+			s.Dependencies = make(map[string]*CombinedOrStringArray)
+		}
+		s.Dependencies[key] = NewCombinedOrStringArrayWithCombined(c)
+		appendKeyOrder(s, keyword, key)
+	default:
+	}
+	return nil
+}
+
+func isIndex(token string) bool {
+	if token == "-" {
+		return true
+	}
+	_, err := strconv.Atoi(token)
+	return err == nil
+}
+
+// setIndex replaces, appends or, for a nil c, removes the item of arr at
+// the index token.
+func setIndex(arr []*Combined, token string, c *Combined) ([]*Combined, error) {
+	i := len(arr)
+	if token != "-" {
+		var err error
+		i, err = strconv.Atoi(token)
+		if err != nil || i < 0 || strconv.Itoa(i) != token {
+			return nil, fmt.Errorf("invalid array index %q", token)
+		}
+	}
+	switch {
+	case i > len(arr) || (i == len(arr) && c == nil):
+		return nil, fmt.Errorf("array index %d out of range", i)
+	case c == nil:
+		return append(arr[:i:i], arr[i+1:]...), nil
+	case i == len(arr):
+		return append(arr, c), nil
+	default:
+	}
+	arr[i] = c
+	return arr, nil
+}
+
+// setKey sets or, for a nil c, deletes the key of m, a map of s under
+// keyword, keeping a recorded key order.
+func setKey(s *Schema, keyword string, m map[string]*Combined, key string, c *Combined) map[string]*Combined {
+	if c == nil {
+		delete(m, key)
+		removeKeyOrder(s, keyword, key)
+		return m
+	}
+	if m == nil {
+		m = make(map[string]*Combined)
+	}
+	m[key] = c
+	appendKeyOrder(s, keyword, key)
+	return m
+}
+
+// appendKeyOrder records a new key as the last under keyword, if the
+// order of its keys has been recorded.
+func appendKeyOrder(s *Schema, keyword, key string) {
+	order, ok := s.KeyOrder[keyword]
+	if !ok {
+		return
+	}
+	for _, k := range order {
+		if k == key {
+			return
+		}
+	}
+	s.KeyOrder[keyword] = append(order, key)
+}
+
+// removeKeyOrder drops a deleted key from the recorded order under
+// keyword, so that the key comes last if it is set again.
+func removeKeyOrder(s *Schema, keyword, key string) {
+	order, ok := s.KeyOrder[keyword]
+	if !ok {
+		return
+	}
+	kept := make([]string, 0, len(order))
+	for _, k := range order {
+		if k != key {
+			kept = append(kept, k)
+		}
+	}
+	s.KeyOrder[keyword] = kept
+}
@@ -0,0 +1,139 @@
+package jsm07
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSchemaLookup(t *testing.T) {
+	bs, err := os.ReadFile("samples/mcp.json")
+	if err != nil {
+		t.Fatalf("Failed to read mcp.json: %v", err)
+	}
+	mcp := new(Schema)
+	if err := json.Unmarshal(bs, mcp); err != nil {
+		t.Fatalf("Failed to unmarshal mcp.json: %v", err)
+	}
+
+	c, err := mcp.Lookup("/definitions/CallToolRequest/properties/params/properties/name")
+	if err != nil {
+		t.Fatalf("Failed to look up name: %v", err)
+	}
+	if c.Schema == nil || c.Schema.Type == nil || *c.Schema.Type.String != "string" {
+		t.Errorf("Expected a string schema, got %#v", c)
+	}
+	if c, err := mcp.Lookup(""); err != nil || c.Schema != mcp {
+		t.Errorf("Expected the empty pointer to be the root, got %v", err)
+	}
+
+	s := new(Schema)
+	if err := json.Unmarshal([]byte(`{"definitions": {"a/b~c": {"items": [{"type": "integer"}, {"allOf": [true, {"type": "null"}]}]}}}`), s); err != nil {
+		t.Fatalf("Failed to unmarshal schema: %v", err)
+	}
+	c, err = s.Lookup("/definitions/a~1b~0c/items/1/allOf/1")
+	if err != nil {
+		t.Fatalf("Failed to look up: %v", err)
+	}
+	if c.Schema == nil || *c.Schema.Type.String != "null" {
+		t.Errorf("Expected a null schema, got %#v", c)
+	}
+
+	for _, ptr := range []string{"definitions", "/definitions/missing", "/definitions/a~1b~0c/items/2", "/title", "/allOf/x"} {
+		if _, err := s.Lookup(ptr); err == nil {
+			t.Errorf("Expected %q to fail", ptr)
+		}
+	}
+}
+
+func TestSchemaSet(t *testing.T) {
+	s := new(Schema)
+	if err := json.Unmarshal([]byte(`{"properties": {"b": {"type": "string"}, "a": {"type": "integer"}}, "allOf": [{"minProperties": 1}]}`), s); err != nil {
+		t.Fatalf("Failed to unmarshal schema: %v", err)
+	}
+	str := &Schema{Type: NewStringOrStringArrayWithString("string")}
+
+	tests := []struct {
+		ptr string
+		c   *Combined
+	}{
+		{"/properties/c~1d", NewCombinedWithSchema(str)},
+		{"/properties/a/items/0", NewCombinedWithBoolean(true)},
+		{"/properties/a/items/-", NewCombinedWithSchema(str)},
+		{"/allOf/1", NewCombinedWithBoolean(true)},
+		{"/allOf/0", nil},
+		{"/definitions/x", NewCombinedWithBoolean(false)},
+		{"/definitions/x", NewCombinedWithSchema(str)},
+		{"/dependencies/b", NewCombinedWithSchema(&Schema{SchemaObject: SchemaObject{Required: []string{"a"}}})},
+		{"/properties/b/not", NewCombinedWithSchema(&Schema{})},
+		{"/properties/b", nil},
+	}
+	for _, tt := range tests {
+		if err := s.Set(tt.ptr, tt.c); err != nil {
+			t.Fatalf("Failed to set %s: %v", tt.ptr, err)
+		}
+	}
+
+	want := new(Schema)
+	if err := json.Unmarshal([]byte(`{
+		"properties": {"a": {"type": "integer", "items": [true, {"type": "string"}]}, "c/d": {"type": "string"}},
+		"dependencies": {"b": {"required": ["a"]}},
+		"definitions": {"x": {"type": "string"}},
+		"allOf": [true]
+	}`), want); err != nil {
+		t.Fatalf("Failed to unmarshal expected schema: %v", err)
+	}
+	if !jsonSame(s, want) {
+		got, _ := json.Marshal(s)
+		t.Errorf("Expected the schema set, got %s", got)
+	}
+	if keys := s.OrderedKeys("properties"); len(keys) != 2 || keys[0] != "a" || keys[1] != "c/d" {
+		t.Errorf("Expected the new property last, got %v", keys)
+	}
+
+	before, _ := json.Marshal(s)
+	for _, ptr := range []string{"/properties/missing/not", "/allOf/3", "/title", "/properties", "/items/0/not"} {
+		if err := s.Set(ptr, NewCombinedWithBoolean(true)); err == nil {
+			t.Errorf("Expected %q to fail", ptr)
+		}
+	}
+	if err := s.Set("/allOf/5", nil); err == nil {
+		t.Errorf("Expected removing /allOf/5 to fail")
+	}
+	if after, _ := json.Marshal(s); string(after) != string(before) {
+		t.Errorf("Expected a failed Set to leave the schema unchanged, got %s", after)
+	}
+	if err := s.Set("", NewCombinedWithBoolean(true)); err == nil {
+		t.Errorf("Expected a boolean root to fail")
+	}
+	if err := s.Set("", NewCombinedWithSchema(str)); err != nil || s.Properties != nil {
+		t.Errorf("Expected the root to be replaced, got %v", err)
+	}
+}
+
+func TestSchemaSetKeyOrder(t *testing.T) {
+	s := new(Schema)
+	if err := json.Unmarshal([]byte(`{
+		"properties": {"b": true, "a": true, "c": true},
+		"dependencies": {"b": ["a"], "a": ["c"], "c": ["b"]}
+	}`), s); err != nil {
+		t.Fatalf("Failed to unmarshal schema: %v", err)
+	}
+
+	for _, keyword := range []string{"properties", "dependencies"} {
+		ptr := "/" + keyword + "/a"
+		if err := s.Set(ptr, nil); err != nil {
+			t.Fatalf("Failed to remove %s: %v", ptr, err)
+		}
+		if order := strings.Join(s.KeyOrder[keyword], ","); order != "b,c" {
+			t.Errorf("Expected %s removed from the key order, got %s", ptr, order)
+		}
+		if err := s.Set(ptr, NewCombinedWithBoolean(true)); err != nil {
+			t.Fatalf("Failed to set %s: %v", ptr, err)
+		}
+		if keys := strings.Join(s.OrderedKeys(keyword), ","); keys != "b,c,a" {
+			t.Errorf("Expected %s set again last, got %s", ptr, keys)
+		}
+	}
+}
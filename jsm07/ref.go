@@ -0,0 +1,339 @@
+package jsm07
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// RefCycleError reports a chain of references that leads back to itself
+// and therefore cannot be inlined.
+type RefCycleError struct {
+	Refs []string
+}
+
+func (self *RefCycleError) Error() string {
+	return fmt.Sprintf("circular $ref: %s", strings.Join(self.Refs, " -> "))
+}
+
+// Resolve returns the subschema that a local reference points to, taking
+// self as the document root. The reference is either a JSON Pointer
+// fragment such as "#/definitions/Role", or a plain-name fragment such
+// as "#role" matching a subschema whose $id is "#role".
+func (self *Schema) Resolve(ref string) (*Combined, error) {
+	if !strings.HasPrefix(ref, "#") {
+		return nil, fmt.Errorf("%q is not a local reference", ref)
+	}
+	fragment, err := url.PathUnescape(ref[1:])
+	if err != nil {
+		return nil, fmt.Errorf("invalid reference %q: %w", ref, err)
+	}
+
+	root := NewCombinedWithSchema(self)
+	if fragment == "" || fragment[0] == '/' {
+		tokens, err := splitPointer(fragment)
+		if err != nil {
+			return nil, err
+		}
+		target, err := lookupTokens(root, tokens)
+		if err != nil {
+			return nil, fmt.Errorf("unresolvable reference %q: %w", ref, err)
+		}
+		return target, nil
+	}
+
+	if target := findAnchor(root, "#"+fragment); target != nil {
+		return target, nil
+	}
+	return nil, fmt.Errorf("unresolvable reference %q: no schema has $id %q", ref, "#"+fragment)
+}
+
+// findAnchor searches c and its subschemas for the one whose $id is id.
+func findAnchor(c *Combined, id string) *Combined {
+	if c == nil || c.Schema == nil {
+		return nil
+	}
+	if c.Schema.ID != nil && *c.Schema.ID == id {
+		return c
+	}
+	var found *Combined
+	forEachChild(c.Schema, func(_ []string, child *Combined) bool {
+		found = findAnchor(child, id)
+		return found == nil
+	})
+	return found
+}
+
+// Dereference returns a copy of the schema in which every local $ref is
+// replaced by a copy of its target. As draft-07 specifies, keywords next
+// to $ref are ignored. A reference reachable from the root that leads back
+// to itself, as in a recursive definition, cannot be inlined and is
+// reported as a *RefCycleError. A definition whose references form a cycle
+// but that is not reachable from the root keeps its $ref as is.
+//
+// The copy shares scalar values such as strings and numbers with self.
+func (self *Schema) Dereference() (*Schema, error) {
+	d := &dereferencer{root: self}
+	c, err := d.deref(NewCombinedWithSchema(self), nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.Schema, nil
+}
+
+type dereferencer struct {
+	root *Schema
+}
+
+func (self *dereferencer) deref(c *Combined, stack []string) (*Combined, error) {
+	if c == nil {
+		return nil, nil
+	}
+	if c.Schema == nil {
+		if c.Boolean == nil {
+			return &Combined{}, nil
+		}
+		return NewCombinedWithBoolean(*c.Boolean), nil
+	}
+
+	if c.Schema.Ref != nil {
+		ref := *c.Schema.Ref
+		for i, r := range stack {
+			if r == ref {
+				refs := append(append([]string{}, stack[i:]...), ref)
+				return nil, &RefCycleError{Refs: refs}
+			}
+		}
+		target, err := self.root.Resolve(ref)
+		if err != nil {
+			return nil, err
+		}
+		return self.deref(target, append(stack[:len(stack):len(stack)], ref))
+	}
+
+	// definitions are not part of the schema itself: they are inlined
+	// where referenced, so a cycle within them is only an error there
+	body := *c.Schema
+	body.Definitions = nil
+	s, err := mapChildren(&body, func(child *Combined) (*Combined, error) {
+		return self.deref(child, stack)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if c.Schema.Definitions != nil {
+		s.Definitions = make(map[string]*Combined, len(c.Schema.Definitions))
+		for k, def := range c.Schema.Definitions {
+			x, err := self.deref(def, stack)
+			var cycle *RefCycleError
+			if errors.As(err, &cycle) {
+				x, err = copyCombined(def)
+			}
+			if err != nil {
+				return nil, err
+			}
+			s.Definitions[k] = x
+		}
+	}
+	return NewCombinedWithSchema(s), nil
+}
+
+// copyCombined returns a copy of c that can be changed without affecting c.
+func copyCombined(c *Combined) (*Combined, error) {
+	if c == nil {
+		return nil, nil
+	}
+	if c.Schema == nil {
+		if c.Boolean == nil {
+			return &Combined{}, nil
+		}
+		return NewCombinedWithBoolean(*c.Boolean), nil
+	}
+	s, err := mapChildren(c.Schema, copyCombined)
+	if err != nil {
+		return nil, err
+	}
+	return NewCombinedWithSchema(s), nil
+}
+
+// forEachChild calls fn on every direct subschema of s, with the reference
+// tokens that lead to it from s, in document order: properties,
+// patternProperties, additionalProperties, propertyNames, dependencies,
+// items, additionalItems, contains, if, then, else, allOf, anyOf, oneOf,
+// not and definitions. Map keys follow OrderedKeys. It stops early if fn
+// returns false.
+func forEachChild(s *Schema, fn func(tokens []string, c *Combined) bool) bool {
+	if s == nil {
+		return true
+	}
+
+	visit := func(c *Combined, tokens ...string) bool {
+		if c == nil {
+			return true
+		}
+		return fn(tokens, c)
+	}
+	visitMap := func(keyword string, m map[string]*Combined) bool {
+		for _, k := range s.OrderedKeys(keyword) {
+			if !visit(m[k], keyword, k) {
+				return false
+			}
+		}
+		return true
+	}
+	visitArray := func(keyword string, arr []*Combined) bool {
+		for i, c := range arr {
+			if !visit(c, keyword, fmt.Sprint(i)) {
+				return false
+			}
+		}
+		return true
+	}
+
+	if !visitMap("properties", s.Properties) ||
+		!visitMap("patternProperties", s.PatternProperties) ||
+		!visit(s.AdditionalProperties, "additionalProperties") ||
+		!visit(s.PropertyNames, "propertyNames") {
+		return false
+	}
+	for _, k := range s.OrderedKeys("dependencies") {
+		if dep := s.Dependencies[k]; dep != nil && !visit(dep.Combined, "dependencies", k) {
+			return false
+		}
+	}
+	if s.Items != nil {
+		if s.Items.Combined != nil && !visit(s.Items.Combined, "items") {
+			return false
+		}
+		if s.Items.CombinedArray != nil && !visitArray("items", *s.Items.CombinedArray) {
+			return false
+		}
+	}
+	return visit(s.AdditionalItems, "additionalItems") &&
+		visit(s.Contains, "contains") &&
+		visit(s.If, "if") &&
+		visit(s.Then, "then") &&
+		visit(s.Else, "else") &&
+		visitArray("allOf", s.AllOf) &&
+		visitArray("anyOf", s.AnyOf) &&
+		visitArray("oneOf", s.OneOf) &&
+		visit(s.Not, "not") &&
+		visitMap("definitions", s.Definitions)
+}
+
+// mapChildren returns a shallow copy of s whose direct subschemas are
+// replaced by the results of fn. Slices and maps are copied, so that the
+// copy can be changed without affecting s.
+func mapChildren(s *Schema, fn func(c *Combined) (*Combined, error)) (*Schema, error) {
+	if s == nil {
+		return nil, nil
+	}
+
+	var err error
+	one := func(c *Combined) *Combined {
+		if c == nil || err != nil {
+			return c
+		}
+		var x *Combined
+		x, err = fn(c)
+		return x
+	}
+	many := func(arr []*Combined) []*Combined {
+		if arr == nil {
+			return nil
+		}
+		out := make([]*Combined, len(arr))
+		for i, c := range arr {
+			out[i] = one(c)
+		}
+		return out
+	}
+	keyed := func(m map[string]*Combined) map[string]*Combined {
+		if m == nil {
+			return nil
+		}
+		out := make(map[string]*Combined, len(m))
+		for _, k := range sortedKeys(m) {
+			out[k] = one(m[k])
+		}
+		return out
+	}
+
+	copied := *s
+	if s.Required != nil {
+		copied.Required = append([]string{}, s.Required...)
+	}
+	if s.Enumeration != nil {
+		copied.Enumeration = append([]SchemaEnumValue{}, s.Enumeration...)
+	}
+	if s.Extensions != nil {
+		copied.Extensions = make(map[string]json.RawMessage, len(s.Extensions))
+		for k, v := range s.Extensions {
+			copied.Extensions[k] = v
+		}
+	}
+
+	if s.KeyOrder != nil {
+		copied.KeyOrder = make(map[string][]string, len(s.KeyOrder))
+		for k, v := range s.KeyOrder {
+			copied.KeyOrder[k] = append([]string{}, v...)
+		}
+	}
+
+	copied.Definitions = keyed(s.Definitions)
+	copied.Properties = keyed(s.Properties)
+	copied.PatternProperties = keyed(s.PatternProperties)
+	copied.AdditionalProperties = one(s.AdditionalProperties)
+	copied.PropertyNames = one(s.PropertyNames)
+	if s.Dependencies != nil {
+		copied.Dependencies = make(map[string]*CombinedOrStringArray, len(s.Dependencies))
+		for k, dep := range s.Dependencies {
+			switch {
+			case dep == nil:
+				copied.Dependencies[k] = nil
+			case dep.Combined != nil:
+				copied.Dependencies[k] = NewCombinedOrStringArrayWithCombined(one(dep.Combined))
+			case dep.StringArray != nil:
+				copied.Dependencies[k] = NewCombinedOrStringArrayWithStringArray(append([]string{}, *dep.StringArray...))
+			default:
+				copied.Dependencies[k] = &CombinedOrStringArray{}
+			}
+		}
+	}
+	if s.Items != nil {
+		switch {
+		case s.Items.Combined != nil:
+			copied.Items = NewCombinedOrCombinedArrayWithCombined(one(s.Items.Combined))
+		case s.Items.CombinedArray != nil:
+			copied.Items = NewCombinedOrCombinedArrayWithCombinedArray(many(*s.Items.CombinedArray))
+		default:
+			copied.Items = &CombinedOrCombinedArray{}
+		}
+	}
+	copied.AdditionalItems = one(s.AdditionalItems)
+	copied.Contains = one(s.Contains)
+	copied.If = one(s.If)
+	copied.Then = one(s.Then)
+	copied.Else = one(s.Else)
+	copied.AllOf = many(s.AllOf)
+	copied.AnyOf = many(s.AnyOf)
+	copied.OneOf = many(s.OneOf)
+	copied.Not = one(s.Not)
+
+	if err != nil {
+		return nil, err
+	}
+	return &copied, nil
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,141 @@
+package jsm07
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSchemaResolve(t *testing.T) {
+	s := new(Schema)
+	if err := json.Unmarshal([]byte(`{
+		"definitions": {
+			"a/b": {"type": "string"},
+			"tuple": {"items": [{"type": "integer"}, {"$id": "#second", "type": "boolean"}]}
+		}
+	}`), s); err != nil {
+		t.Fatalf("Failed to unmarshal schema: %v", err)
+	}
+
+	tests := []struct {
+		ref string
+		typ string
+	}{
+		{"#/definitions/a~1b", "string"},
+		{"#/definitions/tuple/items/0", "integer"},
+		{"#second", "boolean"},
+		{"#/definitions/a%7E1b", "string"},
+	}
+	for _, tt := range tests {
+		c, err := s.Resolve(tt.ref)
+		if err != nil {
+			t.Fatalf("Failed to resolve %s: %v", tt.ref, err)
+		}
+		if c.Schema == nil || *c.Schema.Type.String != tt.typ {
+			t.Errorf("Expected %s to resolve to type %s, got %#v", tt.ref, tt.typ, c)
+		}
+	}
+
+	for _, ref := range []string{"#/definitions/missing", "#/definitions/tuple/items/2", "#/title", "other.json#/definitions/a"} {
+		if _, err := s.Resolve(ref); err == nil {
+			t.Errorf("Expected %s to fail", ref)
+		}
+	}
+}
+
+func TestMCPDereference(t *testing.T) {
+	bs, err := os.ReadFile("samples/mcp.json")
+	if err != nil {
+		t.Fatalf("Failed to read mcp.json: %v", err)
+	}
+	mcp := new(Schema)
+	if err := json.Unmarshal(bs, mcp); err != nil {
+		t.Fatalf("Failed to unmarshal mcp.json: %v", err)
+	}
+
+	d, err := mcp.Dereference()
+	if err != nil {
+		t.Fatalf("Failed to dereference mcp: %v", err)
+	}
+	bs1, err := json.Marshal(d)
+	if err != nil {
+		t.Fatalf("Failed to marshal dereferenced mcp: %v", err)
+	}
+	if strings.Contains(string(bs1), `"$ref"`) {
+		t.Error("Expected no $ref in dereferenced schema")
+	}
+
+	// the original is left untouched
+	bs2, err := json.Marshal(mcp)
+	if err != nil {
+		t.Fatalf("Failed to marshal mcp: %v", err)
+	}
+	if !strings.Contains(string(bs2), `"$ref"`) {
+		t.Error("Expected $ref in original schema")
+	}
+}
+
+func TestDereferenceCycle(t *testing.T) {
+	s := new(Schema)
+	if err := json.Unmarshal([]byte(`{
+		"definitions": {
+			"node": {"type": "object", "properties": {"children": {"type": "array", "items": {"$ref": "#/definitions/node"}}}}
+		},
+		"$ref": "#/definitions/node"
+	}`), s); err != nil {
+		t.Fatalf("Failed to unmarshal schema: %v", err)
+	}
+
+	_, err := s.Dereference()
+	var cycle *RefCycleError
+	if !errors.As(err, &cycle) {
+		t.Fatalf("Expected RefCycleError, got %v", err)
+	}
+	if len(cycle.Refs) != 2 || cycle.Refs[0] != "#/definitions/node" {
+		t.Errorf("Unexpected cycle %v", cycle.Refs)
+	}
+
+	// recursive schemas still validate
+	var instance interface{}
+	if err := json.Unmarshal([]byte(`{"children": [{"children": []}, {"children": [1]}]}`), &instance); err != nil {
+		t.Fatalf("Failed to unmarshal instance: %v", err)
+	}
+	var errs ValidationErrors
+	if !errors.As(s.Validate(instance), &errs) || len(errs) != 1 || errs[0].InstancePath != "/children/1/children/0" {
+		t.Errorf("Expected one error at /children/1/children/0, got %v", errs)
+	}
+}
+
+func TestDereferenceUnreachableCycle(t *testing.T) {
+	s := new(Schema)
+	if err := json.Unmarshal([]byte(`{
+		"definitions": {
+			"node": {"type": "object", "properties": {"children": {"type": "array", "items": {"$ref": "#/definitions/node"}}}},
+			"name": {"type": "string"}
+		},
+		"properties": {"name": {"$ref": "#/definitions/name"}}
+	}`), s); err != nil {
+		t.Fatalf("Failed to unmarshal schema: %v", err)
+	}
+
+	d, err := s.Dereference()
+	if err != nil {
+		t.Fatalf("Failed to dereference schema: %v", err)
+	}
+	bs, err := json.Marshal(d)
+	if err != nil {
+		t.Fatalf("Failed to marshal schema: %v", err)
+	}
+	expected := `{"properties":{"name":{"type":"string","properties":null}},"definitions":{"node":{"type":"object","properties":{"children":{"type":"array","items":{"$ref":"#/definitions/node","properties":null},"properties":null}}},"name":{"type":"string","properties":null}}}`
+	if string(bs) != expected {
+		t.Errorf("Expected %s, got %s", expected, string(bs))
+	}
+
+	// the kept definition is a copy
+	d.Definitions["node"].Schema.Properties["children"].Schema.Type = nil
+	if s.Definitions["node"].Schema.Properties["children"].Schema.Type == nil {
+		t.Error("Expected the original schema to be left untouched")
+	}
+}
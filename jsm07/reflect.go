@@ -0,0 +1,553 @@
+package jsm07
+
+import (
+	"encoding"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TypeOptions controls how FromType builds a schema.
+type TypeOptions struct {
+	// ID is the $id of the schema.
+	ID string
+	// NoAdditionalProperties sets additionalProperties to false on the
+	// schemas of structs.
+	NoAdditionalProperties bool
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	rawMessageType    = reflect.TypeOf(json.RawMessage{})
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// FromType builds the schema of the values of t as encoding/json writes
+// them. The properties of a struct are named by their json tags, or by
+// their hcl tags if they have none. A field is required if its hcl tag
+// declares an attribute that is not optional, or if its jsonschema tag
+// says so.
+//
+// The jsonschema tag holds comma-separated keywords, a comma in a value
+// being escaped as \, as in
+//
+//	Port int `json:"port" jsonschema:"required,minimum=1,maximum=65535,description=TCP port"`
+//
+// The keywords are required, title, description, format, pattern,
+// minLength, maxLength, minimum, maximum, exclusiveMinimum,
+// exclusiveMaximum, multipleOf, minItems, maxItems, uniqueItems, readOnly,
+// writeOnly, default and enum, which may be repeated. On a slice, those
+// constraining strings and numbers apply to its elements.
+//
+// Named struct types other than t are put into Definitions and referred
+// to with $ref. Types implementing json.Marshaler, except time.Time, are
+// not described and accept any value.
+func FromType(t reflect.Type, opts *TypeOptions) (*Schema, error) {
+	if opts == nil {
+		opts = &TypeOptions{}
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	r := &reflector{
+		opts:  opts,
+		root:  t,
+		names: make(map[reflect.Type]string),
+		taken: make(map[string]bool),
+		defs:  make(map[string]*Combined),
+	}
+	c, err := r.typeSchema(t)
+	if err != nil {
+		return nil, err
+	}
+	schema := c.Schema
+	if schema == nil {
+		schema = &Schema{}
+	}
+
+	draft := Draft07
+	schema.Schema = &draft
+	if opts.ID != "" {
+		schema.ID = &opts.ID
+	}
+	if len(r.defs) > 0 {
+		schema.Definitions = r.defs
+		schema.setKeyOrder("definitions", r.order)
+	}
+	return schema, nil
+}
+
+type reflector struct {
+	opts  *TypeOptions
+	root  reflect.Type
+	names map[reflect.Type]string // definition names of the struct types
+	taken map[string]bool
+	defs  map[string]*Combined
+	order []string
+}
+
+func (self *reflector) typeSchema(t reflect.Type) (*Combined, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return NewCombinedWithSchema(&Schema{Type: NewStringOrStringArrayWithString("string"), Common: Common{Format: newString("date-time")}}), nil
+	case t == rawMessageType:
+		return NewCombinedWithBoolean(true), nil
+	case t.Implements(jsonMarshalerType) || reflect.PointerTo(t).Implements(jsonMarshalerType):
+		return NewCombinedWithBoolean(true), nil
+	case t.Implements(textMarshalerType) || reflect.PointerTo(t).Implements(textMarshalerType):
+		return newTyped("string"), nil
+	default:
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return newTyped("boolean"), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return newTyped("integer"), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		c := newTyped("integer")
+		c.Schema.Minimum = NewIntegerOrFloatWithInteger(0)
+		return c, nil
+	case reflect.Float32, reflect.Float64:
+		return newTyped("number"), nil
+	case reflect.String:
+		return newTyped("string"), nil
+	case reflect.Interface:
+		return NewCombinedWithBoolean(true), nil
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 && t.Kind() == reflect.Slice {
+			c := newTyped("string")
+			c.Schema.ContentEncoding = newString("base64")
+			return c, nil
+		}
+		items, err := self.typeSchema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		c := newTyped("array")
+		c.Schema.Items = NewCombinedOrCombinedArrayWithCombined(items)
+		if t.Kind() == reflect.Array {
+			n := int64(t.Len())
+			c.Schema.MinItems, c.Schema.MaxItems = &n, &n
+		}
+		return c, nil
+	case reflect.Map:
+		switch t.Key().Kind() {
+		case reflect.String, reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		default:
+			if !t.Key().Implements(textMarshalerType) {
+				return nil, fmt.Errorf("unsupported map key type %s", t.Key())
+			}
+		}
+		value, err := self.typeSchema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		c := newTyped("object")
+		c.Schema.AdditionalProperties = value
+		return c, nil
+	case reflect.Struct:
+		return self.structRef(t)
+	default:
+	}
+	return nil, fmt.Errorf("unsupported type %s", t)
+}
+
+// structRef returns the schema of an anonymous struct, or a reference to
+// the definition of a named one.
+func (self *reflector) structRef(t reflect.Type) (*Combined, error) {
+	if t == self.root {
+		if _, ok := self.names[t]; ok {
+			return NewCombinedWithSchema(&Schema{Ref: newString("#")}), nil
+		}
+		self.names[t] = "#"
+		s, err := self.structSchema(t)
+		if err != nil {
+			return nil, err
+		}
+		return NewCombinedWithSchema(s), nil
+	}
+	if t.Name() == "" {
+		s, err := self.structSchema(t)
+		if err != nil {
+			return nil, err
+		}
+		return NewCombinedWithSchema(s), nil
+	}
+
+	name, ok := self.names[t]
+	if !ok {
+		name = t.Name()
+		if self.taken[name] {
+			pkg := t.PkgPath()
+			name = pkg[strings.LastIndex(pkg, "/")+1:] + "." + name
+			base := name
+			for i := 2; self.taken[name]; i++ {
+				name = fmt.Sprintf("%s%d", base, i)
+			}
+		}
+		self.taken[name] = true
+		self.names[t] = name
+		self.order = append(self.order, name)
+
+		s, err := self.structSchema(t)
+		if err != nil {
+			return nil, err
+		}
+		self.defs[name] = NewCombinedWithSchema(s)
+	}
+	return NewCombinedWithSchema(&Schema{Ref: newString(pointerAppend("#/definitions", name))}), nil
+}
+
+func (self *reflector) structSchema(t reflect.Type) (*Schema, error) {
+	s := &Schema{Type: NewStringOrStringArrayWithString("object")}
+	s.Properties = make(map[string]*Combined)
+	var keys []string
+	if err := self.addFields(s, t, &keys); err != nil {
+		return nil, err
+	}
+	s.setKeyOrder("properties", keys)
+	if self.opts.NoAdditionalProperties {
+		s.AdditionalProperties = NewCombinedWithBoolean(false)
+	}
+	return s, nil
+}
+
+// addFields adds the properties of the fields of t, including those
+// promoted from embedded structs, to s.
+func (self *reflector) addFields(s *Schema, t reflect.Type, keys *[]string) error {
+	for _, f := range structFields(t) {
+		prop, err := self.typeSchema(f.Type)
+		if err != nil {
+			return fmt.Errorf("field %s.%s: %w", f.owner.Name(), f.Name, err)
+		}
+		required := f.required
+		if tag, ok := f.Tag.Lookup("jsonschema"); ok {
+			if prop, required, err = applyTag(prop, tag, required); err != nil {
+				return fmt.Errorf("field %s.%s: %w", f.owner.Name(), f.Name, err)
+			}
+		}
+
+		s.Properties[f.name] = prop
+		*keys = append(*keys, f.name)
+		if required {
+			s.Required = append(s.Required, f.name)
+		}
+	}
+	return nil
+}
+
+// structField is a field of a struct, or of a struct embedded in it.
+type structField struct {
+	reflect.StructField
+	owner    reflect.Type
+	name     string
+	index    []int
+	tagged   bool
+	required bool
+}
+
+// structFields returns the fields encoding/json writes for t, in field
+// order. As in encoding/json, of the fields with the same name the
+// shallowest wins, a tagged one beating untagged ones at its depth, and
+// the name is dropped if that leaves more than one.
+func structFields(t reflect.Type) []structField {
+	var fields []structField
+	current, next := []structField{}, []structField{{owner: t}}
+	visited := make(map[reflect.Type]bool)
+	for len(next) > 0 {
+		current, next = next, current[:0]
+		seen := make(map[reflect.Type]bool)
+		for _, embedded := range current {
+			// a struct embedded twice at one depth is kept twice, so
+			// its fields cancel out
+			st := embedded.owner
+			if visited[st] {
+				continue
+			}
+			seen[st] = true
+			for i := 0; i < st.NumField(); i++ {
+				f := st.Field(i)
+				name, required, skip := fieldName(f)
+				if skip {
+					continue
+				}
+				index := append(append([]int{}, embedded.index...), i)
+				if f.Anonymous && name == "" {
+					ft := f.Type
+					if ft.Kind() == reflect.Pointer {
+						ft = ft.Elem()
+					}
+					if ft.Kind() == reflect.Struct {
+						next = append(next, structField{owner: ft, index: index})
+						continue
+					}
+				}
+				if !f.IsExported() {
+					continue
+				}
+				tagged := name != ""
+				if !tagged {
+					name = f.Name
+				}
+				fields = append(fields, structField{StructField: f, owner: st, name: name, index: index, tagged: tagged, required: required})
+			}
+		}
+		for st := range seen {
+			visited[st] = true
+		}
+	}
+
+	// fields are found by depth, so the first of a name is the shallowest
+	sort.SliceStable(fields, func(i, j int) bool {
+		return fields[i].name < fields[j].name
+	})
+	var out []structField
+	for i := 0; i < len(fields); {
+		j := i + 1
+		for j < len(fields) && fields[j].name == fields[i].name {
+			j++
+		}
+		if f, ok := dominantField(fields[i:j]); ok {
+			out = append(out, f)
+		}
+		i = j
+	}
+	sort.Slice(out, func(i, j int) bool {
+		a, b := out[i].index, out[j].index
+		for k := 0; k < len(a) && k < len(b); k++ {
+			if a[k] != b[k] {
+				return a[k] < b[k]
+			}
+		}
+		return len(a) < len(b)
+	})
+	return out
+}
+
+// dominantField returns the field that wins among fields of the same
+// name, sorted by depth, or false if none does.
+func dominantField(fields []structField) (structField, bool) {
+	depth := len(fields[0].index)
+	var winner []structField
+	for _, f := range fields {
+		if len(f.index) > depth {
+			break
+		}
+		winner = append(winner, f)
+	}
+	if len(winner) > 1 {
+		var tagged []structField
+		for _, f := range winner {
+			if f.tagged {
+				tagged = append(tagged, f)
+			}
+		}
+		if len(tagged) > 0 {
+			winner = tagged
+		}
+	}
+	if len(winner) > 1 {
+		return structField{}, false
+	}
+	return winner[0], true
+}
+
+// fieldName returns the property name of f from its json or hcl tag, and
+// tells if its hcl tag declares a required attribute or if it is skipped.
+func fieldName(f reflect.StructField) (name string, required bool, skip bool) {
+	jsonTag, hasJSON := f.Tag.Lookup("json")
+	if jsonTag == "-" {
+		return "", false, true
+	}
+	hclTag, hasHCL := f.Tag.Lookup("hcl")
+	if hclTag == "-" && !hasJSON {
+		return "", false, true
+	}
+
+	if hasJSON {
+		name, _, _ = strings.Cut(jsonTag, ",")
+	}
+	if hasHCL && hclTag != "-" {
+		hclName, kind, _ := strings.Cut(hclTag, ",")
+		if name == "" {
+			name = hclName
+		}
+		required = kind == "" || kind == "attr"
+	}
+	return name, required, false
+}
+
+// applyTag applies the keywords of a jsonschema tag to the schema of a
+// field.
+func applyTag(c *Combined, tag string, required bool) (*Combined, bool, error) {
+	if c.Schema == nil {
+		// any value
+		c = NewCombinedWithSchema(&Schema{})
+	}
+	s := c.Schema
+	if s.Ref != nil {
+		// keywords next to $ref are ignored in draft-07
+		s = &Schema{AllOf: []*Combined{c}}
+		c = NewCombinedWithSchema(s)
+	}
+
+	for _, item := range splitTag(tag) {
+		key, value, _ := strings.Cut(item, "=")
+		target := s
+		if itemKeywords[key] && s.Items != nil && s.Items.Combined != nil {
+			if s.Items.Combined.Schema == nil || s.Items.Combined.Schema.Ref != nil {
+				s.Items.Combined = NewCombinedWithSchema(&Schema{AllOf: []*Combined{s.Items.Combined}})
+			}
+			target = s.Items.Combined.Schema
+		}
+
+		var err error
+		switch key {
+		case "":
+		case "required":
+			required = true
+		case "optional":
+			required = false
+		case "title":
+			target.Title = newString(value)
+		case "description":
+			target.Description = newString(value)
+		case "format":
+			target.Format = newString(value)
+		case "pattern":
+			target.Pattern = newString(value)
+		case "minLength":
+			target.MinLength, err = parseTagInt(key, value)
+		case "maxLength":
+			target.MaxLength, err = parseTagInt(key, value)
+		case "minItems":
+			target.MinItems, err = parseTagInt(key, value)
+		case "maxItems":
+			target.MaxItems, err = parseTagInt(key, value)
+		case "minimum":
+			target.Minimum, err = parseTagNumber(key, value)
+		case "maximum":
+			target.Maximum, err = parseTagNumber(key, value)
+		case "exclusiveMinimum":
+			target.ExclusiveMinimum, err = parseTagNumber(key, value)
+		case "exclusiveMaximum":
+			target.ExclusiveMaximum, err = parseTagNumber(key, value)
+		case "multipleOf":
+			target.MultipleOf, err = parseTagNumber(key, value)
+		case "uniqueItems":
+			target.UniqueItems, err = parseTagBool(key, value)
+		case "readOnly":
+			target.ReadOnly, err = parseTagBool(key, value)
+		case "writeOnly":
+			target.WriteOnly, err = parseTagBool(key, value)
+		case "default":
+			var raw json.RawMessage
+			if raw, err = tagValue(target, value); err == nil {
+				target.Default = &raw
+			}
+		case "enum":
+			var raw json.RawMessage
+			if raw, err = tagValue(target, value); err == nil {
+				var v SchemaEnumValue
+				if err = json.Unmarshal(raw, &v); err == nil {
+					target.Enumeration = append(target.Enumeration, v)
+				}
+			}
+		default:
+			err = fmt.Errorf("unknown jsonschema keyword %q", key)
+		}
+		if err != nil {
+			return nil, false, err
+		}
+	}
+	return c, required, nil
+}
+
+// itemKeywords constrain strings and numbers, and apply to the elements
+// of a slice.
+var itemKeywords = map[string]bool{
+	"format": true, "pattern": true, "minLength": true, "maxLength": true,
+	"minimum": true, "maximum": true, "exclusiveMinimum": true, "exclusiveMaximum": true,
+	"multipleOf": true, "enum": true,
+}
+
+// splitTag splits a jsonschema tag at the commas not escaped as \,.
+func splitTag(tag string) []string {
+	var items []string
+	var b strings.Builder
+	for i := 0; i < len(tag); i++ {
+		switch {
+		case tag[i] == '\\' && i+1 < len(tag) && tag[i+1] == ',':
+			b.WriteByte(',')
+			i++
+		case tag[i] == ',':
+			items = append(items, b.String())
+			b.Reset()
+		default:
+			b.WriteByte(tag[i])
+		}
+	}
+	return append(items, b.String())
+}
+
+// tagValue encodes the value of a default or enum keyword in JSON, as a
+// string if s is of type string.
+func tagValue(s *Schema, value string) (json.RawMessage, error) {
+	if s.Type != nil && s.Type.String != nil && *s.Type.String == "string" {
+		return json.Marshal(value)
+	}
+	if !json.Valid([]byte(value)) {
+		return nil, fmt.Errorf("invalid JSON value %q", value)
+	}
+	return json.RawMessage(value), nil
+}
+
+func parseTagInt(key, value string) (*int64, error) {
+	i, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %q", key, value)
+	}
+	return &i, nil
+}
+
+func parseTagNumber(key, value string) (*IntegerOrFloat, error) {
+	if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return NewIntegerOrFloatWithInteger(i), nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %q", key, value)
+	}
+	return NewIntegerOrFloatWithFloat(f), nil
+}
+
+func parseTagBool(key, value string) (*bool, error) {
+	if value == "" {
+		value = "true"
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s %q", key, value)
+	}
+	return &b, nil
+}
+
+func newTyped(typ string) *Combined {
+	return NewCombinedWithSchema(&Schema{Type: NewStringOrStringArrayWithString(typ)})
+}
+
+func newString(str string) *string {
+	return &str
+}
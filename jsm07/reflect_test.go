@@ -0,0 +1,258 @@
+package jsm07
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+)
+
+type reflectAddress struct {
+	Street string `json:"street" jsonschema:"required,minLength=1"`
+	City   string `json:"city,omitempty"`
+}
+
+type reflectBase struct {
+	ID      string    `json:"id" hcl:"id"`
+	Created time.Time `json:"created"`
+}
+
+type reflectUser struct {
+	reflectBase
+	Name     string            `json:"name" hcl:"name" jsonschema:"description=Full name\\, as written"`
+	Age      *uint8            `json:"age,omitempty" hcl:"age,optional" jsonschema:"maximum=150"`
+	Role     string            `json:"role" hcl:"role,optional" jsonschema:"enum=admin,enum=user,default=user"`
+	Tags     []string          `json:"tags,omitempty" hcl:"tags,optional" jsonschema:"pattern=^[a-z]+$,uniqueItems"`
+	Home     *reflectAddress   `json:"home,omitempty" hcl:"home,block" jsonschema:"description=Where the user lives"`
+	Work     []reflectAddress  `json:"work,omitempty" hcl:"work,block"`
+	Labels   map[string]string `json:"labels,omitempty" hcl:"labels,optional"`
+	Manager  *reflectUser      `json:"manager,omitempty"`
+	Avatar   []byte            `json:"avatar,omitempty"`
+	Extra    interface{}       `json:"extra,omitempty"`
+	Point    [2]float64        `json:"point"`
+	Ignored  string            `json:"-"`
+	internal string
+}
+
+func TestFromType(t *testing.T) {
+	schema, err := FromType(reflect.TypeOf(&reflectUser{}), &TypeOptions{ID: "https://example.com/user.json"})
+	if err != nil {
+		t.Fatalf("Failed to build schema: %v", err)
+	}
+	bs, err := json.Marshal(schema)
+	if err != nil {
+		t.Fatalf("Failed to marshal schema: %v", err)
+	}
+
+	expected := new(Schema)
+	if err := json.Unmarshal([]byte(`{
+		"$id": "https://example.com/user.json",
+		"$schema": "http://json-schema.org/draft-07/schema#",
+		"type": "object",
+		"required": ["id", "name"],
+		"properties": {
+			"id": {"type": "string"},
+			"created": {"type": "string", "format": "date-time"},
+			"name": {"type": "string", "description": "Full name, as written"},
+			"age": {"type": "integer", "minimum": 0, "maximum": 150},
+			"role": {"type": "string", "enum": ["admin", "user"], "default": "user"},
+			"tags": {"type": "array", "items": {"type": "string", "pattern": "^[a-z]+$"}, "uniqueItems": true},
+			"home": {"allOf": [{"$ref": "#/definitions/reflectAddress"}], "description": "Where the user lives"},
+			"work": {"type": "array", "items": {"$ref": "#/definitions/reflectAddress"}},
+			"labels": {"type": "object", "additionalProperties": {"type": "string"}},
+			"manager": {"$ref": "#"},
+			"avatar": {"type": "string", "contentEncoding": "base64"},
+			"extra": true,
+			"point": {"type": "array", "items": {"type": "number"}, "minItems": 2, "maxItems": 2}
+		},
+		"definitions": {
+			"reflectAddress": {
+				"type": "object",
+				"required": ["street"],
+				"properties": {
+					"street": {"type": "string", "minLength": 1},
+					"city": {"type": "string"}
+				}
+			}
+		}
+	}`), expected); err != nil {
+		t.Fatalf("Failed to unmarshal expected schema: %v", err)
+	}
+	want, err := json.Marshal(expected)
+	if err != nil {
+		t.Fatalf("Failed to marshal expected schema: %v", err)
+	}
+	if string(bs) != string(want) {
+		t.Errorf("Expected\n%s\ngot\n%s", want, bs)
+	}
+
+	if got := strings.Join(schema.OrderedKeys("properties"), ","); got != "id,created,name,age,role,tags,home,work,labels,manager,avatar,extra,point" {
+		t.Errorf("Expected properties in field order, got %s", got)
+	}
+
+	// the schema accepts what encoding/json writes
+	age := uint8(30)
+	user := reflectUser{
+		reflectBase: reflectBase{ID: "u1", Created: time.Now()},
+		Name:        "Ann",
+		Age:         &age,
+		Role:        "admin",
+		Home:        &reflectAddress{Street: "Main"},
+	}
+	data, err := json.Marshal(user)
+	if err != nil {
+		t.Fatalf("Failed to marshal user: %v", err)
+	}
+	var instance interface{}
+	if err := json.Unmarshal(data, &instance); err != nil {
+		t.Fatalf("Failed to unmarshal user: %v", err)
+	}
+	if err := schema.Validate(instance); err != nil {
+		t.Errorf("Expected %s to be valid: %v", data, err)
+	}
+}
+
+func TestFromTypeErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+		err   string
+	}{
+		{"channel", struct{ C chan int }{}, "unsupported type chan int"},
+		{"keyword", struct {
+			N int `jsonschema:"minimun=1"`
+		}{}, `unknown jsonschema keyword "minimun"`},
+		{"number", struct {
+			N int `jsonschema:"maximum=ten"`
+		}{}, `invalid maximum "ten"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := FromType(reflect.TypeOf(tt.value), nil)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Errorf("Expected error containing %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+type reflectInner struct {
+	Name  string `json:"name"`
+	Kind  string
+	Label string
+}
+
+type reflectOther struct {
+	Label string
+	Size  int
+}
+
+type reflectOuter struct {
+	reflectInner
+	*reflectOther
+	Kind string `json:"kind"`
+	Size string `json:"Size"`
+	Name int
+}
+
+type reflectTagged struct {
+	reflectOther
+	Alias int `json:"Label"`
+}
+
+type reflectShadow struct {
+	Label string
+}
+
+type reflectPrecedence struct {
+	reflectTagged
+	reflectShadow
+}
+
+func TestFromTypeFields(t *testing.T) {
+	tests := []struct {
+		name  string
+		value interface{}
+	}{
+		{"outer", reflectOuter{Name: 1, reflectInner: reflectInner{Name: "n", Label: "l"}, reflectOther: &reflectOther{Label: "o", Size: 3}, Size: "s"}},
+		{"precedence", reflectPrecedence{reflectTagged: reflectTagged{Alias: 1, reflectOther: reflectOther{Label: "o"}}, reflectShadow: reflectShadow{Label: "s"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema, err := FromType(reflect.TypeOf(tt.value), nil)
+			if err != nil {
+				t.Fatalf("Failed to build schema: %v", err)
+			}
+			data, err := json.Marshal(tt.value)
+			if err != nil {
+				t.Fatalf("Failed to marshal value: %v", err)
+			}
+			var instance map[string]interface{}
+			if err := json.Unmarshal(data, &instance); err != nil {
+				t.Fatalf("Failed to unmarshal value: %v", err)
+			}
+			var want []string
+			for k := range instance {
+				want = append(want, k)
+			}
+			sort.Strings(want)
+			got := append([]string{}, schema.OrderedKeys("properties")...)
+			sort.Strings(got)
+			if strings.Join(got, ",") != strings.Join(want, ",") {
+				t.Errorf("Expected properties %v, got %v", want, got)
+			}
+			if err := schema.Validate(instance); err != nil {
+				t.Errorf("Expected %s to be valid: %v", data, err)
+			}
+		})
+	}
+
+}
+
+type reflectItem struct {
+	A int `json:"a"`
+}
+
+func TestFromTypeNames(t *testing.T) {
+	// types declared in functions share the name and package of
+	// reflectItem
+	local := func() (a, b, c reflect.Type) {
+		{
+			type reflectItem struct {
+				B int `json:"b"`
+			}
+			a = reflect.TypeOf(reflectItem{})
+		}
+		{
+			type reflectItem struct {
+				C int `json:"c"`
+			}
+			b = reflect.TypeOf(reflectItem{})
+		}
+		{
+			type reflectItem struct {
+				D int `json:"d"`
+			}
+			c = reflect.TypeOf(reflectItem{})
+		}
+		return
+	}
+	a, b, c := local()
+	root := reflect.StructOf([]reflect.StructField{
+		{Name: "W", Type: reflect.TypeOf(reflectItem{}), Tag: `json:"w"`},
+		{Name: "X", Type: a, Tag: `json:"x"`},
+		{Name: "Y", Type: b, Tag: `json:"y"`},
+		{Name: "Z", Type: c, Tag: `json:"z"`},
+	})
+	schema, err := FromType(root, nil)
+	if err != nil {
+		t.Fatalf("Failed to build schema: %v", err)
+	}
+	if got := strings.Join(schema.OrderedKeys("definitions"), ","); got != "reflectItem,jsm07.reflectItem,jsm07.reflectItem2,jsm07.reflectItem3" {
+		t.Errorf("Expected numbered definitions, got %s", got)
+	}
+}
@@ -0,0 +1,157 @@
+package jsm07
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// Resolver resolves references that may point into other documents.
+//
+// Every schema known to the resolver is indexed with the base URI in
+// effect at it: a subschema declaring $id changes the base URI for itself
+// and its subschemas, as draft-07 specifies, and can be referenced by that
+// URI. A plain-name $id such as "#node" is indexed as a fragment of the
+// current base URI. Documents not yet known are fetched through the
+// Loader and cached. A Resolver is not safe for concurrent use.
+type Resolver struct {
+	loader    Loader
+	root      *Schema
+	resources map[string]*Combined
+	bases     map[*Schema]string
+}
+
+// NewResolver returns a resolver whose root document is root, retrieved
+// from baseURI. If root is nil, it is loaded from baseURI. Both baseURI
+// and loader may be empty, in which case only references within root
+// can be resolved.
+func NewResolver(root *Schema, baseURI string, loader Loader) (*Resolver, error) {
+	u, err := url.Parse(baseURI)
+	if err != nil {
+		return nil, err
+	}
+	u.Fragment, u.RawFragment = "", ""
+
+	self := &Resolver{
+		loader:    loader,
+		resources: make(map[string]*Combined),
+		bases:     make(map[*Schema]string),
+	}
+	if root == nil {
+		if loader == nil {
+			return nil, fmt.Errorf("no root schema and no loader")
+		}
+		root, err = loader.Load(u.String())
+		if err != nil {
+			return nil, err
+		}
+	}
+	self.root = root
+	self.addDocument(NewCombinedWithSchema(root), u)
+	return self, nil
+}
+
+// Root returns the root document.
+func (self *Resolver) Root() *Schema {
+	return self.root
+}
+
+// BaseURI returns the base URI in effect at s, or "" if s is not known to
+// the resolver.
+func (self *Resolver) BaseURI(s *Schema) string {
+	return self.bases[s]
+}
+
+// Resolve resolves ref against the base URI base and returns the target
+// together with the base URI in effect at the target.
+func (self *Resolver) Resolve(base, ref string) (*Combined, string, error) {
+	b, err := url.Parse(base)
+	if err != nil {
+		return nil, "", err
+	}
+	u, err := b.Parse(ref)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid reference %q: %w", ref, err)
+	}
+	fragment := u.Fragment
+	u.Fragment, u.RawFragment = "", ""
+	doc := u.String()
+
+	c, ok := self.resources[doc]
+	if !ok {
+		if self.loader == nil {
+			return nil, "", fmt.Errorf("unresolvable reference %q: no loader for %s", ref, doc)
+		}
+		s, err := self.loader.Load(doc)
+		if err != nil {
+			return nil, "", fmt.Errorf("unresolvable reference %q: %w", ref, err)
+		}
+		c = NewCombinedWithSchema(s)
+		self.addDocument(c, u)
+	}
+
+	target := c
+	if strings.HasPrefix(fragment, "/") {
+		tokens, err := splitPointer(fragment)
+		if err != nil {
+			return nil, "", err
+		}
+		target, err = lookupTokens(c, tokens)
+		if err != nil {
+			return nil, "", fmt.Errorf("unresolvable reference %q: %w", ref, err)
+		}
+	} else if fragment != "" {
+		if target, ok = self.resources[doc+"#"+fragment]; !ok {
+			return nil, "", fmt.Errorf("unresolvable reference %q: no schema has $id %q", ref, "#"+fragment)
+		}
+	}
+
+	if target.Schema != nil {
+		if b, ok := self.bases[target.Schema]; ok {
+			return target, b, nil
+		}
+	}
+	return target, doc, nil
+}
+
+// addDocument indexes a document retrieved from u.
+func (self *Resolver) addDocument(c *Combined, u *url.URL) {
+	self.resources[u.String()] = c
+	self.index(c, u)
+}
+
+func (self *Resolver) index(c *Combined, base *url.URL) {
+	if c == nil || c.Schema == nil {
+		return
+	}
+	s := c.Schema
+	if _, ok := self.bases[s]; ok {
+		return
+	}
+
+	if s.ID != nil {
+		if id, err := base.Parse(*s.ID); err == nil {
+			if strings.HasPrefix(*s.ID, "#") {
+				if id.Fragment != "" {
+					self.resources[withoutFragment(base)+"#"+id.Fragment] = c
+				}
+			} else {
+				base = id
+				base.Fragment, base.RawFragment = "", ""
+				self.resources[base.String()] = c
+			}
+		}
+	}
+	self.bases[s] = withoutFragment(base)
+
+	forEachChild(s, func(_ []string, child *Combined) bool {
+		self.index(child, base)
+		return true
+	})
+}
+
+func withoutFragment(u *url.URL) string {
+	x := *u
+	x.Fragment, x.RawFragment = "", ""
+	return x.String()
+}
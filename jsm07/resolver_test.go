@@ -0,0 +1,162 @@
+package jsm07
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func mustSchema(t *testing.T, data string) *Schema {
+	t.Helper()
+	s := new(Schema)
+	if err := json.Unmarshal([]byte(data), s); err != nil {
+		t.Fatalf("Failed to unmarshal schema: %v", err)
+	}
+	return s
+}
+
+func TestResolverNestedID(t *testing.T) {
+	root := mustSchema(t, `{
+		"$id": "http://example.com/root.json",
+		"definitions": {
+			"A": {"$id": "#foo", "type": "integer"},
+			"B": {
+				"$id": "other.json",
+				"definitions": {
+					"X": {"$id": "#bar", "type": "string"},
+					"Y": {"$ref": "#/definitions/X"}
+				}
+			},
+			"C": {"$id": "t/inner.json", "type": "boolean"}
+		}
+	}`)
+
+	r, err := NewResolver(root, "", nil)
+	if err != nil {
+		t.Fatalf("Failed to create resolver: %v", err)
+	}
+
+	tests := []struct {
+		base string
+		ref  string
+		typ  string
+		out  string
+	}{
+		{"http://example.com/root.json", "#foo", "integer", "http://example.com/root.json"},
+		{"http://example.com/root.json", "other.json#bar", "string", "http://example.com/other.json"},
+		{"http://example.com/root.json", "http://example.com/other.json#/definitions/X", "string", "http://example.com/other.json"},
+		{"http://example.com/other.json", "#/definitions/Y", "", "http://example.com/other.json"},
+		{"http://example.com/root.json", "t/inner.json", "boolean", "http://example.com/t/inner.json"},
+	}
+	for _, tt := range tests {
+		c, base, err := r.Resolve(tt.base, tt.ref)
+		if err != nil {
+			t.Fatalf("Failed to resolve %s: %v", tt.ref, err)
+		}
+		if tt.typ != "" && (c.Schema.Type == nil || *c.Schema.Type.String != tt.typ) {
+			t.Errorf("Expected %s to resolve to type %s, got %#v", tt.ref, tt.typ, c.Schema)
+		}
+		if base != tt.out {
+			t.Errorf("Expected base %s for %s, got %s", tt.out, tt.ref, base)
+		}
+	}
+
+	if err := root.Validate(map[string]interface{}{}); err != nil {
+		t.Errorf("Expected valid instance, got %v", err)
+	}
+}
+
+func TestResolverMapLoader(t *testing.T) {
+	root := mustSchema(t, `{
+		"$id": "http://example.com/root.json",
+		"properties": {"role": {"$ref": "common.json#/definitions/Role"}}
+	}`)
+	loader := MapLoader{
+		"http://example.com/common.json": mustSchema(t, `{"definitions": {"Role": {"enum": ["user", "assistant"]}}}`),
+	}
+
+	r, err := NewResolver(root, "", loader)
+	if err != nil {
+		t.Fatalf("Failed to create resolver: %v", err)
+	}
+	if err := r.Validate(map[string]interface{}{"role": "user"}); err != nil {
+		t.Errorf("Expected valid instance, got %v", err)
+	}
+	var errs ValidationErrors
+	if !errors.As(r.Validate(map[string]interface{}{"role": "system"}), &errs) || errs[0].Keyword != "enum" {
+		t.Errorf("Expected enum error, got %v", errs)
+	}
+
+	// without a loader the reference cannot be followed
+	if !errors.As(root.Validate(map[string]interface{}{"role": "user"}), &errs) || errs[0].Keyword != "$ref" {
+		t.Errorf("Expected $ref error, got %v", errs)
+	}
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func TestResolverHTTPLoader(t *testing.T) {
+	docs := map[string]string{
+		"/schemas/root.json":  `{"properties": {"name": {"$ref": "common.hcl#/definitions/Name"}}}`,
+		"/schemas/common.hcl": "definitions \"Name\" {\n  type = \"string\"\n  minLength = 2\n}\n",
+	}
+	var fetched []string
+	transport := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+		fetched = append(fetched, req.URL.Path)
+		body, ok := docs[req.URL.Path]
+		if !ok {
+			return &http.Response{StatusCode: http.StatusNotFound, Status: "404 Not Found", Body: io.NopCloser(strings.NewReader("")), Request: req}, nil
+		}
+		return &http.Response{StatusCode: http.StatusOK, Status: "200 OK", Body: io.NopCloser(strings.NewReader(body)), Request: req}, nil
+	})
+
+	r, err := NewResolver(nil, "https://example.com/schemas/root.json", &HTTPLoader{Transport: transport})
+	if err != nil {
+		t.Fatalf("Failed to create resolver: %v", err)
+	}
+	if err := r.Validate(map[string]interface{}{"name": "ab"}); err != nil {
+		t.Errorf("Expected valid instance, got %v", err)
+	}
+	if err := r.Validate(map[string]interface{}{"name": "a"}); err == nil {
+		t.Error("Expected minLength error")
+	}
+	if len(fetched) != 2 {
+		t.Errorf("Expected each document to be fetched once, got %v", fetched)
+	}
+
+	if _, _, err := r.Resolve("https://example.com/schemas/root.json", "nowhere.json"); err == nil {
+		t.Error("Expected error for missing document")
+	}
+}
+
+func TestResolverFileLoader(t *testing.T) {
+	dir := t.TempDir()
+	common := "definitions \"Port\" {\n  type = \"integer\"\n  maximum = 65535\n}\n"
+	if err := os.WriteFile(filepath.Join(dir, "common.hcl"), []byte(common), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	root := `{"properties": {"port": {"$ref": "common.hcl#/definitions/Port"}}}`
+	if err := os.WriteFile(filepath.Join(dir, "root.json"), []byte(root), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := NewResolver(nil, "file://"+filepath.ToSlash(filepath.Join(dir, "root.json")), NewDefaultLoader())
+	if err != nil {
+		t.Fatalf("Failed to create resolver: %v", err)
+	}
+	if err := r.Validate(map[string]interface{}{"port": 80.0}); err != nil {
+		t.Errorf("Expected valid instance, got %v", err)
+	}
+	if err := r.Validate(map[string]interface{}{"port": 80000.0}); err == nil {
+		t.Error("Expected maximum error")
+	}
+}
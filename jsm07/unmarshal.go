@@ -2,9 +2,13 @@ package jsm07
 
 import (
 	"encoding/json"
-	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
 
-	"github.com/genelet/hcllight/light"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
 )
 
 // UnmarshalHCL unmarshals HCL data into a Schema object.
@@ -17,383 +21,579 @@ func (self *Schema) UnmarshalHCL(data []byte, labels ...string) error {
 	return nil
 }
 
-// parseSchema parses a HCL string representing a JSON schema and returns a Schema object.
+// ParseSchema parses a HCL string representing a JSON schema and returns a Schema object.
+// Errors are returned as hcl.Diagnostics; unknown keywords are ignored.
+// Use ParseSchemaFile for file names in diagnostics, warnings and strict mode.
 func ParseSchema(data []byte) (*Schema, error) {
-	body, err := light.ParseBody(data)
-	if err != nil {
-		return nil, err
+	schema, diags := ParseSchemaFile(data, nil)
+	if diags.HasErrors() {
+		return nil, diags
 	}
-	return parseSchemaFromBody(body)
+	return schema, nil
 }
 
-func parseSchemaFromBody(body *light.Body) (*Schema, error) {
-	if body == nil {
-		return nil, nil
-	}
+type keywordKind int
+
+const (
+	kindString keywordKind = iota
+	kindStringArray
+	kindType
+	kindNonNegativeInteger
+	kindNumber
+	kindBool
+	kindAny
+	kindEnum
+	kindCombined
+	kindDependency
+)
 
-	var typ *StringOrStringArray
-	if attr, ok := body.Attributes["type"]; ok {
-		typ = exprToStringOrStringArray(attr.Expr)
-	}
+// keywordValue holds a decoded keyword value in the field of its kind.
+type keywordValue struct {
+	str        *string
+	strs       []string
+	typ        *StringOrStringArray
+	integer    *int64
+	number     *IntegerOrFloat
+	boolean    *bool
+	raw        *json.RawMessage
+	enum       []SchemaEnumValue
+	combined   *Combined
+	dependency *CombinedOrStringArray
+}
 
-	schema := &Schema{}
-	for k, v := range body.Attributes {
-		expr := v.Expr
-		var err error
-		switch k {
-		case "_id":
-			schema.ID = light.TextValueExprToString(expr)
-		case "_schema":
-			schema.Schema = light.TextValueExprToString(expr)
-		case "_ref":
-			schema.Ref = light.TextValueExprToString(expr)
-		case "_comment":
-			schema.Comment = light.TextValueExprToString(expr)
-		case "title":
-			schema.Title = light.TextValueExprToString(expr)
-		case "description":
-			schema.Description = light.TextValueExprToString(expr)
-		case "format":
-			schema.Format = light.TextValueExprToString(expr)
-		case "contentMediaType":
-			schema.ContentMediaType = light.TextValueExprToString(expr)
-		case "contentEncoding":
-			schema.ContentEncoding = light.TextValueExprToString(expr)
-		case "pattern":
-			schema.Pattern = light.TextValueExprToString(expr)
-
-		case "maxLength":
-			schema.MaxLength = light.LiteralValueExprToInt64(expr)
-		case "minLength":
-			schema.MinLength = light.LiteralValueExprToInt64(expr)
-		case "maxItems":
-			schema.MaxItems = light.LiteralValueExprToInt64(expr)
-		case "minItems":
-			schema.MinItems = light.LiteralValueExprToInt64(expr)
-		case "maxProperties":
-			schema.MaxProperties = light.LiteralValueExprToInt64(expr)
-		case "minProperties":
-			schema.MinProperties = light.LiteralValueExprToInt64(expr)
-
-		case "readOnly":
-			schema.ReadOnly = light.LiteralValueExprToBoolean(expr)
-		case "writeOnly":
-			schema.WriteOnly = light.LiteralValueExprToBoolean(expr)
-		case "uniqueItems":
-			schema.UniqueItems = light.LiteralValueExprToBoolean(expr)
-
-		case "const":
-			schema.Const = exprToJSONRaw(expr)
-		case "default":
-			schema.Default = exprToJSONRaw(expr)
-		case "example":
-			schema.Examples = exprToJSONRaw(expr)
-
-		case "type":
-			schema.Type = typ
-		case "multipleOf":
-			schema.MultipleOf = exprToIntegerOrFloat(expr, typ)
-		case "maximum":
-			schema.Maximum = exprToIntegerOrFloat(expr, typ)
-		case "exclusiveMaximum":
-			schema.ExclusiveMaximum = exprToIntegerOrFloat(expr, typ)
-		case "minimum":
-			schema.Minimum = exprToIntegerOrFloat(expr, typ)
-		case "exclusiveMinimum":
-			schema.ExclusiveMinimum = exprToIntegerOrFloat(expr, typ)
-
-		case "additionalItems":
-			schema.AdditionalItems, err = expressionToCombined(expr)
-		case "propertyNames":
-			schema.PropertyNames, err = expressionToCombined(expr)
-		case "additionalProperties":
-			schema.AdditionalProperties, err = expressionToCombined(expr)
-		case "contains":
-			schema.Contains, err = expressionToCombined(expr)
-		case "if":
-			schema.If, err = expressionToCombined(expr)
-		case "then":
-			schema.Then, err = expressionToCombined(expr)
-		case "else":
-			schema.Else, err = expressionToCombined(expr)
-		case "not":
-			schema.Not, err = expressionToCombined(expr)
-
-		case "required":
-			schema.Required = light.TupleConsExprToStringArray(expr)
-		case "enum":
-			schema.Enumeration, err = tupleConsExprToEnum(expr)
+// keyword describes how a schema keyword is written in HCL and where its
+// value goes. label is the block label, if any.
+type keyword struct {
+	kind keywordKind
+	// labels is the minimum and maximum number of labels of a block.
+	labels [2]int
+	set    func(s *Schema, label string, v *keywordValue)
+}
 
-		default:
-			// ignore
+// schemaAttributes lists the keywords that may appear as HCL attributes.
+var schemaAttributes = map[string]keyword{
+	"_id":              {kind: kindString, set: func(s *Schema, _ string, v *keywordValue) { s.ID = v.str }},
+	"_schema":          {kind: kindString, set: func(s *Schema, _ string, v *keywordValue) { s.Schema = v.str }},
+	"_ref":             {kind: kindString, set: func(s *Schema, _ string, v *keywordValue) { s.Ref = v.str }},
+	"_comment":         {kind: kindString, set: func(s *Schema, _ string, v *keywordValue) { s.Comment = v.str }},
+	"title":            {kind: kindString, set: func(s *Schema, _ string, v *keywordValue) { s.Title = v.str }},
+	"description":      {kind: kindString, set: func(s *Schema, _ string, v *keywordValue) { s.Description = v.str }},
+	"format":           {kind: kindString, set: func(s *Schema, _ string, v *keywordValue) { s.Format = v.str }},
+	"contentMediaType": {kind: kindString, set: func(s *Schema, _ string, v *keywordValue) { s.ContentMediaType = v.str }},
+	"contentEncoding":  {kind: kindString, set: func(s *Schema, _ string, v *keywordValue) { s.ContentEncoding = v.str }},
+	"pattern":          {kind: kindString, set: func(s *Schema, _ string, v *keywordValue) { s.Pattern = v.str }},
+
+	"maxLength":     {kind: kindNonNegativeInteger, set: func(s *Schema, _ string, v *keywordValue) { s.MaxLength = v.integer }},
+	"minLength":     {kind: kindNonNegativeInteger, set: func(s *Schema, _ string, v *keywordValue) { s.MinLength = v.integer }},
+	"maxItems":      {kind: kindNonNegativeInteger, set: func(s *Schema, _ string, v *keywordValue) { s.MaxItems = v.integer }},
+	"minItems":      {kind: kindNonNegativeInteger, set: func(s *Schema, _ string, v *keywordValue) { s.MinItems = v.integer }},
+	"maxProperties": {kind: kindNonNegativeInteger, set: func(s *Schema, _ string, v *keywordValue) { s.MaxProperties = v.integer }},
+	"minProperties": {kind: kindNonNegativeInteger, set: func(s *Schema, _ string, v *keywordValue) { s.MinProperties = v.integer }},
+
+	"readOnly":    {kind: kindBool, set: func(s *Schema, _ string, v *keywordValue) { s.ReadOnly = v.boolean }},
+	"writeOnly":   {kind: kindBool, set: func(s *Schema, _ string, v *keywordValue) { s.WriteOnly = v.boolean }},
+	"uniqueItems": {kind: kindBool, set: func(s *Schema, _ string, v *keywordValue) { s.UniqueItems = v.boolean }},
+
+	"const":    {kind: kindAny, set: func(s *Schema, _ string, v *keywordValue) { s.Const = v.raw }},
+	"default":  {kind: kindAny, set: func(s *Schema, _ string, v *keywordValue) { s.Default = v.raw }},
+	"examples": {kind: kindAny, set: func(s *Schema, _ string, v *keywordValue) { s.Examples = v.raw }},
+
+	"type":             {kind: kindType, set: func(s *Schema, _ string, v *keywordValue) { s.Type = v.typ }},
+	"multipleOf":       {kind: kindNumber, set: func(s *Schema, _ string, v *keywordValue) { s.MultipleOf = v.number }},
+	"maximum":          {kind: kindNumber, set: func(s *Schema, _ string, v *keywordValue) { s.Maximum = v.number }},
+	"exclusiveMaximum": {kind: kindNumber, set: func(s *Schema, _ string, v *keywordValue) { s.ExclusiveMaximum = v.number }},
+	"minimum":          {kind: kindNumber, set: func(s *Schema, _ string, v *keywordValue) { s.Minimum = v.number }},
+	"exclusiveMinimum": {kind: kindNumber, set: func(s *Schema, _ string, v *keywordValue) { s.ExclusiveMinimum = v.number }},
+
+	"additionalItems":      {kind: kindCombined, set: func(s *Schema, _ string, v *keywordValue) { s.AdditionalItems = v.combined }},
+	"propertyNames":        {kind: kindCombined, set: func(s *Schema, _ string, v *keywordValue) { s.PropertyNames = v.combined }},
+	"additionalProperties": {kind: kindCombined, set: func(s *Schema, _ string, v *keywordValue) { s.AdditionalProperties = v.combined }},
+	"contains":             {kind: kindCombined, set: func(s *Schema, _ string, v *keywordValue) { s.Contains = v.combined }},
+	"if":                   {kind: kindCombined, set: func(s *Schema, _ string, v *keywordValue) { s.If = v.combined }},
+	"then":                 {kind: kindCombined, set: func(s *Schema, _ string, v *keywordValue) { s.Then = v.combined }},
+	"else":                 {kind: kindCombined, set: func(s *Schema, _ string, v *keywordValue) { s.Else = v.combined }},
+	"not":                  {kind: kindCombined, set: func(s *Schema, _ string, v *keywordValue) { s.Not = v.combined }},
+
+	"required": {kind: kindStringArray, set: func(s *Schema, _ string, v *keywordValue) { s.Required = v.strs }},
+	"enum":     {kind: kindEnum, set: func(s *Schema, _ string, v *keywordValue) { s.Enumeration = v.enum }},
+}
+
+// schemaBlocks lists the keywords that may appear as HCL blocks. Repeated
+// items blocks form an array of schemas; allOf, anyOf and oneOf blocks
+// add one schema each.
+var schemaBlocks = map[string]keyword{
+	"items":                {kind: kindCombined, set: func(s *Schema, _ string, v *keywordValue) { addItem(s, v.combined) }},
+	"additionalProperties": {kind: kindCombined, set: func(s *Schema, _ string, v *keywordValue) { s.AdditionalProperties = v.combined }},
+	"additionalItems":      {kind: kindCombined, set: func(s *Schema, _ string, v *keywordValue) { s.AdditionalItems = v.combined }},
+	"propertyNames":        {kind: kindCombined, set: func(s *Schema, _ string, v *keywordValue) { s.PropertyNames = v.combined }},
+	"contains":             {kind: kindCombined, set: func(s *Schema, _ string, v *keywordValue) { s.Contains = v.combined }},
+	"if":                   {kind: kindCombined, set: func(s *Schema, _ string, v *keywordValue) { s.If = v.combined }},
+	"then":                 {kind: kindCombined, set: func(s *Schema, _ string, v *keywordValue) { s.Then = v.combined }},
+	"else":                 {kind: kindCombined, set: func(s *Schema, _ string, v *keywordValue) { s.Else = v.combined }},
+	"not":                  {kind: kindCombined, set: func(s *Schema, _ string, v *keywordValue) { s.Not = v.combined }},
+	"allOf":                {kind: kindCombined, set: func(s *Schema, _ string, v *keywordValue) { s.AllOf = append(s.AllOf, v.combined) }},
+	"anyOf":                {kind: kindCombined, set: func(s *Schema, _ string, v *keywordValue) { s.AnyOf = append(s.AnyOf, v.combined) }},
+	"oneOf":                {kind: kindCombined, set: func(s *Schema, _ string, v *keywordValue) { s.OneOf = append(s.OneOf, v.combined) }},
+	"dependencies": {kind: kindDependency, labels: [2]int{1, 1}, set: func(s *Schema, label string, v *keywordValue) {
+		if s.Dependencies == nil {
+			s.Dependencies = make(map[string]*CombinedOrStringArray)
 		}
-		if err != nil {
-			return nil, err
+		s.Dependencies[label] = v.dependency
+	}},
+	"definitions":       {kind: kindCombined, labels: [2]int{1, 1}, set: func(s *Schema, label string, v *keywordValue) { setMember(&s.Definitions, label, v.combined) }},
+	"patternProperties": {kind: kindCombined, labels: [2]int{1, 1}, set: func(s *Schema, label string, v *keywordValue) { setMember(&s.PatternProperties, label, v.combined) }},
+	// an unlabeled properties block stands for an empty properties map
+	"properties": {kind: kindCombined, labels: [2]int{0, 1}, set: func(s *Schema, label string, v *keywordValue) {
+		if label == "" {
+			if s.Properties == nil {
+				s.Properties = map[string]*Combined{}
+			}
+			return
 		}
+		setMember(&s.Properties, label, v.combined)
+	}},
+}
 
+func addItem(s *Schema, c *Combined) {
+	switch {
+	case s.Items == nil:
+		s.Items = NewCombinedOrCombinedArrayWithCombined(c)
+	case s.Items.Combined != nil:
+		s.Items = NewCombinedOrCombinedArrayWithCombinedArray([]*Combined{s.Items.Combined, c})
+	default:
+		arr := append(*s.Items.CombinedArray, c)
+		s.Items.CombinedArray = &arr
+	}
+}
+
+func setMember(m *map[string]*Combined, k string, c *Combined) {
+	if *m == nil {
+		*m = make(map[string]*Combined)
 	}
+	(*m)[k] = c
+}
 
-	var combs []*Combined
-	props := make(map[string]*Combined)
-	var nullProps int
+// schemaBody reads a schema written as an HCL body.
+func (self *parser) schemaBody(body *hclsyntax.Body) *Schema {
+	schema := &Schema{}
+	for _, attr := range sortedAttributes(body) {
+		self.attribute(schema, attr.Name, attr.NameRange, attr.Expr)
+	}
 
+	order := make(map[string][]string)
 	for _, block := range body.Blocks {
-		switch block.Type {
-		case "items", "additionalProperties", "additionalItems", "propertyNames", "contains", "if", "then", "else", "not":
-			c, err := newCombinedFromBody(block.Bdy)
-			if err != nil {
-				return nil, err
-			}
-			switch block.Type {
-			case "items":
-				combs = append(combs, c)
-			case "additionalProperties":
-				schema.AdditionalProperties = c
-			case "additionalItems":
-				schema.AdditionalItems = c
-			case "propertyNames":
-				schema.PropertyNames = c
-			case "contains":
-				schema.Contains = c
-			case "if":
-				schema.If = c
-			case "then":
-				schema.Then = c
-			case "else":
-				schema.Else = c
-			case "not":
-				schema.Not = c
-			}
+		kw, ok := schemaBlocks[block.Type]
+		if !ok {
+			self.unknown("block", block.Type, block.TypeRange)
+			continue
+		}
+		n := len(block.Labels)
+		if n < kw.labels[0] {
+			self.invalid("Missing block label", block.TypeRange, "A %s block requires a label naming its key.", block.Type)
+			continue
+		}
+		if n > kw.labels[1] {
+			self.invalid("Extraneous block label", block.LabelRanges[kw.labels[1]], "A %s block takes at most %d label(s).", block.Type, kw.labels[1])
+			continue
+		}
 
-		case "allOf", "anyOf", "oneOf":
-			combined, err := newCombinedFromBody(block.Bdy)
-			if err != nil {
-				return nil, err
-			}
-			switch block.Type {
-			case "allOf":
-				schema.AllOf = append(schema.AllOf, combined)
-			case "anyOf":
-				schema.AnyOf = append(schema.AnyOf, combined)
-			case "oneOf":
-				schema.OneOf = append(schema.OneOf, combined)
-			}
+		var label string
+		if n > 0 {
+			label = block.Labels[0]
+			order[block.Type] = append(order[block.Type], label)
+		}
+		v := &keywordValue{}
+		if kw.kind == kindDependency {
+			v.dependency = self.dependencyBody(block.Body)
+		} else {
+			v.combined = NewCombinedWithSchema(self.schemaBody(block.Body))
+		}
+		kw.set(schema, label, v)
+	}
 
-		case "dependencies":
-			combinedOrStringArray, err := bodyCombinedOrStringArray(block.Bdy)
-			if err != nil {
-				return nil, err
-			}
-			if schema.Dependencies == nil {
-				schema.Dependencies = make(map[string]*CombinedOrStringArray)
-			}
-			schema.Dependencies[block.Labels[0]] = combinedOrStringArray
+	for keyword, keys := range order {
+		schema.setKeyOrder(keyword, keys)
+	}
+	return schema
+}
 
-		case "definitions", "properties", "patternProperties":
-			combined, err := newCombinedFromBody(block.Bdy)
-			if err != nil {
-				return nil, err
+// objectSchema reads a schema written inline as an object expression.
+// Keywords that are blocks in a body are objects here; those taking a
+// label map the labels to their schemas.
+func (self *parser) objectSchema(expr *hclsyntax.ObjectConsExpr) *Schema {
+	schema := &Schema{}
+	order := make(map[string][]string)
+	for _, item := range expr.Items {
+		name, ok := self.objectKey(item.KeyExpr)
+		if !ok {
+			continue
+		}
+		if _, ok := schemaAttributes[name]; ok {
+			self.attribute(schema, name, item.KeyExpr.Range(), item.ValueExpr)
+			continue
+		}
+		kw, ok := schemaBlocks[name]
+		if !ok {
+			self.attribute(schema, name, item.KeyExpr.Range(), item.ValueExpr)
+			continue
+		}
+		obj, ok := item.ValueExpr.(*hclsyntax.ObjectConsExpr)
+		if !ok {
+			self.invalid("Invalid value type", item.ValueExpr.Range(), "%s must be an object.", name)
+			continue
+		}
+		if kw.labels[1] == 0 || (kw.labels[0] == 0 && len(obj.Items) == 0) {
+			kw.set(schema, "", &keywordValue{combined: NewCombinedWithSchema(self.objectSchema(obj))})
+			continue
+		}
+		for _, sub := range obj.Items {
+			label, ok := self.objectKey(sub.KeyExpr)
+			if !ok {
+				continue
 			}
-			switch block.Type {
-			case "definitions":
-				if schema.Definitions == nil {
-					schema.Definitions = make(map[string]*Combined)
-				}
-				schema.Definitions[block.Labels[0]] = combined
-			case "patternProperties":
-				if schema.PatternProperties == nil {
-					schema.PatternProperties = make(map[string]*Combined)
-				}
-				schema.PatternProperties[block.Labels[0]] = combined
-			case "properties":
-				if len(block.Labels) == 0 {
-					nullProps++
-				} else {
-					props[block.Labels[0]] = combined
-				}
+			v := &keywordValue{}
+			if kw.kind == kindDependency {
+				v.dependency = self.dependency(name, sub.ValueExpr)
+			} else if x, ok := sub.ValueExpr.(*hclsyntax.ObjectConsExpr); ok {
+				v.combined = NewCombinedWithSchema(self.objectSchema(x))
+			} else {
+				self.invalid("Invalid value type", sub.ValueExpr.Range(), "Each %s entry must be a schema object.", name)
+				continue
 			}
-
-		default:
-			// ignore
+			if v.dependency == nil && v.combined == nil {
+				continue
+			}
+			kw.set(schema, label, v)
+			order[name] = append(order[name], label)
 		}
 	}
 
-	if len(combs) > 1 {
-		schema.Items = NewCombinedOrCombinedArrayWithCombinedArray(combs)
-	} else if len(combs) == 1 {
-		schema.Items = NewCombinedOrCombinedArrayWithCombined(combs[0])
+	for keyword, keys := range order {
+		schema.setKeyOrder(keyword, keys)
 	}
+	return schema
+}
 
-	if len(props) > 0 {
-		schema.Properties = props
-	} else if nullProps > 0 {
-		schema.Properties = map[string]*Combined{}
+func (self *parser) objectKey(expr hclsyntax.Expression) (string, bool) {
+	key, diags := expr.Value(nil)
+	if diags.HasErrors() || key.IsNull() || key.Type() != cty.String {
+		self.invalid("Invalid keyword", expr.Range(), "Schema keywords must be names or strings.")
+		return "", false
 	}
-
-	return schema, nil
+	return key.AsString(), true
 }
 
-func exprToJSONRaw(expr *light.Expression) *json.RawMessage {
-	switch expr.ExpressionClause.(type) {
-	case *light.Expression_Lvexpr:
-		i := light.LiteralValueExprToInterface(expr)
-		raw := json.RawMessage([]byte(fmt.Sprintf("%v", i)))
-		return &raw
-	case *light.Expression_Texpr:
-		str := *light.TextValueExprToString(expr)
-		raw := json.RawMessage([]byte(`"` + str + `"`))
-		return &raw
-	default:
+// attribute reads the attribute name of a schema. Unknown attributes are
+// reported, and kept in Extensions if their values are constant.
+func (self *parser) attribute(schema *Schema, name string, rng hcl.Range, expr hclsyntax.Expression) {
+	kw, ok := schemaAttributes[name]
+	if !ok {
+		self.extension(schema, name, rng, expr)
+		return
+	}
+	if v := self.value(name, expr, kw.kind); v != nil {
+		kw.set(schema, "", v)
 	}
-	return nil
 }
 
-func exprToIntegerOrFloat(expr *light.Expression, typ *StringOrStringArray) *IntegerOrFloat {
-	if typ != nil && typ.String != nil {
-		if *typ.String == "integer" {
-			i64 := light.LiteralValueExprToInt64(expr)
-			return NewIntegerOrFloatWithInteger(*i64)
-		} else {
-			i64 := light.LiteralValueExprToInt64(expr)
-			if i64 != nil {
-				return NewIntegerOrFloatWithInteger(*i64)
-			}
-			f64 := light.LiteralValueExprToFloat64(expr)
-			return NewIntegerOrFloatWithFloat(*f64)
+func (self *parser) extension(schema *Schema, name string, rng hcl.Range, expr hclsyntax.Expression) {
+	vendor := strings.HasPrefix(name, "x-")
+	if !vendor {
+		self.unknown("attribute", name, rng)
+	}
+	val, diags := expr.Value(nil)
+	if diags.HasErrors() {
+		// vendor extensions are kept as they are
+		if vendor {
+			self.invalid("Invalid value", expr.Range(), "%s must be a constant value.", name)
 		}
+		return
 	}
-	return nil
+	raw, err := ctyToRaw(val)
+	if err != nil {
+		self.invalid("Invalid value", expr.Range(), "%s: %s.", name, err)
+		return
+	}
+	if name[0] == '_' {
+		name = "$" + name[1:]
+	}
+	if schema.Extensions == nil {
+		schema.Extensions = make(map[string]json.RawMessage)
+	}
+	schema.Extensions[name] = *raw
 }
 
-func newCombinedFromBody(body *light.Body) (*Combined, error) {
-	if body == nil {
-		return nil, nil
-	}
+// value decodes expr as a keyword value of kind, reporting a diagnostic
+// and returning nil if it is not one.
+func (self *parser) value(name string, expr hclsyntax.Expression, kind keywordKind) *keywordValue {
+	rng := expr.Range()
 
-	schema, err := parseSchemaFromBody(body)
-	if err != nil {
-		return nil, err
+	if kind == kindCombined {
+		c := self.combined(name, expr)
+		if c == nil {
+			return nil
+		}
+		return &keywordValue{combined: c}
 	}
-	return NewCombinedWithSchema(schema), nil
-}
 
-func exprToStringOrStringArray(expr *light.Expression) *StringOrStringArray {
-	if expr == nil {
+	val, diags := expr.Value(nil)
+	if diags.HasErrors() {
+		self.invalid("Invalid value", rng, "%s must be a constant value.", name)
+		return nil
+	}
+	if val.IsNull() && kind != kindAny {
+		self.invalid("Invalid value type", rng, "%s must not be null.", name)
 		return nil
 	}
 
-	switch expr.ExpressionClause.(type) {
-	case *light.Expression_Texpr:
-		return &StringOrStringArray{
-			String: light.TextValueExprToString(expr),
+	typ := val.Type()
+	switch kind {
+	case kindString:
+		if typ != cty.String {
+			self.invalid("Invalid value type", rng, "%s must be a string.", name)
+			return nil
+		}
+		s := val.AsString()
+		return &keywordValue{str: &s}
+	case kindBool:
+		if typ != cty.Bool {
+			self.invalid("Invalid value type", rng, "%s must be a boolean.", name)
+			return nil
+		}
+		b := val.True()
+		return &keywordValue{boolean: &b}
+	case kindNumber:
+		if typ != cty.Number {
+			self.invalid("Invalid value type", rng, "%s must be a number.", name)
+			return nil
+		}
+		return &keywordValue{number: ctyToNumber(val)}
+	case kindNonNegativeInteger:
+		if typ == cty.Number {
+			if i, acc := val.AsBigFloat().Int64(); acc == big.Exact && i >= 0 {
+				return &keywordValue{integer: &i}
+			}
+		}
+		self.invalid("Invalid value type", rng, "%s must be a non-negative integer.", name)
+	case kindType:
+		if typ == cty.String {
+			s := val.AsString()
+			return &keywordValue{typ: &StringOrStringArray{String: &s}}
+		}
+		if arr, ok := stringSequence(val); ok {
+			return &keywordValue{typ: &StringOrStringArray{StringArray: &arr}}
+		}
+		self.invalid("Invalid value type", rng, "%s must be a string or a list of strings.", name)
+	case kindStringArray:
+		if arr, ok := stringSequence(val); ok {
+			return &keywordValue{strs: arr}
 		}
+		self.invalid("Invalid value type", rng, "%s must be a list of strings.", name)
+	case kindEnum:
+		return self.enum(name, expr, val)
+	case kindAny:
+		raw, err := ctyToRaw(val)
+		if err != nil {
+			self.invalid("Invalid value", rng, "%s: %s.", name, err)
+			return nil
+		}
+		return &keywordValue{raw: raw}
 	default:
 	}
-	x := light.TupleConsExprToStringArray(expr)
-	return &StringOrStringArray{
-		StringArray: &x,
-	}
+	return nil
 }
 
-func tupleConsExprToEnum(expr *light.Expression) ([]SchemaEnumValue, error) {
-	t := expr.GetTcexpr()
-	if t == nil {
-		return nil, nil
-	}
-
-	exprs := t.Exprs
-	if len(exprs) == 0 {
-		return nil, nil
+// enum decodes the values of an enum list, reporting every value that is
+// not a string, a number, a boolean or null at its own range.
+func (self *parser) enum(name string, expr hclsyntax.Expression, val cty.Value) *keywordValue {
+	typ := val.Type()
+	if !typ.IsTupleType() && !typ.IsListType() {
+		self.invalid("Invalid value type", expr.Range(), "%s must be a list.", name)
+		return nil
 	}
-	var enums []SchemaEnumValue
-	for _, expr := range exprs {
-		switch expr.ExpressionClause.(type) {
-		case *light.Expression_Texpr:
-			enums = append(enums, SchemaEnumValue{String: light.TextValueExprToString(expr)})
-		case *light.Expression_Lvexpr:
-			enums = append(enums, SchemaEnumValue{Bool: light.LiteralValueExprToBoolean(expr)})
+	tuple, _ := expr.(*hclsyntax.TupleConsExpr)
+
+	enums := []SchemaEnumValue{}
+	ok := true
+	for i, it := 0, val.ElementIterator(); it.Next(); i++ {
+		_, v := it.Element()
+		switch {
+		case v.IsNull():
+			null := true
+			enums = append(enums, SchemaEnumValue{Null: &null})
+		case v.Type() == cty.String:
+			s := v.AsString()
+			enums = append(enums, SchemaEnumValue{String: &s})
+		case v.Type() == cty.Bool:
+			b := v.True()
+			enums = append(enums, SchemaEnumValue{Bool: &b})
+		case v.Type() == cty.Number:
+			enums = append(enums, SchemaEnumValue{Number: ctyToNumber(v)})
 		default:
+			rng := expr.Range()
+			if tuple != nil && i < len(tuple.Exprs) {
+				rng = tuple.Exprs[i].Range()
+			}
+			self.invalid("Invalid value type", rng, "%s values must be strings, numbers, booleans or null.", name)
+			ok = false
 		}
 	}
-	return enums, nil
+	if !ok {
+		return nil
+	}
+	return &keywordValue{enum: enums}
 }
 
-func bodyCombinedOrStringArray(b *light.Body) (*CombinedOrStringArray, error) {
-	if b == nil {
-		return nil, nil
+// combined reads a subschema written as an attribute: a boolean, an
+// inline object or a reference such as definitions.name.
+func (self *parser) combined(name string, expr hclsyntax.Expression) *Combined {
+	switch t := expr.(type) {
+	case *hclsyntax.ScopeTraversalExpr:
+		var parts []string
+		for _, step := range t.Traversal {
+			switch x := step.(type) {
+			case hcl.TraverseRoot:
+				parts = append(parts, x.Name)
+			case hcl.TraverseAttr:
+				parts = append(parts, x.Name)
+			default:
+			}
+		}
+		ref := "#/" + strings.Join(parts, "/")
+		return NewCombinedWithSchema(&Schema{Ref: &ref})
+	case *hclsyntax.ObjectConsExpr:
+		return NewCombinedWithSchema(self.objectSchema(t))
+	case *hclsyntax.LiteralValueExpr:
+		if t.Val.Type() == cty.Bool {
+			return NewCombinedWithBoolean(t.Val.True())
+		}
+	default:
 	}
+	self.invalid("Invalid value type", expr.Range(), "%s must be a boolean, a schema object or a reference.", name)
+	return nil
+}
 
-	for _, v := range b.Attributes {
-		// label is "" if body is attribute only
-		if v.Expr.GetTcexpr() != nil {
-			stringArray := light.TupleConsExprToStringArray(v.Expr)
-			return NewCombinedOrStringArrayWithStringArray(stringArray), nil
-		} else {
-			c, err := expressionToCombined(v.Expr)
-			if err != nil {
-				return nil, err
+// dependencyBody reads a dependencies block. A lone required list is the
+// array form; an empty block is true and a block holding only an empty
+// not is false, as marshalCombinedBody writes them; anything else is a
+// schema.
+func (self *parser) dependencyBody(body *hclsyntax.Body) *CombinedOrStringArray {
+	if attr, ok := body.Attributes["required"]; ok && len(body.Attributes) == 1 && len(body.Blocks) == 0 {
+		if _, ok := attr.Expr.(*hclsyntax.TupleConsExpr); ok {
+			if v := self.value("required", attr.Expr, kindStringArray); v != nil {
+				return NewCombinedOrStringArrayWithStringArray(v.strs)
 			}
-			return NewCombinedOrStringArrayWithCombined(c), nil
+			return nil
+		}
+	}
+	if len(body.Attributes) == 0 && len(body.Blocks) == 0 {
+		return NewCombinedOrStringArrayWithCombined(NewCombinedWithBoolean(true))
+	}
+	if len(body.Attributes) == 0 && len(body.Blocks) == 1 && body.Blocks[0].Type == "not" && len(body.Blocks[0].Labels) == 0 {
+		if not := body.Blocks[0].Body; len(not.Attributes) == 0 && len(not.Blocks) == 0 {
+			return NewCombinedOrStringArrayWithCombined(NewCombinedWithBoolean(false))
 		}
 	}
+	return NewCombinedOrStringArrayWithCombined(NewCombinedWithSchema(self.schemaBody(body)))
+}
 
-	s, err := parseSchemaFromBody(b)
-	if err != nil {
-		return nil, err
+// dependency reads a dependency written inline: a list of property names,
+// or a subschema.
+func (self *parser) dependency(name string, expr hclsyntax.Expression) *CombinedOrStringArray {
+	if _, ok := expr.(*hclsyntax.TupleConsExpr); ok {
+		if v := self.value(name, expr, kindStringArray); v != nil {
+			return NewCombinedOrStringArrayWithStringArray(v.strs)
+		}
+		return nil
 	}
-	return NewCombinedOrStringArrayWithCombined(NewCombinedWithSchema(s)), nil
+	if c := self.combined(name, expr); c != nil {
+		return NewCombinedOrStringArrayWithCombined(c)
+	}
+	return nil
 }
 
-func expressionToSchema(expr *light.Expression) (*Schema, error) {
-	if expr == nil {
-		return nil, nil
+func stringSequence(val cty.Value) ([]string, bool) {
+	typ := val.Type()
+	if !typ.IsTupleType() && !typ.IsListType() {
+		return nil, false
 	}
+	arr := []string{}
+	for it := val.ElementIterator(); it.Next(); {
+		_, v := it.Element()
+		if v.IsNull() || v.Type() != cty.String {
+			return nil, false
+		}
+		arr = append(arr, v.AsString())
+	}
+	return arr, true
+}
 
-	switch expr.ExpressionClause.(type) {
-	case *light.Expression_Stexpr:
-		ref, err := expressionToReference(expr)
-		if err != nil {
-			return nil, err
-		}
-		return &Schema{
-			Ref: &ref,
-		}, nil
-	// case *light.Expression_Fcexpr:
-	//   return fcexprToSchema(expr.GetFcexpr())
-	case *light.Expression_Ocexpr:
-		body := expr.GetOcexpr().ToBody()
-		return parseSchemaFromBody(body)
-	default:
+// ctyToNumber keeps integral values as integers, like UnmarshalJSON.
+func ctyToNumber(val cty.Value) *IntegerOrFloat {
+	bf := val.AsBigFloat()
+	if i, acc := bf.Int64(); acc == big.Exact {
+		return NewIntegerOrFloatWithInteger(i)
 	}
+	f, _ := bf.Float64()
+	return NewIntegerOrFloatWithFloat(f)
+}
 
-	return nil, fmt.Errorf("not supported expression: %#v", expr)
+// ctyToRaw encodes a constant value as JSON. Numbers are written with all
+// their digits.
+func ctyToRaw(val cty.Value) (*json.RawMessage, error) {
+	bs, err := json.Marshal(ctyToInterface(val))
+	if err != nil {
+		return nil, err
+	}
+	raw := json.RawMessage(bs)
+	return &raw, nil
 }
 
-func expressionToCombined(expr *light.Expression) (*Combined, error) {
-	if expr == nil {
-		return nil, nil
+// bigFloatText writes f with the fewest digits that read back exactly, in
+// plain notation unless it is very large or very small.
+func bigFloatText(f *big.Float) string {
+	if i, acc := f.Int64(); acc == big.Exact {
+		return strconv.FormatInt(i, 10)
 	}
-	schemaOrBoolean := &Combined{}
-	switch expr.ExpressionClause.(type) {
-	case *light.Expression_Lvexpr:
-		v := light.LiteralValueExprToBoolean(expr)
-		schemaOrBoolean.Boolean = v
-	default:
-		schema, err := expressionToSchema(expr)
-		if err != nil {
-			return nil, err
+	abs := new(big.Float).Abs(f)
+	if abs.Cmp(big.NewFloat(1e21)) < 0 && abs.Cmp(big.NewFloat(1e-6)) >= 0 {
+		return f.Text('f', -1)
+	}
+	// drop the sign and padding of the exponent, as in 1e-7 and 1e300
+	text := f.Text('g', -1)
+	if i := strings.IndexByte(text, 'e'); i >= 0 {
+		if exp, err := strconv.Atoi(text[i+1:]); err == nil {
+			text = text[:i+1] + strconv.Itoa(exp)
 		}
-		schemaOrBoolean.Schema = schema
 	}
-	return schemaOrBoolean, nil
+	return text
 }
 
-func expressionToReference(expr *light.Expression) (string, error) {
-	// in case there is only one level of reference which is parsed as lvexpr
-	if x := expr.GetLvexpr(); x != nil {
-		return "#/" + x.Val.GetStringValue(), nil
-	} else if x := light.TraversalToString(expr); x != nil {
-		return "#/" + *x, nil
+func ctyToInterface(val cty.Value) interface{} {
+	if val.IsNull() {
+		return nil
+	}
+	typ := val.Type()
+	switch {
+	case typ == cty.String:
+		return val.AsString()
+	case typ == cty.Bool:
+		return val.True()
+	case typ == cty.Number:
+		return json.Number(bigFloatText(val.AsBigFloat()))
+	case typ.IsObjectType() || typ.IsMapType():
+		obj := make(map[string]interface{})
+		for it := val.ElementIterator(); it.Next(); {
+			k, v := it.Element()
+			obj[k.AsString()] = ctyToInterface(v)
+		}
+		return obj
+	default:
+	}
+	arr := []interface{}{}
+	for it := val.ElementIterator(); it.Next(); {
+		_, v := it.Element()
+		arr = append(arr, ctyToInterface(v))
 	}
-	return "", fmt.Errorf("1 invalid expression: %#v", expr)
+	return arr
 }
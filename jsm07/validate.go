@@ -0,0 +1,637 @@
+package jsm07
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// ValidationError describes one keyword that an instance fails.
+// InstancePath is a JSON Pointer to the failing value inside the instance.
+type ValidationError struct {
+	InstancePath string
+	Keyword      string
+	Message      string
+}
+
+func (self *ValidationError) Error() string {
+	path := self.InstancePath
+	if path == "" {
+		path = "/"
+	}
+	return fmt.Sprintf("%s: %s: %s", path, self.Keyword, self.Message)
+}
+
+// ValidationErrors collects every failure found in one validation run.
+type ValidationErrors []*ValidationError
+
+func (self ValidationErrors) Error() string {
+	var arr []string
+	for _, e := range self {
+		arr = append(arr, e.Error())
+	}
+	return strings.Join(arr, "; ")
+}
+
+// Validate checks a decoded JSON value, as produced by json.Unmarshal into
+// an interface{}, against the schema. It returns nil if the instance is
+// valid, or ValidationErrors listing every failing keyword.
+//
+// References are resolved within self only; use a Resolver with a Loader
+// to follow references into other documents. As draft-07 specifies,
+// keywords next to $ref are ignored. The format keyword is treated as an
+// annotation and is not asserted.
+func (self *Schema) Validate(instance interface{}) error {
+	r, err := NewResolver(self, "", nil)
+	if err != nil {
+		return err
+	}
+	return r.Validate(instance)
+}
+
+// Validate checks instance against a boolean or schema value.
+func (self *Combined) Validate(instance interface{}) error {
+	if self.Schema != nil {
+		return self.Schema.Validate(instance)
+	}
+	v := newValidator(nil)
+	errs := v.validateCombined(self, instance, "")
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+// Validate checks instance against the root document, following references
+// into other documents through the resolver.
+func (self *Resolver) Validate(instance interface{}) error {
+	v := newValidator(self)
+	errs := v.validateSchema(self.root, instance, "")
+	if len(errs) == 0 {
+		return nil
+	}
+	return errs
+}
+
+type validator struct {
+	resolver *Resolver
+	patterns map[string]*regexp.Regexp
+	// active holds the references being followed at each instance path,
+	// to stop a reference cycle that consumes no input
+	active map[string]bool
+}
+
+func newValidator(resolver *Resolver) *validator {
+	return &validator{
+		resolver: resolver,
+		patterns: make(map[string]*regexp.Regexp),
+		active:   make(map[string]bool),
+	}
+}
+
+func (self *validator) regexp(pattern string) (*regexp.Regexp, error) {
+	if re, ok := self.patterns[pattern]; ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	self.patterns[pattern] = re
+	return re, nil
+}
+
+func newValidationErrors(path, keyword, format string, args ...interface{}) ValidationErrors {
+	return ValidationErrors{&ValidationError{
+		InstancePath: path,
+		Keyword:      keyword,
+		Message:      fmt.Sprintf(format, args...),
+	}}
+}
+
+func (self *validator) validateCombined(c *Combined, instance interface{}, path string) ValidationErrors {
+	if c == nil {
+		return nil
+	}
+	if c.Boolean != nil {
+		if *c.Boolean {
+			return nil
+		}
+		return newValidationErrors(path, "false", "no value is allowed")
+	}
+	return self.validateSchema(c.Schema, instance, path)
+}
+
+func (self *validator) validateSchema(s *Schema, instance interface{}, path string) ValidationErrors {
+	if s == nil {
+		return nil
+	}
+	if s.Ref != nil {
+		return self.validateRef(s, instance, path)
+	}
+
+	var errs ValidationErrors
+	errs = append(errs, self.validateType(s, instance, path)...)
+	errs = append(errs, self.validateCommon(s, instance, path)...)
+
+	switch t := instance.(type) {
+	case string:
+		errs = append(errs, self.validateString(s, t, path)...)
+	case []interface{}:
+		errs = append(errs, self.validateArray(s, t, path)...)
+	case map[string]interface{}:
+		errs = append(errs, self.validateObject(s, t, path)...)
+	default:
+		if x, ok := toRat(instance); ok {
+			errs = append(errs, self.validateNumber(s, x, path)...)
+		}
+	}
+
+	errs = append(errs, self.validateConditional(s, instance, path)...)
+	errs = append(errs, self.validateComposition(s, instance, path)...)
+	return errs
+}
+
+func (self *validator) validateRef(s *Schema, instance interface{}, path string) ValidationErrors {
+	ref := *s.Ref
+	if self.resolver == nil {
+		return newValidationErrors(path, "$ref", "no resolver for %q", ref)
+	}
+	base := self.resolver.BaseURI(s)
+	target, _, err := self.resolver.Resolve(base, ref)
+	if err != nil {
+		return newValidationErrors(path, "$ref", "%v", err)
+	}
+
+	key := base + "\x00" + ref + "\x00" + path
+	if self.active[key] {
+		return newValidationErrors(path, "$ref", "circular reference %q", ref)
+	}
+	self.active[key] = true
+	defer delete(self.active, key)
+
+	return self.validateCombined(target, instance, path)
+}
+
+func (self *validator) validateType(s *Schema, instance interface{}, path string) ValidationErrors {
+	if s.Type == nil {
+		return nil
+	}
+
+	var types []string
+	if s.Type.String != nil {
+		types = []string{*s.Type.String}
+	} else if s.Type.StringArray != nil {
+		types = *s.Type.StringArray
+	}
+	if len(types) == 0 {
+		return nil
+	}
+
+	for _, t := range types {
+		if isOfType(instance, t) {
+			return nil
+		}
+	}
+	return newValidationErrors(path, "type", "expected %s, got %s", strings.Join(types, " or "), typeOf(instance))
+}
+
+func (self *validator) validateCommon(s *Schema, instance interface{}, path string) ValidationErrors {
+	var errs ValidationErrors
+
+	if s.Enumeration != nil {
+		found := false
+		for _, e := range s.Enumeration {
+			if jsonEqual(enumToInterface(e), instance) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			errs = append(errs, newValidationErrors(path, "enum", "value is not one of the enumerated values")...)
+		}
+	}
+
+	if s.Const != nil {
+		var c interface{}
+		if err := json.Unmarshal(*s.Const, &c); err != nil {
+			errs = append(errs, newValidationErrors(path, "const", "invalid const in schema: %v", err)...)
+		} else if !jsonEqual(c, instance) {
+			errs = append(errs, newValidationErrors(path, "const", "value must be %s", string(*s.Const))...)
+		}
+	}
+
+	return errs
+}
+
+func (self *validator) validateNumber(s *Schema, x *big.Rat, path string) ValidationErrors {
+	var errs ValidationErrors
+
+	if s.MultipleOf != nil {
+		if m := integerOrFloatToRat(s.MultipleOf); m != nil && m.Sign() > 0 {
+			q := new(big.Rat).Quo(x, m)
+			if !q.IsInt() {
+				errs = append(errs, newValidationErrors(path, "multipleOf", "%s is not a multiple of %s", ratString(x), ratString(m))...)
+			}
+		}
+	}
+	if m := integerOrFloatToRat(s.Maximum); m != nil && x.Cmp(m) > 0 {
+		errs = append(errs, newValidationErrors(path, "maximum", "%s is greater than %s", ratString(x), ratString(m))...)
+	}
+	if m := integerOrFloatToRat(s.ExclusiveMaximum); m != nil && x.Cmp(m) >= 0 {
+		errs = append(errs, newValidationErrors(path, "exclusiveMaximum", "%s is not less than %s", ratString(x), ratString(m))...)
+	}
+	if m := integerOrFloatToRat(s.Minimum); m != nil && x.Cmp(m) < 0 {
+		errs = append(errs, newValidationErrors(path, "minimum", "%s is less than %s", ratString(x), ratString(m))...)
+	}
+	if m := integerOrFloatToRat(s.ExclusiveMinimum); m != nil && x.Cmp(m) <= 0 {
+		errs = append(errs, newValidationErrors(path, "exclusiveMinimum", "%s is not greater than %s", ratString(x), ratString(m))...)
+	}
+
+	return errs
+}
+
+func (self *validator) validateString(s *Schema, str string, path string) ValidationErrors {
+	var errs ValidationErrors
+
+	n := int64(utf8.RuneCountInString(str))
+	if s.MaxLength != nil && n > *s.MaxLength {
+		errs = append(errs, newValidationErrors(path, "maxLength", "length %d is greater than %d", n, *s.MaxLength)...)
+	}
+	if s.MinLength != nil && n < *s.MinLength {
+		errs = append(errs, newValidationErrors(path, "minLength", "length %d is less than %d", n, *s.MinLength)...)
+	}
+	if s.Pattern != nil {
+		re, err := self.regexp(*s.Pattern)
+		if err != nil {
+			errs = append(errs, newValidationErrors(path, "pattern", "invalid pattern %q in schema: %v", *s.Pattern, err)...)
+		} else if !re.MatchString(str) {
+			errs = append(errs, newValidationErrors(path, "pattern", "%q does not match %q", str, *s.Pattern)...)
+		}
+	}
+
+	return errs
+}
+
+func (self *validator) validateArray(s *Schema, arr []interface{}, path string) ValidationErrors {
+	var errs ValidationErrors
+
+	n := int64(len(arr))
+	if s.MaxItems != nil && n > *s.MaxItems {
+		errs = append(errs, newValidationErrors(path, "maxItems", "%d items is more than %d", n, *s.MaxItems)...)
+	}
+	if s.MinItems != nil && n < *s.MinItems {
+		errs = append(errs, newValidationErrors(path, "minItems", "%d items is fewer than %d", n, *s.MinItems)...)
+	}
+	if s.UniqueItems != nil && *s.UniqueItems {
+	OUTER:
+		for i := 0; i < len(arr); i++ {
+			for j := i + 1; j < len(arr); j++ {
+				if jsonEqual(arr[i], arr[j]) {
+					errs = append(errs, newValidationErrors(path, "uniqueItems", "items %d and %d are equal", i, j)...)
+					break OUTER
+				}
+			}
+		}
+	}
+
+	if s.Items != nil {
+		if s.Items.Combined != nil {
+			for i, item := range arr {
+				errs = append(errs, self.validateCombined(s.Items.Combined, item, pointerAppend(path, strconv.Itoa(i)))...)
+			}
+		} else if s.Items.CombinedArray != nil {
+			items := *s.Items.CombinedArray
+			for i, item := range arr {
+				if i < len(items) {
+					errs = append(errs, self.validateCombined(items[i], item, pointerAppend(path, strconv.Itoa(i)))...)
+				} else if s.AdditionalItems != nil {
+					errs = append(errs, self.validateCombined(s.AdditionalItems, item, pointerAppend(path, strconv.Itoa(i)))...)
+				}
+			}
+		}
+	}
+
+	if s.Contains != nil {
+		found := false
+		for _, item := range arr {
+			if len(self.validateCombined(s.Contains, item, path)) == 0 {
+				found = true
+				break
+			}
+		}
+		if !found {
+			errs = append(errs, newValidationErrors(path, "contains", "no item matches the contains schema")...)
+		}
+	}
+
+	return errs
+}
+
+func (self *validator) validateObject(s *Schema, obj map[string]interface{}, path string) ValidationErrors {
+	var errs ValidationErrors
+
+	n := int64(len(obj))
+	if s.MaxProperties != nil && n > *s.MaxProperties {
+		errs = append(errs, newValidationErrors(path, "maxProperties", "%d properties is more than %d", n, *s.MaxProperties)...)
+	}
+	if s.MinProperties != nil && n < *s.MinProperties {
+		errs = append(errs, newValidationErrors(path, "minProperties", "%d properties is fewer than %d", n, *s.MinProperties)...)
+	}
+	for _, name := range s.Required {
+		if _, ok := obj[name]; !ok {
+			errs = append(errs, newValidationErrors(path, "required", "missing property %q", name)...)
+		}
+	}
+
+	// iterate in sorted order so that errors are reported deterministically
+	keys := make([]string, 0, len(obj))
+	for k := range obj {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		v := obj[k]
+		kpath := pointerAppend(path, k)
+
+		if s.PropertyNames != nil {
+			for _, e := range self.validateCombined(s.PropertyNames, k, path) {
+				e.Message = fmt.Sprintf("property name %q: %s", k, e.Message)
+				errs = append(errs, e)
+			}
+		}
+
+		matched := false
+		if c, ok := s.Properties[k]; ok {
+			matched = true
+			errs = append(errs, self.validateCombined(c, v, kpath)...)
+		}
+		for pattern, c := range s.PatternProperties {
+			re, err := self.regexp(pattern)
+			if err != nil {
+				errs = append(errs, newValidationErrors(path, "patternProperties", "invalid pattern %q in schema: %v", pattern, err)...)
+				continue
+			}
+			if re.MatchString(k) {
+				matched = true
+				errs = append(errs, self.validateCombined(c, v, kpath)...)
+			}
+		}
+		if !matched && s.AdditionalProperties != nil {
+			if b := s.AdditionalProperties.Boolean; b != nil && !*b {
+				errs = append(errs, newValidationErrors(path, "additionalProperties", "property %q is not allowed", k)...)
+			} else {
+				errs = append(errs, self.validateCombined(s.AdditionalProperties, v, kpath)...)
+			}
+		}
+
+		if dep, ok := s.Dependencies[k]; ok && dep != nil {
+			if dep.StringArray != nil {
+				for _, name := range *dep.StringArray {
+					if _, ok := obj[name]; !ok {
+						errs = append(errs, newValidationErrors(path, "dependencies", "property %q requires property %q", k, name)...)
+					}
+				}
+			} else if dep.Combined != nil {
+				errs = append(errs, self.validateCombined(dep.Combined, obj, path)...)
+			}
+		}
+	}
+
+	return errs
+}
+
+func (self *validator) validateConditional(s *Schema, instance interface{}, path string) ValidationErrors {
+	if s.If == nil {
+		return nil
+	}
+	if len(self.validateCombined(s.If, instance, path)) == 0 {
+		return self.validateCombined(s.Then, instance, path)
+	}
+	return self.validateCombined(s.Else, instance, path)
+}
+
+func (self *validator) validateComposition(s *Schema, instance interface{}, path string) ValidationErrors {
+	var errs ValidationErrors
+
+	for _, c := range s.AllOf {
+		errs = append(errs, self.validateCombined(c, instance, path)...)
+	}
+
+	if len(s.AnyOf) > 0 {
+		found := false
+		for _, c := range s.AnyOf {
+			if len(self.validateCombined(c, instance, path)) == 0 {
+				found = true
+				break
+			}
+		}
+		if !found {
+			errs = append(errs, newValidationErrors(path, "anyOf", "value does not match any of %d schemas", len(s.AnyOf))...)
+		}
+	}
+
+	if len(s.OneOf) > 0 {
+		var matches []int
+		for i, c := range s.OneOf {
+			if len(self.validateCombined(c, instance, path)) == 0 {
+				matches = append(matches, i)
+			}
+		}
+		switch len(matches) {
+		case 1:
+		case 0:
+			errs = append(errs, newValidationErrors(path, "oneOf", "value does not match any of %d schemas", len(s.OneOf))...)
+		default:
+			errs = append(errs, newValidationErrors(path, "oneOf", "value matches schemas %v, but exactly one is allowed", matches)...)
+		}
+	}
+
+	if s.Not != nil && len(self.validateCombined(s.Not, instance, path)) == 0 {
+		errs = append(errs, newValidationErrors(path, "not", "value must not match the schema")...)
+	}
+
+	return errs
+}
+
+func isOfType(instance interface{}, t string) bool {
+	switch t {
+	case "null":
+		return instance == nil
+	case "boolean":
+		_, ok := instance.(bool)
+		return ok
+	case "string":
+		_, ok := instance.(string)
+		return ok
+	case "array":
+		_, ok := instance.([]interface{})
+		return ok
+	case "object":
+		_, ok := instance.(map[string]interface{})
+		return ok
+	case "number":
+		_, ok := toRat(instance)
+		return ok
+	case "integer":
+		x, ok := toRat(instance)
+		return ok && x.IsInt()
+	default:
+	}
+	return false
+}
+
+func typeOf(instance interface{}) string {
+	switch instance.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	default:
+	}
+	if x, ok := toRat(instance); ok {
+		if x.IsInt() {
+			return "integer"
+		}
+		return "number"
+	}
+	return fmt.Sprintf("%T", instance)
+}
+
+// toRat converts any Go numeric value, or a json.Number, to an exact rational.
+func toRat(v interface{}) (*big.Rat, bool) {
+	switch t := v.(type) {
+	case float64:
+		if math.IsNaN(t) || math.IsInf(t, 0) {
+			return nil, false
+		}
+		// go through the shortest decimal form so that 0.1 means 1/10
+		return new(big.Rat).SetString(strconv.FormatFloat(t, 'g', -1, 64))
+	case float32:
+		return toRat(float64(t))
+	case int:
+		return new(big.Rat).SetInt64(int64(t)), true
+	case int8:
+		return new(big.Rat).SetInt64(int64(t)), true
+	case int16:
+		return new(big.Rat).SetInt64(int64(t)), true
+	case int32:
+		return new(big.Rat).SetInt64(int64(t)), true
+	case int64:
+		return new(big.Rat).SetInt64(t), true
+	case uint:
+		return new(big.Rat).SetUint64(uint64(t)), true
+	case uint8:
+		return new(big.Rat).SetUint64(uint64(t)), true
+	case uint16:
+		return new(big.Rat).SetUint64(uint64(t)), true
+	case uint32:
+		return new(big.Rat).SetUint64(uint64(t)), true
+	case uint64:
+		return new(big.Rat).SetUint64(t), true
+	case json.Number:
+		return new(big.Rat).SetString(string(t))
+	default:
+	}
+	return nil, false
+}
+
+func integerOrFloatToRat(v *IntegerOrFloat) *big.Rat {
+	if v == nil {
+		return nil
+	}
+	if v.Integer != nil {
+		return new(big.Rat).SetInt64(*v.Integer)
+	}
+	if v.Float != nil {
+		if x, ok := toRat(*v.Float); ok {
+			return x
+		}
+	}
+	return nil
+}
+
+func ratString(x *big.Rat) string {
+	if x.IsInt() {
+		return x.Num().String()
+	}
+	f, _ := x.Float64()
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
+
+func enumToInterface(e SchemaEnumValue) interface{} {
+	switch {
+	case e.Null != nil && *e.Null:
+		return nil
+	case e.String != nil:
+		return *e.String
+	case e.Bool != nil:
+		return *e.Bool
+	case e.Number != nil:
+		if e.Number.Integer != nil {
+			return *e.Number.Integer
+		}
+		if e.Number.Float != nil {
+			return *e.Number.Float
+		}
+	default:
+	}
+	return nil
+}
+
+// jsonEqual compares two decoded JSON values, treating numbers by value.
+func jsonEqual(a, b interface{}) bool {
+	if x, ok := toRat(a); ok {
+		y, ok := toRat(b)
+		return ok && x.Cmp(y) == 0
+	}
+
+	switch t := a.(type) {
+	case nil:
+		return b == nil
+	case bool:
+		u, ok := b.(bool)
+		return ok && t == u
+	case string:
+		u, ok := b.(string)
+		return ok && t == u
+	case []interface{}:
+		u, ok := b.([]interface{})
+		if !ok || len(t) != len(u) {
+			return false
+		}
+		for i := range t {
+			if !jsonEqual(t[i], u[i]) {
+				return false
+			}
+		}
+		return true
+	case map[string]interface{}:
+		u, ok := b.(map[string]interface{})
+		if !ok || len(t) != len(u) {
+			return false
+		}
+		for k, v := range t {
+			w, ok := u[k]
+			if !ok || !jsonEqual(v, w) {
+				return false
+			}
+		}
+		return true
+	default:
+	}
+	return false
+}
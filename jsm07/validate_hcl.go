@@ -0,0 +1,202 @@
+package jsm07
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/genelet/hcllight/light"
+	"github.com/genelet/hclschema/internal/lightexpr"
+)
+
+// ValidateHCL parses an HCL configuration document and validates it
+// against the schema.
+//
+// The document is converted to a JSON-like instance guided by the schema:
+// attributes become property values; blocks become object properties
+// named after the block type, with block labels adding one level of
+// object nesting per label. When the schema of a block property is an
+// array (it declares type "array" or items), every block of that type
+// becomes one array item, so repeated blocks map to the items keyword.
+func (self *Schema) ValidateHCL(data []byte) error {
+	r, err := NewResolver(self, "", nil)
+	if err != nil {
+		return err
+	}
+	return r.ValidateHCL(data)
+}
+
+// ValidateHCL validates an HCL configuration document against the root
+// document, following references into other documents through the resolver.
+func (self *Resolver) ValidateHCL(data []byte) error {
+	body, err := light.ParseBody(data)
+	if err != nil {
+		return err
+	}
+	b := &instanceBuilder{resolver: self}
+	instance, err := b.bodyToInstance(body, NewCombinedWithSchema(self.root))
+	if err != nil {
+		return err
+	}
+	return self.Validate(instance)
+}
+
+// instanceBuilder converts HCL bodies to instances, resolving references
+// in the guiding schema through resolver.
+type instanceBuilder struct {
+	resolver *Resolver
+}
+
+// bodyToInstance converts an HCL body to a map of decoded JSON values,
+// using c to decide which blocks are repeated array items.
+func (self *instanceBuilder) bodyToInstance(body *light.Body, c *Combined) (map[string]interface{}, error) {
+	obj := make(map[string]interface{})
+	if body == nil {
+		return obj, nil
+	}
+
+	for k, v := range body.Attributes {
+		x, err := lightexpr.ToInterface(v.Expr)
+		if err != nil {
+			return nil, fmt.Errorf("attribute %s: %w", k, err)
+		}
+		obj[k] = x
+	}
+
+	for _, block := range body.Blocks {
+		if err := self.addBlockToInstance(obj, block.Type, block.Labels, block.Bdy, self.propertyCombined(c, block.Type)); err != nil {
+			return nil, err
+		}
+	}
+
+	return obj, nil
+}
+
+func (self *instanceBuilder) addBlockToInstance(obj map[string]interface{}, name string, labels []string, body *light.Body, c *Combined) error {
+	if len(labels) > 0 {
+		var child map[string]interface{}
+		switch t := obj[name].(type) {
+		case nil:
+			child = make(map[string]interface{})
+			obj[name] = child
+		case map[string]interface{}:
+			child = t
+		default:
+			return fmt.Errorf("block %s conflicts with an attribute or unlabeled block of the same name", name)
+		}
+		return self.addBlockToInstance(child, labels[0], labels[1:], body, self.propertyCombined(c, labels[0]))
+	}
+
+	c = self.deref(c)
+	if isArrayCombined(c) {
+		var arr []interface{}
+		switch t := obj[name].(type) {
+		case nil:
+		case []interface{}:
+			arr = t
+		default:
+			return fmt.Errorf("block %s conflicts with an attribute of the same name", name)
+		}
+		item, err := self.bodyToInstance(body, itemCombined(c, len(arr)))
+		if err != nil {
+			return err
+		}
+		obj[name] = append(arr, item)
+		return nil
+	}
+
+	item, err := self.bodyToInstance(body, c)
+	if err != nil {
+		return err
+	}
+	switch t := obj[name].(type) {
+	case nil:
+		obj[name] = item
+	case []interface{}:
+		// repeated blocks where the schema expects one: keep them all
+		// so that validation reports the type mismatch
+		obj[name] = append(t, item)
+	default:
+		obj[name] = []interface{}{t, item}
+	}
+	return nil
+}
+
+// deref follows references from c until it reaches a schema without $ref.
+// It returns nil for unresolvable or circular references.
+func (self *instanceBuilder) deref(c *Combined) *Combined {
+	seen := make(map[string]bool)
+	for c != nil && c.Schema != nil && c.Schema.Ref != nil {
+		base := self.resolver.BaseURI(c.Schema)
+		key := base + "\x00" + *c.Schema.Ref
+		if seen[key] {
+			return nil
+		}
+		seen[key] = true
+		target, _, err := self.resolver.Resolve(base, *c.Schema.Ref)
+		if err != nil {
+			return nil
+		}
+		c = target
+	}
+	return c
+}
+
+// propertyCombined returns the schema that applies to property name of an
+// object described by c, or nil if it is unconstrained.
+func (self *instanceBuilder) propertyCombined(c *Combined, name string) *Combined {
+	c = self.deref(c)
+	if c == nil || c.Schema == nil {
+		return nil
+	}
+	s := c.Schema
+	if p, ok := s.Properties[name]; ok {
+		return p
+	}
+	for pattern, p := range s.PatternProperties {
+		if re, err := regexp.Compile(pattern); err == nil && re.MatchString(name) {
+			return p
+		}
+	}
+	return s.AdditionalProperties
+}
+
+// itemCombined returns the schema of the i-th item of an array described by c.
+func itemCombined(c *Combined, i int) *Combined {
+	if c == nil || c.Schema == nil || c.Schema.Items == nil {
+		return nil
+	}
+	s := c.Schema
+	if s.Items.Combined != nil {
+		return s.Items.Combined
+	}
+	if s.Items.CombinedArray != nil {
+		if items := *s.Items.CombinedArray; i < len(items) {
+			return items[i]
+		}
+	}
+	return s.AdditionalItems
+}
+
+func isArrayCombined(c *Combined) bool {
+	if c == nil || c.Schema == nil {
+		return false
+	}
+	s := c.Schema
+	if s.Items != nil {
+		return true
+	}
+	if s.Type == nil {
+		return false
+	}
+	if s.Type.String != nil {
+		return *s.Type.String == "array"
+	}
+	if s.Type.StringArray != nil {
+		for _, t := range *s.Type.StringArray {
+			if t == "array" {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,124 @@
+package jsm07
+
+import (
+	"errors"
+	"testing"
+)
+
+var serverSchemaHCL = `
+  type = "object"
+  required = ["name", "listener"]
+  additionalProperties = false
+  properties "name" {
+    type = "string"
+    minLength = 1
+  }
+  properties "debug" {
+    type = "boolean"
+  }
+  properties "tags" {
+    type = "array"
+    items {
+      type = "string"
+    }
+  }
+  properties "listener" {
+    type = "array"
+    minItems = 1
+    items {
+      type = "object"
+      required = ["port"]
+      properties "port" {
+        type = "integer"
+        minimum = 1
+        maximum = 65535
+      }
+    }
+  }
+  properties "upstream" {
+    type = "object"
+    additionalProperties {
+      type = "object"
+      required = ["url"]
+      properties "url" {
+        type = "string"
+        pattern = "^https?://"
+      }
+    }
+  }
+`
+
+func TestSchemaValidateHCL(t *testing.T) {
+	schema, err := ParseSchema([]byte(serverSchemaHCL))
+	if err != nil {
+		t.Fatalf("Failed to parse schema: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		config   string
+		keywords []string
+	}{
+		{
+			name: "valid",
+			config: `
+name = "web"
+debug = false
+tags = ["a", "b"]
+listener {
+  port = 80
+}
+listener {
+  port = 443
+}
+upstream "api" {
+  url = "https://api.example.com"
+}
+`,
+		},
+		{
+			name: "invalid",
+			config: `
+name = "web"
+extra = 1
+listener {
+  port = 70000
+}
+upstream "api" {
+  url = "ftp://api.example.com"
+}
+`,
+			keywords: []string{"additionalProperties", "maximum", "pattern"},
+		},
+		{
+			name:     "missing",
+			config:   `name = ""`,
+			keywords: []string{"required", "minLength"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := schema.ValidateHCL([]byte(tt.config))
+			if len(tt.keywords) == 0 {
+				if err != nil {
+					t.Fatalf("Expected valid config, got %v", err)
+				}
+				return
+			}
+
+			var errs ValidationErrors
+			if !errors.As(err, &errs) {
+				t.Fatalf("Expected ValidationErrors, got %v", err)
+			}
+			if len(errs) != len(tt.keywords) {
+				t.Fatalf("Expected %d errors, got %v", len(tt.keywords), errs)
+			}
+			for i, e := range errs {
+				if e.Keyword != tt.keywords[i] {
+					t.Errorf("Expected keyword %q, got %q", tt.keywords[i], e.Keyword)
+				}
+			}
+		})
+	}
+}
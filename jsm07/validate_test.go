@@ -0,0 +1,98 @@
+package jsm07
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+)
+
+func TestSchemaValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		schema   string
+		instance string
+		keywords []string
+	}{
+		{"type ok", `{"type": "string"}`, `"abc"`, nil},
+		{"type bad", `{"type": "string"}`, `12`, []string{"type"}},
+		{"type array", `{"type": ["string", "null"]}`, `null`, nil},
+		{"integer", `{"type": "integer"}`, `1.0`, nil},
+		{"not integer", `{"type": "integer"}`, `1.5`, []string{"type"}},
+		{"enum", `{"enum": ["a", 1, null]}`, `1`, nil},
+		{"enum bad", `{"enum": ["a", 1, null]}`, `"b"`, []string{"enum"}},
+		{"const", `{"const": {"a": [1, 2]}}`, `{"a": [1, 2]}`, nil},
+		{"const bad", `{"const": {"a": [1, 2]}}`, `{"a": [2, 1]}`, []string{"const"}},
+		{"minimum", `{"type": "number", "minimum": 0, "maximum": 1}`, `1.5`, []string{"maximum"}},
+		{"exclusive", `{"exclusiveMinimum": 0, "exclusiveMaximum": 1}`, `0`, []string{"exclusiveMinimum"}},
+		{"multipleOf", `{"multipleOf": 0.1}`, `0.3`, nil},
+		{"multipleOf bad", `{"multipleOf": 2}`, `3`, []string{"multipleOf"}},
+		{"string", `{"minLength": 2, "maxLength": 3, "pattern": "^a"}`, `"bcde"`, []string{"maxLength", "pattern"}},
+		{"items", `{"items": {"type": "integer"}}`, `[1, "a"]`, []string{"type"}},
+		{"tuple", `{"items": [{"type": "integer"}], "additionalItems": false}`, `[1, 2]`, []string{"false"}},
+		{"array bounds", `{"minItems": 3, "uniqueItems": true}`, `[1, 1]`, []string{"minItems", "uniqueItems"}},
+		{"contains", `{"contains": {"const": 3}}`, `[1, 2]`, []string{"contains"}},
+		{"required", `{"required": ["a", "b"]}`, `{"a": 1}`, []string{"required"}},
+		{"additionalProperties", `{"properties": {"a": {}}, "patternProperties": {"^x-": {}}, "additionalProperties": false}`, `{"a": 1, "x-b": 2, "c": 3}`, []string{"additionalProperties"}},
+		{"propertyNames", `{"propertyNames": {"maxLength": 2}}`, `{"abc": 1}`, []string{"maxLength"}},
+		{"dependencies", `{"dependencies": {"a": ["b"], "c": {"required": ["d"]}}}`, `{"a": 1, "c": 2}`, []string{"dependencies", "required"}},
+		{"if then", `{"if": {"properties": {"a": {"const": 1}}}, "then": {"required": ["b"]}, "else": {"required": ["c"]}}`, `{"a": 1}`, []string{"required"}},
+		{"if else", `{"if": {"properties": {"a": {"const": 1}}}, "then": {"required": ["b"]}, "else": {"required": ["c"]}}`, `{"a": 2, "c": 0}`, nil},
+		{"allOf", `{"allOf": [{"type": "integer"}, {"minimum": 5}]}`, `3`, []string{"minimum"}},
+		{"anyOf", `{"anyOf": [{"type": "string"}, {"type": "null"}]}`, `1`, []string{"anyOf"}},
+		{"oneOf", `{"oneOf": [{"type": "integer"}, {"minimum": 0}]}`, `1`, []string{"oneOf"}},
+		{"not", `{"not": {"type": "null"}}`, `null`, []string{"not"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := new(Schema)
+			if err := json.Unmarshal([]byte(tt.schema), s); err != nil {
+				t.Fatalf("Failed to unmarshal schema: %v", err)
+			}
+			var instance interface{}
+			if err := json.Unmarshal([]byte(tt.instance), &instance); err != nil {
+				t.Fatalf("Failed to unmarshal instance: %v", err)
+			}
+
+			err := s.Validate(instance)
+			if len(tt.keywords) == 0 {
+				if err != nil {
+					t.Fatalf("Expected valid instance, got %v", err)
+				}
+				return
+			}
+
+			var errs ValidationErrors
+			if !errors.As(err, &errs) {
+				t.Fatalf("Expected ValidationErrors, got %v", err)
+			}
+			if len(errs) != len(tt.keywords) {
+				t.Fatalf("Expected %d errors, got %v", len(tt.keywords), errs)
+			}
+			for i, e := range errs {
+				if e.Keyword != tt.keywords[i] {
+					t.Errorf("Expected keyword %q, got %q", tt.keywords[i], e.Keyword)
+				}
+			}
+		})
+	}
+}
+
+func TestSchemaValidateInstancePath(t *testing.T) {
+	s := new(Schema)
+	if err := json.Unmarshal([]byte(`{"properties": {"a/b": {"items": {"type": "string"}}}}`), s); err != nil {
+		t.Fatalf("Failed to unmarshal schema: %v", err)
+	}
+	var instance interface{}
+	if err := json.Unmarshal([]byte(`{"a/b": ["x", 1]}`), &instance); err != nil {
+		t.Fatalf("Failed to unmarshal instance: %v", err)
+	}
+
+	var errs ValidationErrors
+	if !errors.As(s.Validate(instance), &errs) || len(errs) != 1 {
+		t.Fatalf("Expected one error, got %v", errs)
+	}
+	if errs[0].InstancePath != "/a~1b/1" {
+		t.Errorf("Expected path /a~1b/1, got %s", errs[0].InstancePath)
+	}
+}
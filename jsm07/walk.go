@@ -0,0 +1,132 @@
+package jsm07
+
+import (
+	"errors"
+	"fmt"
+)
+
+// SkipSubschemas, returned by the function passed to Walk, skips the
+// subschemas of the current schema. Walk itself does not return it.
+var SkipSubschemas = errors.New("skip subschemas")
+
+// Walk calls fn on the schema and on every subschema reachable from it,
+// parents before their subschemas, with the JSON Pointer of each. The
+// subschemas are visited in document order: properties,
+// patternProperties, additionalProperties, propertyNames, dependencies,
+// items, additionalItems, contains, if, then, else, allOf, anyOf, oneOf,
+// not and definitions. Boolean subschemas are not visited, and $ref is
+// not followed.
+//
+// Walk stops at the first error from fn and returns it, unless it is
+// SkipSubschemas.
+func Walk(schema *Schema, fn func(ptr JSONPointer, s *Schema) error) error {
+	return walkSchema("", schema, fn)
+}
+
+func walkSchema(ptr JSONPointer, s *Schema, fn func(ptr JSONPointer, s *Schema) error) error {
+	if s == nil {
+		return nil
+	}
+	if err := fn(ptr, s); err != nil {
+		if err == SkipSubschemas {
+			return nil
+		}
+		return err
+	}
+	var err error
+	forEachChild(s, func(tokens []string, c *Combined) bool {
+		p := ptr
+		for _, t := range tokens {
+			p = p.Append(t)
+		}
+		err = walkSchema(p, c.Schema, fn)
+		return err == nil
+	})
+	return err
+}
+
+// Transform returns a copy of the schema in which every subschema, true
+// and false included, is replaced by what fn returns for it. Subschemas
+// are transformed before their parents, so fn sees them already
+// replaced; the schema fn receives is a copy it may change and return.
+// If fn returns nil, the subschema is removed, and array items after it
+// move up. The root must remain a schema.
+func Transform(schema *Schema, fn func(ptr JSONPointer, c *Combined) (*Combined, error)) (*Schema, error) {
+	if schema == nil {
+		return nil, nil
+	}
+	c, err := transformCombined("", NewCombinedWithSchema(schema), fn)
+	if err != nil {
+		return nil, err
+	}
+	if c == nil || c.Schema == nil {
+		return nil, fmt.Errorf("transform replaced the root with a boolean or nothing")
+	}
+	return c.Schema, nil
+}
+
+func transformCombined(ptr JSONPointer, c *Combined, fn func(ptr JSONPointer, c *Combined) (*Combined, error)) (*Combined, error) {
+	if c.Schema == nil {
+		copied := *c
+		return fn(ptr, &copied)
+	}
+
+	tokens := make(map[*Combined][]string)
+	forEachChild(c.Schema, func(t []string, child *Combined) bool {
+		tokens[child] = t
+		return true
+	})
+	s, err := mapChildren(c.Schema, func(child *Combined) (*Combined, error) {
+		p := ptr
+		for _, t := range tokens[child] {
+			p = p.Append(t)
+		}
+		return transformCombined(p, child, fn)
+	})
+	if err != nil {
+		return nil, err
+	}
+	dropRemoved(s)
+	return fn(ptr, NewCombinedWithSchema(s))
+}
+
+// dropRemoved deletes the subschemas of s that Transform removed.
+func dropRemoved(s *Schema) {
+	keyed := func(m map[string]*Combined) {
+		for k, c := range m {
+			if c == nil {
+				delete(m, k)
+			}
+		}
+	}
+	many := func(arr []*Combined) []*Combined {
+		if arr == nil {
+			return nil
+		}
+		out := make([]*Combined, 0, len(arr))
+		for _, c := range arr {
+			if c != nil {
+				out = append(out, c)
+			}
+		}
+		return out
+	}
+
+	keyed(s.Definitions)
+	keyed(s.Properties)
+	keyed(s.PatternProperties)
+	for k, dep := range s.Dependencies {
+		if dep != nil && dep.StringArray == nil && dep.Combined == nil {
+			delete(s.Dependencies, k)
+		}
+	}
+	if s.Items != nil && s.Items.CombinedArray != nil {
+		tuple := many(*s.Items.CombinedArray)
+		s.Items = NewCombinedOrCombinedArrayWithCombinedArray(tuple)
+	} else if s.Items != nil && s.Items.Combined == nil {
+		s.Items = nil
+	}
+	s.AllOf = many(s.AllOf)
+	s.AnyOf = many(s.AnyOf)
+	s.OneOf = many(s.OneOf)
+}
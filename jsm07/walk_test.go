@@ -0,0 +1,109 @@
+package jsm07
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestWalk(t *testing.T) {
+	s := new(Schema)
+	if err := json.Unmarshal([]byte(`{
+		"properties": {"b": {"type": "string"}, "a/c": {"items": [{"type": "integer"}, true], "additionalItems": {"not": {}}}},
+		"dependencies": {"b": ["a/c"], "x": {"required": ["y"]}},
+		"allOf": [{"$ref": "#/definitions/d"}],
+		"definitions": {"d": {"anyOf": [{"type": "null"}]}}
+	}`), s); err != nil {
+		t.Fatalf("Failed to unmarshal schema: %v", err)
+	}
+
+	var got []JSONPointer
+	err := Walk(s, func(ptr JSONPointer, _ *Schema) error {
+		got = append(got, ptr)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to walk: %v", err)
+	}
+	want := []JSONPointer{
+		"", "/properties/b", "/properties/a~1c", "/properties/a~1c/items/0",
+		"/properties/a~1c/additionalItems", "/properties/a~1c/additionalItems/not",
+		"/dependencies/x", "/allOf/0", "/definitions/d", "/definitions/d/anyOf/0",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	got = nil
+	Walk(s, func(ptr JSONPointer, _ *Schema) error {
+		got = append(got, ptr)
+		if ptr == "/properties/a~1c" {
+			return SkipSubschemas
+		}
+		return nil
+	})
+	if len(got) != len(want)-3 {
+		t.Errorf("Expected subschemas to be skipped, got %v", got)
+	}
+
+	stop := errors.New("stop")
+	got = nil
+	err = Walk(s, func(ptr JSONPointer, _ *Schema) error {
+		got = append(got, ptr)
+		if ptr == "/properties/b" {
+			return stop
+		}
+		return nil
+	})
+	if err != stop || len(got) != 2 {
+		t.Errorf("Expected the walk to stop at /properties/b, got %v after %v", err, got)
+	}
+}
+
+func TestTransform(t *testing.T) {
+	s := new(Schema)
+	if err := json.Unmarshal([]byte(`{
+		"description": "root",
+		"properties": {"a": {"description": "a", "type": "string"}, "b": {"x-internal": true}},
+		"anyOf": [{"x-internal": true}, {"type": "object"}, false]
+	}`), s); err != nil {
+		t.Fatalf("Failed to unmarshal schema: %v", err)
+	}
+
+	var order []JSONPointer
+	out, err := Transform(s, func(ptr JSONPointer, c *Combined) (*Combined, error) {
+		order = append(order, ptr)
+		if c.Schema == nil {
+			return NewCombinedWithBoolean(true), nil
+		}
+		if _, ok := c.Schema.Extensions["x-internal"]; ok {
+			return nil, nil
+		}
+		c.Schema.Description = nil
+		return c, nil
+	})
+	if err != nil {
+		t.Fatalf("Failed to transform: %v", err)
+	}
+	want := new(Schema)
+	if err := json.Unmarshal([]byte(`{"properties": {"a": {"type": "string"}}, "anyOf": [{"type": "object"}, true]}`), want); err != nil {
+		t.Fatalf("Failed to unmarshal expected schema: %v", err)
+	}
+	if !jsonSame(out, want) {
+		got, _ := json.Marshal(out)
+		t.Errorf("Expected the transformed schema, got %s", got)
+	}
+	if order[len(order)-1] != "" {
+		t.Errorf("Expected the root to be transformed last, got %v", order)
+	}
+	if s.Description == nil || s.Properties["a"].Schema.Description == nil || len(s.AnyOf) != 3 {
+		t.Errorf("Expected the original schema to be unchanged")
+	}
+
+	if _, err := Transform(s, func(ptr JSONPointer, c *Combined) (*Combined, error) {
+		return NewCombinedWithBoolean(false), nil
+	}); err == nil {
+		t.Errorf("Expected an error for a boolean root")
+	}
+}
@@ -0,0 +1,105 @@
+// Package jsm2019 models JSON Schema draft 2019-09 and converts it between
+// JSON and HCL, the way package jsm07 does for draft-07.
+package jsm2019
+
+import (
+	"encoding/json"
+
+	"github.com/genelet/hclschema/jsm07"
+)
+
+// The scalar types are shared with draft-07.
+type (
+	IntegerOrFloat      = jsm07.IntegerOrFloat
+	StringOrStringArray = jsm07.StringOrStringArray
+	SchemaEnumValue     = jsm07.SchemaEnumValue
+)
+
+type Common struct {
+	ID               *string           `json:"$id,omitempty" hcl:"_id,optional"`
+	Schema           *string           `json:"$schema,omitempty" hcl:"_schema,optional"`
+	Anchor           *string           `json:"$anchor,omitempty" hcl:"_anchor,optional"`
+	RecursiveAnchor  *bool             `json:"$recursiveAnchor,omitempty" hcl:"_recursiveAnchor,optional"`
+	Vocabulary       map[string]bool   `json:"$vocabulary,omitempty" hcl:"_vocabulary,optional"`
+	Format           *string           `json:"format,omitempty" hcl:"format,optional"`
+	ContentMediaType *string           `json:"contentMediaType,omitempty" hcl:"contentMediaType,optional"`
+	ContentEncoding  *string           `json:"contentEncoding,omitempty" hcl:"contentEncoding,optional"`
+	Comment          *string           `json:"$comment,omitempty" hcl:"_comment,optional"`
+	Title            *string           `json:"title,omitempty" hcl:"title,optional"`
+	Description      *string           `json:"description,omitempty" hcl:"description,optional"`
+	Deprecated       *bool             `json:"deprecated,omitempty" hcl:"deprecated,optional"`
+	Enumeration      []SchemaEnumValue `json:"enum,omitempty" hcl:"enum,optional"`
+	Const            *json.RawMessage  `json:"const,omitempty" hcl:"const,optional"`
+	Default          *json.RawMessage  `json:"default,omitempty" hcl:"default,optional"`
+	Examples         *json.RawMessage  `json:"examples,omitempty" hcl:"examples,optional"`
+}
+
+type SchemaNumber struct {
+	MultipleOf       *IntegerOrFloat `json:"multipleOf,omitempty" hcl:"multipleOf,optional"`
+	Maximum          *IntegerOrFloat `json:"maximum,omitempty" hcl:"maximum,optional"`
+	ExclusiveMaximum *IntegerOrFloat `json:"exclusiveMaximum,omitempty" hcl:"exclusiveMaximum,optional"`
+	Minimum          *IntegerOrFloat `json:"minimum,omitempty" hcl:"minimum,optional"`
+	ExclusiveMinimum *IntegerOrFloat `json:"exclusiveMinimum,omitempty" hcl:"exclusiveMinimum,optional"`
+}
+
+type SchemaString struct {
+	MaxLength *int64  `json:"maxLength,omitempty" hcl:"maxLength,optional"`
+	MinLength *int64  `json:"minLength,omitempty" hcl:"minLength,optional"`
+	Pattern   *string `json:"pattern,omitempty" hcl:"pattern,optional"`
+}
+
+type SchemaArray struct {
+	AdditionalItems  *Combined                `json:"additionalItems,omitempty" hcl:"additionalItems,block"`
+	Items            *CombinedOrCombinedArray `json:"items,omitempty" hcl:"items,block"`
+	UnevaluatedItems *Combined                `json:"unevaluatedItems,omitempty" hcl:"unevaluatedItems,block"`
+	MaxItems         *int64                   `json:"maxItems,omitempty" hcl:"maxItems,optional"`
+	MinItems         *int64                   `json:"minItems,omitempty" hcl:"minItems,optional"`
+	UniqueItems      *bool                    `json:"uniqueItems,omitempty" hcl:"uniqueItems,optional"`
+	Contains         *Combined                `json:"contains,omitempty" hcl:"contains,block"`
+	MaxContains      *int64                   `json:"maxContains,omitempty" hcl:"maxContains,optional"`
+	MinContains      *int64                   `json:"minContains,omitempty" hcl:"minContains,optional"`
+}
+
+// SchemaObject holds the object keywords. Draft-07 dependencies is split
+// into dependentRequired and dependentSchemas.
+type SchemaObject struct {
+	MaxProperties         *int64               `json:"maxProperties,omitempty" hcl:"maxProperties,optional"`
+	MinProperties         *int64               `json:"minProperties,omitempty" hcl:"minProperties,optional"`
+	Required              []string             `json:"required,omitempty" hcl:"required,optional"`
+	DependentRequired     map[string][]string  `json:"dependentRequired,omitempty" hcl:"dependentRequired,optional"`
+	AdditionalProperties  *Combined            `json:"additionalProperties,omitempty" hcl:"additionalProperties,block"`
+	UnevaluatedProperties *Combined            `json:"unevaluatedProperties,omitempty" hcl:"unevaluatedProperties,block"`
+	PropertyNames         *Combined            `json:"propertyNames,omitempty" hcl:"propertyNames,block"`
+	Properties            map[string]*Combined `json:"properties,omitempty" hcl:"properties,block"`
+	PatternProperties     map[string]*Combined `json:"patternProperties,omitempty" hcl:"patternProperties,block"`
+	DependentSchemas      map[string]*Combined `json:"dependentSchemas,omitempty" hcl:"dependentSchemas,block"`
+}
+
+// The Schema struct models a JSON Schema of draft 2019-09 and, because
+// schemas are defined hierarchically, contains many references to itself.
+// All fields are pointers and are nil if the associated values
+// are not specified.
+type Schema struct {
+	Type *StringOrStringArray `json:"type,omitempty" hcl:"type,optional"`
+	Common
+	Ref          *string `json:"$ref,omitempty" hcl:"_ref,optional"`
+	RecursiveRef *string `json:"$recursiveRef,omitempty" hcl:"_recursiveRef,optional"`
+	ReadOnly     *bool   `json:"readOnly,omitempty" hcl:"readOnly,optional"`
+	WriteOnly    *bool   `json:"writeOnly,omitempty" hcl:"writeOnly,optional"`
+
+	SchemaNumber
+	SchemaString
+	SchemaArray
+	SchemaObject
+
+	Defs          map[string]*Combined `json:"$defs,omitempty" hcl:"_defs,block"`
+	ContentSchema *Combined            `json:"contentSchema,omitempty" hcl:"contentSchema,block"`
+
+	If    *Combined   `json:"if,omitempty" hcl:"if,block"`
+	Then  *Combined   `json:"then,omitempty" hcl:"then,block"`
+	Else  *Combined   `json:"else,omitempty" hcl:"else,block"`
+	AllOf []*Combined `json:"allOf,omitempty" hcl:"allOf,block"`
+	AnyOf []*Combined `json:"anyOf,omitempty" hcl:"anyOf,block"`
+	OneOf []*Combined `json:"oneOf,omitempty" hcl:"oneOf,block"`
+	Not   *Combined   `json:"not,omitempty" hcl:"not,block"`
+}
@@ -0,0 +1,203 @@
+package jsm2019
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/genelet/determined/dethcl"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSchemaJSON(t *testing.T) {
+	data := `{
+		"$schema": "https://json-schema.org/draft/2019-09/schema",
+		"$id": "https://example.com/tree",
+		"$recursiveAnchor": true,
+		"type": "object",
+		"properties": {
+			"children": {"type": "array", "items": {"$recursiveRef": "#"}, "minContains": 1},
+			"point": {"items": [{"type": "number"}, {"$ref": "#/$defs/lon"}], "additionalItems": false}
+		},
+		"dependentRequired": {"credit_card": ["billing_address"]},
+		"dependentSchemas": {"credit_card": {"required": ["cvv"]}},
+		"unevaluatedProperties": false,
+		"$defs": {"lon": {"$anchor": "lon", "type": "number"}}
+	}`
+	schema := new(Schema)
+	if err := json.Unmarshal([]byte(data), schema); err != nil {
+		t.Fatalf("Failed to unmarshal schema: %v", err)
+	}
+	point := schema.Properties["point"].Schema
+	if point.Items.CombinedArray == nil || len(*point.Items.CombinedArray) != 2 || *point.AdditionalItems.Boolean {
+		t.Errorf("Unexpected items and additionalItems: %#v", point.SchemaArray)
+	}
+	if schema.RecursiveAnchor == nil || !*schema.RecursiveAnchor || schema.Defs["lon"] == nil {
+		t.Errorf("Expected $recursiveAnchor and $defs, got %#v", schema)
+	}
+
+	bs, err := json.Marshal(schema)
+	if err != nil {
+		t.Fatalf("Failed to marshal schema: %v", err)
+	}
+	schema1 := new(Schema)
+	if err := json.Unmarshal(bs, schema1); err != nil {
+		t.Fatalf("Failed to unmarshal marshalled schema: %v", err)
+	}
+	if diff := cmp.Diff(schema, schema1); diff != "" {
+		t.Errorf("Schema mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestParseSchema(t *testing.T) {
+	schema, err := ParseSchema([]byte(`
+  _schema = "https://json-schema.org/draft/2019-09/schema"
+  _recursiveAnchor = true
+  type = "object"
+  dependentRequired = {
+    credit_card = ["billing_address"]
+  }
+  unevaluatedProperties = false
+  properties "children" {
+    type = "array"
+    items {
+      _recursiveRef = "#"
+    }
+    maxContains = 3
+  }
+  properties "point" {
+    items {
+      type = "number"
+      minimum = -90
+    }
+    items {
+      _ref = "#/$defs/lon"
+    }
+    additionalItems = false
+  }
+  dependentSchemas "credit_card" {
+    required = ["cvv"]
+  }
+  _defs "lon" {
+    _anchor = "lon"
+    type = "number"
+  }
+`))
+	if err != nil {
+		t.Fatalf("Failed to parse HCL: %v", err)
+	}
+
+	expected := new(Schema)
+	if err := json.Unmarshal([]byte(`{
+		"$schema": "https://json-schema.org/draft/2019-09/schema",
+		"$recursiveAnchor": true,
+		"type": "object",
+		"dependentRequired": {"credit_card": ["billing_address"]},
+		"unevaluatedProperties": false,
+		"properties": {
+			"children": {"type": "array", "items": {"$recursiveRef": "#"}, "maxContains": 3},
+			"point": {"items": [{"type": "number", "minimum": -90}, {"$ref": "#/$defs/lon"}], "additionalItems": false}
+		},
+		"dependentSchemas": {"credit_card": {"required": ["cvv"]}},
+		"$defs": {"lon": {"$anchor": "lon", "type": "number"}}
+	}`), expected); err != nil {
+		t.Fatalf("Failed to unmarshal schema: %v", err)
+	}
+	if diff := cmp.Diff(expected, schema); diff != "" {
+		t.Errorf("Schema mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSchemaHCL(t *testing.T) {
+	schema := new(Schema)
+	if err := json.Unmarshal([]byte(`{
+		"$vocabulary": {"https://json-schema.org/draft/2019-09/vocab/core": true},
+		"$recursiveAnchor": true,
+		"$recursiveRef": "#",
+		"type": "array",
+		"deprecated": true,
+		"items": false,
+		"unevaluatedItems": false,
+		"minContains": 2,
+		"dependentRequired": {"credit_card": ["billing_address"]}
+	}`), schema); err != nil {
+		t.Fatalf("Failed to unmarshal schema: %v", err)
+	}
+
+	bs, err := dethcl.Marshal(schema)
+	if err != nil {
+		t.Fatalf("Failed to marshal schema to HCL: %v", err)
+	}
+	parsed, err := ParseSchema(bs)
+	if err != nil {
+		t.Fatalf("Failed to parse HCL: %v\n%s", err, bs)
+	}
+	if diff := cmp.Diff(schema, parsed); diff != "" {
+		t.Errorf("Schema mismatch (-want +got):\n%s\n%s", diff, bs)
+	}
+}
+
+func TestSchemaHCLNested(t *testing.T) {
+	schema := new(Schema)
+	if err := json.Unmarshal([]byte(`{
+		"type": "object",
+		"required": ["a"],
+		"properties": {
+			"b": {"type": "integer", "minimum": 3},
+			"a": {"type": "array", "items": [true, {"type": "string", "maxLength": 2}, false], "additionalItems": {"type": "number", "multipleOf": 0.5}}
+		},
+		"$defs": {"x": {"allOf": [{"required": ["p"], "minProperties": 1}, true], "not": {"type": "null", "title": "no"}}}
+	}`), schema); err != nil {
+		t.Fatalf("Failed to unmarshal schema: %v", err)
+	}
+
+	bs, err := dethcl.Marshal(schema)
+	if err != nil {
+		t.Fatalf("Failed to marshal schema to HCL: %v", err)
+	}
+	parsed, err := ParseSchema(bs)
+	if err != nil {
+		t.Fatalf("Failed to parse HCL: %v\n%s", err, bs)
+	}
+
+	// in blocks, true is written as an empty schema and false as an empty not
+	expected := new(Schema)
+	if err := json.Unmarshal([]byte(`{
+		"type": "object",
+		"required": ["a"],
+		"properties": {
+			"b": {"type": "integer", "minimum": 3},
+			"a": {"type": "array", "items": [{}, {"type": "string", "maxLength": 2}, {"not": {}}], "additionalItems": {"type": "number", "multipleOf": 0.5}}
+		},
+		"$defs": {"x": {"allOf": [{"required": ["p"], "minProperties": 1}, {}], "not": {"type": "null", "title": "no"}}}
+	}`), expected); err != nil {
+		t.Fatalf("Failed to unmarshal expected schema: %v", err)
+	}
+	if diff := cmp.Diff(expected, parsed); diff != "" {
+		t.Errorf("Schema mismatch (-want +got):\n%s\n%s", diff, bs)
+	}
+}
+
+func TestSchemaHCLStrings(t *testing.T) {
+	schema := new(Schema)
+	if err := json.Unmarshal([]byte(`{
+		"type": "string",
+		"title": "say \"hi\"",
+		"description": "line one\nline two \\ ${x} %{y}",
+		"pattern": "^\\d+$",
+		"$comment": "${not} a template"
+	}`), schema); err != nil {
+		t.Fatalf("Failed to unmarshal schema: %v", err)
+	}
+
+	bs, err := dethcl.Marshal(schema)
+	if err != nil {
+		t.Fatalf("Failed to marshal schema to HCL: %v", err)
+	}
+	parsed, err := ParseSchema(bs)
+	if err != nil {
+		t.Fatalf("Failed to parse HCL: %v\n%s", err, bs)
+	}
+	if diff := cmp.Diff(schema, parsed); diff != "" {
+		t.Errorf("Schema mismatch (-want +got):\n%s\n%s", diff, bs)
+	}
+}
@@ -0,0 +1,454 @@
+package jsm2019
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/genelet/hcllight/light"
+	"github.com/genelet/hclschema/internal/lightexpr"
+)
+
+// UnmarshalHCL unmarshals HCL data into a Schema object.
+func (self *Schema) UnmarshalHCL(data []byte, labels ...string) error {
+	s, err := ParseSchema(data)
+	if err != nil {
+		return err
+	}
+	*self = *s
+	return nil
+}
+
+// ParseSchema parses a HCL string representing a JSON schema of draft
+// 2019-09 and returns a Schema object. Unknown keywords are ignored.
+func ParseSchema(data []byte) (*Schema, error) {
+	body, err := light.ParseBody(data)
+	if err != nil {
+		return nil, err
+	}
+	return parseSchemaFromBody(body)
+}
+
+func parseSchemaFromBody(body *light.Body) (*Schema, error) {
+	if body == nil {
+		return nil, nil
+	}
+
+	schema := &Schema{}
+	for k, v := range body.Attributes {
+		expr := v.Expr
+		var err error
+		switch k {
+		case "_id":
+			schema.ID = light.TextValueExprToString(expr)
+		case "_schema":
+			schema.Schema = light.TextValueExprToString(expr)
+		case "_ref":
+			schema.Ref = light.TextValueExprToString(expr)
+		case "_recursiveRef":
+			schema.RecursiveRef = light.TextValueExprToString(expr)
+		case "_anchor":
+			schema.Anchor = light.TextValueExprToString(expr)
+		case "_comment":
+			schema.Comment = light.TextValueExprToString(expr)
+		case "title":
+			schema.Title = light.TextValueExprToString(expr)
+		case "description":
+			schema.Description = light.TextValueExprToString(expr)
+		case "format":
+			schema.Format = light.TextValueExprToString(expr)
+		case "contentMediaType":
+			schema.ContentMediaType = light.TextValueExprToString(expr)
+		case "contentEncoding":
+			schema.ContentEncoding = light.TextValueExprToString(expr)
+		case "pattern":
+			schema.Pattern = light.TextValueExprToString(expr)
+
+		case "maxLength":
+			schema.MaxLength = light.LiteralValueExprToInt64(expr)
+		case "minLength":
+			schema.MinLength = light.LiteralValueExprToInt64(expr)
+		case "maxItems":
+			schema.MaxItems = light.LiteralValueExprToInt64(expr)
+		case "minItems":
+			schema.MinItems = light.LiteralValueExprToInt64(expr)
+		case "maxContains":
+			schema.MaxContains = light.LiteralValueExprToInt64(expr)
+		case "minContains":
+			schema.MinContains = light.LiteralValueExprToInt64(expr)
+		case "maxProperties":
+			schema.MaxProperties = light.LiteralValueExprToInt64(expr)
+		case "minProperties":
+			schema.MinProperties = light.LiteralValueExprToInt64(expr)
+
+		case "readOnly":
+			schema.ReadOnly = light.LiteralValueExprToBoolean(expr)
+		case "writeOnly":
+			schema.WriteOnly = light.LiteralValueExprToBoolean(expr)
+		case "_recursiveAnchor":
+			schema.RecursiveAnchor = light.LiteralValueExprToBoolean(expr)
+		case "deprecated":
+			schema.Deprecated = light.LiteralValueExprToBoolean(expr)
+		case "uniqueItems":
+			schema.UniqueItems = light.LiteralValueExprToBoolean(expr)
+
+		case "const":
+			schema.Const, err = lightexpr.ToRaw(expr)
+		case "default":
+			schema.Default, err = lightexpr.ToRaw(expr)
+		case "examples":
+			schema.Examples, err = lightexpr.ToRaw(expr)
+
+		case "type":
+			schema.Type = exprToStringOrStringArray(expr)
+		case "multipleOf":
+			schema.MultipleOf = exprToIntegerOrFloat(expr)
+		case "maximum":
+			schema.Maximum = exprToIntegerOrFloat(expr)
+		case "exclusiveMaximum":
+			schema.ExclusiveMaximum = exprToIntegerOrFloat(expr)
+		case "minimum":
+			schema.Minimum = exprToIntegerOrFloat(expr)
+		case "exclusiveMinimum":
+			schema.ExclusiveMinimum = exprToIntegerOrFloat(expr)
+
+		case "items":
+			var c *Combined
+			c, err = expressionToCombined(expr)
+			schema.Items = NewCombinedOrCombinedArrayWithCombined(c)
+		case "additionalItems":
+			schema.AdditionalItems, err = expressionToCombined(expr)
+		case "unevaluatedItems":
+			schema.UnevaluatedItems, err = expressionToCombined(expr)
+		case "propertyNames":
+			schema.PropertyNames, err = expressionToCombined(expr)
+		case "additionalProperties":
+			schema.AdditionalProperties, err = expressionToCombined(expr)
+		case "unevaluatedProperties":
+			schema.UnevaluatedProperties, err = expressionToCombined(expr)
+		case "contains":
+			schema.Contains, err = expressionToCombined(expr)
+		case "contentSchema":
+			schema.ContentSchema, err = expressionToCombined(expr)
+		case "if":
+			schema.If, err = expressionToCombined(expr)
+		case "then":
+			schema.Then, err = expressionToCombined(expr)
+		case "else":
+			schema.Else, err = expressionToCombined(expr)
+		case "not":
+			schema.Not, err = expressionToCombined(expr)
+
+		case "required":
+			schema.Required = light.TupleConsExprToStringArray(expr)
+		case "enum":
+			schema.Enumeration, err = exprToEnum(expr)
+		case "dependentRequired":
+			schema.DependentRequired, err = exprToStringArrayMap(expr)
+		case "_vocabulary":
+			schema.Vocabulary, err = exprToBoolMap(expr)
+
+		default:
+			// ignore
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", k, err)
+		}
+	}
+
+	var combs []*Combined
+	props := make(map[string]*Combined)
+	var nullProps int
+
+	for _, block := range body.Blocks {
+		switch block.Type {
+		case "items", "additionalItems", "unevaluatedItems", "additionalProperties", "unevaluatedProperties", "propertyNames", "contains", "contentSchema", "if", "then", "else", "not":
+			c, err := newCombinedFromBody(block.Bdy)
+			if err != nil {
+				return nil, err
+			}
+			switch block.Type {
+			case "items":
+				combs = append(combs, c)
+			case "additionalItems":
+				schema.AdditionalItems = c
+			case "unevaluatedItems":
+				schema.UnevaluatedItems = c
+			case "additionalProperties":
+				schema.AdditionalProperties = c
+			case "unevaluatedProperties":
+				schema.UnevaluatedProperties = c
+			case "propertyNames":
+				schema.PropertyNames = c
+			case "contains":
+				schema.Contains = c
+			case "contentSchema":
+				schema.ContentSchema = c
+			case "if":
+				schema.If = c
+			case "then":
+				schema.Then = c
+			case "else":
+				schema.Else = c
+			case "not":
+				schema.Not = c
+			}
+
+		case "allOf", "anyOf", "oneOf":
+			combined, err := newCombinedFromBody(block.Bdy)
+			if err != nil {
+				return nil, err
+			}
+			switch block.Type {
+			case "allOf":
+				schema.AllOf = append(schema.AllOf, combined)
+			case "anyOf":
+				schema.AnyOf = append(schema.AnyOf, combined)
+			case "oneOf":
+				schema.OneOf = append(schema.OneOf, combined)
+			}
+
+		case "_defs", "properties", "patternProperties", "dependentSchemas":
+			combined, err := newCombinedFromBody(block.Bdy)
+			if err != nil {
+				return nil, err
+			}
+			if block.Type == "properties" && len(block.Labels) == 0 {
+				nullProps++
+				continue
+			}
+			if len(block.Labels) != 1 {
+				return nil, fmt.Errorf("%s block needs one label", block.Type)
+			}
+			name := block.Labels[0]
+			switch block.Type {
+			case "_defs":
+				if schema.Defs == nil {
+					schema.Defs = make(map[string]*Combined)
+				}
+				schema.Defs[name] = combined
+			case "patternProperties":
+				if schema.PatternProperties == nil {
+					schema.PatternProperties = make(map[string]*Combined)
+				}
+				schema.PatternProperties[name] = combined
+			case "dependentSchemas":
+				if schema.DependentSchemas == nil {
+					schema.DependentSchemas = make(map[string]*Combined)
+				}
+				schema.DependentSchemas[name] = combined
+			case "properties":
+				props[name] = combined
+			}
+
+		default:
+			// ignore
+		}
+	}
+
+	if len(combs) > 1 {
+		schema.Items = NewCombinedOrCombinedArrayWithCombinedArray(combs)
+	} else if len(combs) == 1 {
+		schema.Items = NewCombinedOrCombinedArrayWithCombined(combs[0])
+	}
+
+	if len(props) > 0 {
+		schema.Properties = props
+	} else if nullProps > 0 {
+		schema.Properties = map[string]*Combined{}
+	}
+
+	return schema, nil
+}
+
+func exprToIntegerOrFloat(expr *light.Expression) *IntegerOrFloat {
+	x, err := lightexpr.ToInterface(expr)
+	if err != nil {
+		return nil
+	}
+	f, ok := x.(float64)
+	if !ok {
+		return nil
+	}
+	// like UnmarshalJSON, keep integral values as integers
+	if f == math.Trunc(f) && math.Abs(f) < 1<<63 {
+		i := int64(f)
+		return &IntegerOrFloat{Integer: &i}
+	}
+	return &IntegerOrFloat{Float: &f}
+}
+
+func newCombinedFromBody(body *light.Body) (*Combined, error) {
+	if body == nil {
+		return nil, nil
+	}
+
+	schema, err := parseSchemaFromBody(body)
+	if err != nil {
+		return nil, err
+	}
+	return NewCombinedWithSchema(schema), nil
+}
+
+func exprToStringOrStringArray(expr *light.Expression) *StringOrStringArray {
+	if expr == nil {
+		return nil
+	}
+
+	switch expr.ExpressionClause.(type) {
+	case *light.Expression_Texpr:
+		return &StringOrStringArray{
+			String: light.TextValueExprToString(expr),
+		}
+	default:
+	}
+	x := light.TupleConsExprToStringArray(expr)
+	return &StringOrStringArray{
+		StringArray: &x,
+	}
+}
+
+func exprToEnum(expr *light.Expression) ([]SchemaEnumValue, error) {
+	x, err := lightexpr.ToInterface(expr)
+	if err != nil {
+		return nil, err
+	}
+	arr, ok := x.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expect a list of values")
+	}
+
+	var enums []SchemaEnumValue
+	for _, v := range arr {
+		switch t := v.(type) {
+		case string:
+			enums = append(enums, SchemaEnumValue{String: &t})
+		case bool:
+			enums = append(enums, SchemaEnumValue{Bool: &t})
+		case float64:
+			var n IntegerOrFloat
+			if t == math.Trunc(t) && math.Abs(t) < 1<<63 {
+				i := int64(t)
+				n.Integer = &i
+			} else {
+				n.Float = &t
+			}
+			enums = append(enums, SchemaEnumValue{Number: &n})
+		default:
+			return nil, fmt.Errorf("enum value %v is not supported", v)
+		}
+	}
+	return enums, nil
+}
+
+func exprToStringArrayMap(expr *light.Expression) (map[string][]string, error) {
+	x, err := lightexpr.ToInterface(expr)
+	if err != nil {
+		return nil, err
+	}
+	obj, ok := x.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expect an object of string lists")
+	}
+
+	m := make(map[string][]string)
+	for k, v := range obj {
+		arr, ok := v.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("expect a string list for %s", k)
+		}
+		strs := []string{}
+		for _, item := range arr {
+			str, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("expect a string list for %s", k)
+			}
+			strs = append(strs, str)
+		}
+		m[k] = strs
+	}
+	return m, nil
+}
+
+func exprToBoolMap(expr *light.Expression) (map[string]bool, error) {
+	x, err := lightexpr.ToInterface(expr)
+	if err != nil {
+		return nil, err
+	}
+	obj, ok := x.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("expect an object of booleans")
+	}
+
+	m := make(map[string]bool)
+	for k, v := range obj {
+		b, ok := v.(bool)
+		if !ok {
+			return nil, fmt.Errorf("expect a boolean for %s", k)
+		}
+		m[k] = b
+	}
+	return m, nil
+}
+
+func expressionToSchema(expr *light.Expression) (*Schema, error) {
+	if expr == nil {
+		return nil, nil
+	}
+
+	switch expr.ExpressionClause.(type) {
+	case *light.Expression_Stexpr:
+		ref, err := expressionToReference(expr)
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{
+			Ref: &ref,
+		}, nil
+	case *light.Expression_Ocexpr:
+		body := expr.GetOcexpr().ToBody()
+		return parseSchemaFromBody(body)
+	default:
+	}
+
+	return nil, fmt.Errorf("not supported expression: %#v", expr)
+}
+
+func expressionToCombined(expr *light.Expression) (*Combined, error) {
+	if expr == nil {
+		return nil, nil
+	}
+	schemaOrBoolean := &Combined{}
+	switch expr.ExpressionClause.(type) {
+	case *light.Expression_Lvexpr:
+		v := light.LiteralValueExprToBoolean(expr)
+		schemaOrBoolean.Boolean = v
+	default:
+		schema, err := expressionToSchema(expr)
+		if err != nil {
+			return nil, err
+		}
+		schemaOrBoolean.Schema = schema
+	}
+	return schemaOrBoolean, nil
+}
+
+// expressionToReference turns a traversal such as _defs.Name into the
+// pointer "#/$defs/Name".
+func expressionToReference(expr *light.Expression) (string, error) {
+	var x *string
+	if lv := expr.GetLvexpr(); lv != nil {
+		str := lv.Val.GetStringValue()
+		x = &str
+	} else {
+		x = light.TraversalToString(expr)
+	}
+	if x == nil {
+		return "", fmt.Errorf("invalid reference expression")
+	}
+	ref := *x
+	if strings.HasPrefix(ref, "_") {
+		ref = "$" + ref[1:]
+	}
+	return "#/" + ref, nil
+}
@@ -0,0 +1,560 @@
+package jsm2020
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/genelet/hclschema/jsm07"
+)
+
+// Draft2020 is the $schema URI of draft 2020-12.
+const Draft2020 = "https://json-schema.org/draft/2020-12/schema"
+
+// Incompatibility reports a keyword that could not be carried over
+// exactly when converting between drafts. Pointer locates the schema in
+// the source, as a JSON Pointer.
+type Incompatibility struct {
+	Pointer string
+	Keyword string
+	Reason  string
+}
+
+func (self *Incompatibility) Error() string {
+	return fmt.Sprintf("%s: %s: %s", self.Pointer, self.Keyword, self.Reason)
+}
+
+// FromDraft07 converts a draft-07 schema to draft 2020-12: definitions
+// becomes $defs, array-form items becomes prefixItems with additionalItems
+// as items, dependencies is split into dependentRequired and
+// dependentSchemas, plain-name $id fragments become $anchor, and the
+// fragments of $ref pointers are rewritten to match. The result does not
+// share maps or slices with s.
+//
+// Every draft-07 keyword has a 2020-12 counterpart; the incompatibilities
+// returned are keywords next to $ref, which draft-07 ignores but 2020-12
+// applies.
+func FromDraft07(s *jsm07.Schema) (*Schema, []*Incompatibility) {
+	if s == nil {
+		return nil, nil
+	}
+	u := &upgrader{}
+	c := u.upgrade(jsm07.NewCombinedWithSchema(s), "", true)
+	return c.Schema, u.issues
+}
+
+// ToDraft07 converts a 2020-12 schema to draft-07, the reverse of
+// FromDraft07. $ref with sibling keywords is wrapped in allOf, since
+// draft-07 ignores its siblings, and dependentRequired and dependentSchemas
+// on the same property are merged with allOf. Key order is kept, the keys
+// of dependentRequired coming first in dependencies.
+//
+// Keywords without a draft-07 counterpart are reported and dropped:
+// $dynamicRef (turned into a plain $ref), $dynamicAnchor and $anchor when
+// they cannot be written as an $id fragment, unevaluatedItems,
+// unevaluatedProperties, minContains, maxContains, deprecated,
+// $vocabulary and contentSchema.
+func (self *Schema) ToDraft07() (*jsm07.Schema, []*Incompatibility) {
+	d := &downgrader{root: self}
+	c := d.downgrade(NewCombinedWithSchema(self), "", true)
+	if c == nil {
+		return nil, d.issues
+	}
+	return c.Schema, d.issues
+}
+
+type upgrader struct {
+	issues []*Incompatibility
+}
+
+func (self *upgrader) report(path, keyword, reason string) {
+	self.issues = append(self.issues, &Incompatibility{Pointer: path, Keyword: keyword, Reason: reason})
+}
+
+func (self *upgrader) upgrade(c *jsm07.Combined, path string, top bool) *Combined {
+	if c == nil {
+		return nil
+	}
+	if c.Schema == nil {
+		if c.Boolean == nil {
+			return &Combined{}
+		}
+		return NewCombinedWithBoolean(*c.Boolean)
+	}
+	s := c.Schema
+
+	out := &Schema{
+		Type:         s.Type,
+		ReadOnly:     s.ReadOnly,
+		WriteOnly:    s.WriteOnly,
+		SchemaNumber: SchemaNumber(s.SchemaNumber),
+		SchemaString: SchemaString(s.SchemaString),
+	}
+	out.ID = s.ID
+	out.Schema = s.Common.Schema
+	out.Format = s.Format
+	out.ContentMediaType = s.ContentMediaType
+	out.ContentEncoding = s.ContentEncoding
+	out.Comment = s.Comment
+	out.Title = s.Title
+	out.Description = s.Description
+	out.Enumeration = append([]SchemaEnumValue(nil), s.Enumeration...)
+	out.Const = s.Const
+	out.Default = s.Default
+	out.Examples = s.Examples
+
+	if top && out.Schema != nil && strings.Contains(*out.Schema, "draft-07") {
+		x := Draft2020
+		out.Schema = &x
+	}
+	if s.ID != nil && strings.HasPrefix(*s.ID, "#") {
+		anchor := (*s.ID)[1:]
+		out.ID = nil
+		out.Anchor = &anchor
+	}
+
+	if s.Ref != nil {
+		ref := mapRef(*s.Ref, upgradePointer)
+		out.Ref = &ref
+		if hasRefSiblings07(s) {
+			self.report(path, "$ref", "keywords next to $ref were ignored in draft-07 but apply in 2020-12")
+		}
+	}
+
+	child := func(keyword string, c *jsm07.Combined) *Combined {
+		return self.upgrade(c, pointerAppend(path, keyword), false)
+	}
+	children := func(keyword string, arr []*jsm07.Combined) []*Combined {
+		var outs []*Combined
+		for i, c := range arr {
+			outs = append(outs, self.upgrade(c, pointerAppend(pointerAppend(path, keyword), strconv.Itoa(i)), false))
+		}
+		return outs
+	}
+	named := func(keyword, to string, m map[string]*jsm07.Combined) map[string]*Combined {
+		if m == nil {
+			return nil
+		}
+		outs := make(map[string]*Combined)
+		keys := s.OrderedKeys(keyword)
+		for _, k := range keys {
+			outs[k] = self.upgrade(m[k], pointerAppend(pointerAppend(path, keyword), k), false)
+		}
+		out.setKeyOrder(to, keys)
+		return outs
+	}
+
+	if s.Items != nil {
+		if s.Items.CombinedArray != nil {
+			out.PrefixItems = children("items", *s.Items.CombinedArray)
+			out.Items = child("additionalItems", s.AdditionalItems)
+		} else {
+			out.Items = child("items", s.Items.Combined)
+		}
+	}
+	out.MaxItems = s.MaxItems
+	out.MinItems = s.MinItems
+	out.UniqueItems = s.UniqueItems
+	out.Contains = child("contains", s.Contains)
+
+	out.MaxProperties = s.MaxProperties
+	out.MinProperties = s.MinProperties
+	out.Required = append([]string(nil), s.Required...)
+	out.AdditionalProperties = child("additionalProperties", s.AdditionalProperties)
+	out.PropertyNames = child("propertyNames", s.PropertyNames)
+	out.Properties = named("properties", "properties", s.Properties)
+	out.PatternProperties = named("patternProperties", "patternProperties", s.PatternProperties)
+	var requiredKeys, schemaKeys []string
+	for _, k := range s.OrderedKeys("dependencies") {
+		dep := s.Dependencies[k]
+		if dep == nil {
+			continue
+		}
+		if dep.StringArray != nil {
+			if out.DependentRequired == nil {
+				out.DependentRequired = make(map[string][]string)
+			}
+			out.DependentRequired[k] = append([]string{}, *dep.StringArray...)
+			requiredKeys = append(requiredKeys, k)
+		} else {
+			if out.DependentSchemas == nil {
+				out.DependentSchemas = make(map[string]*Combined)
+			}
+			out.DependentSchemas[k] = self.upgrade(dep.Combined, pointerAppend(pointerAppend(path, "dependencies"), k), false)
+			schemaKeys = append(schemaKeys, k)
+		}
+	}
+	out.setKeyOrder("dependentRequired", requiredKeys)
+	out.setKeyOrder("dependentSchemas", schemaKeys)
+
+	out.Defs = named("definitions", "$defs", s.Definitions)
+	out.If = child("if", s.If)
+	out.Then = child("then", s.Then)
+	out.Else = child("else", s.Else)
+	out.AllOf = children("allOf", s.AllOf)
+	out.AnyOf = children("anyOf", s.AnyOf)
+	out.OneOf = children("oneOf", s.OneOf)
+	out.Not = child("not", s.Not)
+
+	return NewCombinedWithSchema(out)
+}
+
+// hasRefSiblings07 reports whether s has keywords other than $ref that
+// affect validation.
+func hasRefSiblings07(s *jsm07.Schema) bool {
+	x := *s
+	x.Ref = nil
+	x.ID = nil
+	x.Common.Schema = nil
+	x.Comment = nil
+	x.Title = nil
+	x.Description = nil
+	x.Default = nil
+	x.Examples = nil
+	x.ReadOnly = nil
+	x.WriteOnly = nil
+	x.Definitions = nil
+	return !isEmpty07(&x)
+}
+
+func isEmpty07(s *jsm07.Schema) bool {
+	return s.Type == nil && s.Format == nil && s.ContentMediaType == nil && s.ContentEncoding == nil &&
+		s.Enumeration == nil && s.Const == nil &&
+		s.SchemaNumber == (jsm07.SchemaNumber{}) && s.SchemaString == (jsm07.SchemaString{}) &&
+		s.AdditionalItems == nil && s.Items == nil && s.MaxItems == nil && s.MinItems == nil && s.UniqueItems == nil && s.Contains == nil &&
+		s.MaxProperties == nil && s.MinProperties == nil && s.Required == nil && s.AdditionalProperties == nil &&
+		s.PropertyNames == nil && s.Properties == nil && s.PatternProperties == nil && s.Dependencies == nil &&
+		s.If == nil && s.Then == nil && s.Else == nil && s.AllOf == nil && s.AnyOf == nil && s.OneOf == nil && s.Not == nil
+}
+
+type downgrader struct {
+	root   *Schema
+	issues []*Incompatibility
+}
+
+func (self *downgrader) report(path, keyword, reason string) {
+	self.issues = append(self.issues, &Incompatibility{Pointer: path, Keyword: keyword, Reason: reason})
+}
+
+func (self *downgrader) downgrade(c *Combined, path string, top bool) *jsm07.Combined {
+	if c == nil {
+		return nil
+	}
+	if c.Schema == nil {
+		if c.Boolean == nil {
+			return &jsm07.Combined{}
+		}
+		return jsm07.NewCombinedWithBoolean(*c.Boolean)
+	}
+	s := c.Schema
+
+	out := &jsm07.Schema{
+		Type:         s.Type,
+		ReadOnly:     s.ReadOnly,
+		WriteOnly:    s.WriteOnly,
+		SchemaNumber: jsm07.SchemaNumber(s.SchemaNumber),
+		SchemaString: jsm07.SchemaString(s.SchemaString),
+	}
+	out.ID = s.ID
+	out.Common.Schema = s.Common.Schema
+	out.Format = s.Format
+	out.ContentMediaType = s.ContentMediaType
+	out.ContentEncoding = s.ContentEncoding
+	out.Comment = s.Comment
+	out.Title = s.Title
+	out.Description = s.Description
+	out.Enumeration = append([]SchemaEnumValue(nil), s.Enumeration...)
+	out.Const = s.Const
+	out.Default = s.Default
+	out.Examples = s.Examples
+
+	if top && out.Common.Schema != nil && strings.Contains(*out.Common.Schema, "2020-12") {
+		x := jsm07.Draft07
+		out.Common.Schema = &x
+	}
+
+	if s.Anchor != nil {
+		if out.ID == nil {
+			id := "#" + *s.Anchor
+			out.ID = &id
+		} else {
+			self.report(path, "$anchor", "$id is taken, dropped")
+		}
+	}
+	if s.DynamicAnchor != nil {
+		if out.ID == nil {
+			id := "#" + *s.DynamicAnchor
+			out.ID = &id
+			self.report(path, "$dynamicAnchor", "dynamic scope is not supported in draft-07, written as a plain anchor")
+		} else {
+			self.report(path, "$dynamicAnchor", "not supported in draft-07, dropped")
+		}
+	}
+
+	drop := func(keyword string, present bool) {
+		if present {
+			self.report(path, keyword, "not supported in draft-07, dropped")
+		}
+	}
+	drop("unevaluatedItems", s.UnevaluatedItems != nil)
+	drop("unevaluatedProperties", s.UnevaluatedProperties != nil)
+	drop("minContains", s.MinContains != nil)
+	drop("maxContains", s.MaxContains != nil)
+	drop("deprecated", s.Deprecated != nil)
+	drop("$vocabulary", s.Vocabulary != nil)
+	drop("contentSchema", s.ContentSchema != nil)
+	if s.DynamicRef != nil {
+		self.report(path, "$dynamicRef", "dynamic scope is not supported in draft-07, written as $ref")
+	}
+
+	child := func(keyword string, c *Combined) *jsm07.Combined {
+		return self.downgrade(c, pointerAppend(path, keyword), false)
+	}
+	children := func(keyword string, arr []*Combined) []*jsm07.Combined {
+		var outs []*jsm07.Combined
+		for i, c := range arr {
+			outs = append(outs, self.downgrade(c, pointerAppend(pointerAppend(path, keyword), strconv.Itoa(i)), false))
+		}
+		return outs
+	}
+	named := func(keyword, to string, m map[string]*Combined) map[string]*jsm07.Combined {
+		if m == nil {
+			return nil
+		}
+		outs := make(map[string]*jsm07.Combined)
+		keys := s.OrderedKeys(keyword)
+		for _, k := range keys {
+			outs[k] = self.downgrade(m[k], pointerAppend(pointerAppend(path, keyword), k), false)
+		}
+		setKeyOrder07(out, to, keys)
+		return outs
+	}
+	if s.PrefixItems != nil {
+		out.Items = jsm07.NewCombinedOrCombinedArrayWithCombinedArray(children("prefixItems", s.PrefixItems))
+		out.AdditionalItems = child("items", s.Items)
+	} else if s.Items != nil {
+		out.Items = jsm07.NewCombinedOrCombinedArrayWithCombined(child("items", s.Items))
+	}
+	out.MaxItems = s.MaxItems
+	out.MinItems = s.MinItems
+	out.UniqueItems = s.UniqueItems
+	out.Contains = child("contains", s.Contains)
+
+	out.MaxProperties = s.MaxProperties
+	out.MinProperties = s.MinProperties
+	out.Required = append([]string(nil), s.Required...)
+	out.AdditionalProperties = child("additionalProperties", s.AdditionalProperties)
+	out.PropertyNames = child("propertyNames", s.PropertyNames)
+	out.Properties = named("properties", "properties", s.Properties)
+	out.PatternProperties = named("patternProperties", "patternProperties", s.PatternProperties)
+	if s.DependentRequired != nil || s.DependentSchemas != nil {
+		out.Dependencies = make(map[string]*jsm07.CombinedOrStringArray)
+	}
+	// dependencies lists the keys of dependentRequired first
+	var depKeys []string
+	for _, k := range s.OrderedKeys("dependentRequired") {
+		out.Dependencies[k] = jsm07.NewCombinedOrStringArrayWithStringArray(append([]string{}, s.DependentRequired[k]...))
+		depKeys = append(depKeys, k)
+	}
+	for _, k := range s.OrderedKeys("dependentSchemas") {
+		dep := self.downgrade(s.DependentSchemas[k], pointerAppend(pointerAppend(path, "dependentSchemas"), k), false)
+		if prev, ok := out.Dependencies[k]; ok {
+			dep = jsm07.NewCombinedWithSchema(&jsm07.Schema{
+				SchemaObject: jsm07.SchemaObject{Required: *prev.StringArray},
+				AllOf:        []*jsm07.Combined{dep},
+			})
+		} else {
+			depKeys = append(depKeys, k)
+		}
+		out.Dependencies[k] = jsm07.NewCombinedOrStringArrayWithCombined(dep)
+	}
+	setKeyOrder07(out, "dependencies", depKeys)
+
+	out.Definitions = named("$defs", "definitions", s.Defs)
+	out.If = child("if", s.If)
+	out.Then = child("then", s.Then)
+	out.Else = child("else", s.Else)
+	out.AllOf = children("allOf", s.AllOf)
+	out.AnyOf = children("anyOf", s.AnyOf)
+	out.OneOf = children("oneOf", s.OneOf)
+	out.Not = child("not", s.Not)
+
+	ref := s.Ref
+	if s.DynamicRef != nil {
+		if ref == nil {
+			ref = s.DynamicRef
+		} else {
+			// both apply; keep the dynamic one in allOf
+			x := mapRef(*s.DynamicRef, self.downgradePointer)
+			out.AllOf = append(out.AllOf, jsm07.NewCombinedWithSchema(&jsm07.Schema{Ref: &x}))
+		}
+	}
+	if ref != nil {
+		x := mapRef(*ref, self.downgradePointer)
+		if !hasRefSiblings07(out) && out.ID == nil {
+			out.Ref = &x
+		} else {
+			// draft-07 ignores keywords next to $ref
+			out.AllOf = append([]*jsm07.Combined{jsm07.NewCombinedWithSchema(&jsm07.Schema{Ref: &x})}, out.AllOf...)
+		}
+	}
+
+	return jsm07.NewCombinedWithSchema(out)
+}
+
+// setKeyOrder07 records the order of the keys of keyword in a draft-07
+// schema, unless they are already sorted.
+func setKeyOrder07(s *jsm07.Schema, keyword string, keys []string) {
+	if sort.StringsAreSorted(keys) {
+		return
+	}
+	if s.KeyOrder == nil {
+		s.KeyOrder = make(map[string][]string)
+	}
+	s.KeyOrder[keyword] = keys
+}
+
+// mapRef rewrites the JSON Pointer fragment of ref with fn. Other
+// references, such as plain-name fragments, are returned unchanged.
+func mapRef(ref string, fn func([]string) []string) string {
+	i := strings.Index(ref, "#/")
+	if i < 0 {
+		return ref
+	}
+	tokens := strings.Split(ref[i+2:], "/")
+	return ref[:i] + "#/" + strings.Join(fn(tokens), "/")
+}
+
+// upgradePointer maps the reference tokens of a pointer into the draft-07
+// layout to the 2020-12 layout.
+func upgradePointer(tokens []string) []string {
+	out := make([]string, 0, len(tokens))
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i]
+		switch t {
+		case "definitions", "dependencies", "properties", "patternProperties", "allOf", "anyOf", "oneOf":
+			switch t {
+			case "definitions":
+				t = "$defs"
+			case "dependencies":
+				t = "dependentSchemas"
+			}
+			out = append(out, t)
+			if i+1 < len(tokens) {
+				i++
+				out = append(out, tokens[i])
+			}
+		case "items":
+			if i+1 < len(tokens) && isIndex(tokens[i+1]) {
+				i++
+				out = append(out, "prefixItems", tokens[i])
+			} else {
+				out = append(out, t)
+			}
+		case "additionalItems":
+			out = append(out, "items")
+		default:
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// downgradePointer maps the reference tokens of a pointer into the
+// 2020-12 layout to the draft-07 layout. The root is consulted to tell
+// whether items follows prefixItems; for other documents it is assumed
+// not to.
+func (self *downgrader) downgradePointer(tokens []string) []string {
+	out := make([]string, 0, len(tokens))
+	cur := self.root
+	for i := 0; i < len(tokens); i++ {
+		t := tokens[i]
+		var next *Combined
+		switch t {
+		case "$defs", "dependentSchemas", "properties", "patternProperties", "prefixItems", "allOf", "anyOf", "oneOf":
+			k := t
+			switch t {
+			case "$defs":
+				k = "definitions"
+			case "dependentSchemas":
+				k = "dependencies"
+			case "prefixItems":
+				k = "items"
+			}
+			out = append(out, k)
+			if i+1 >= len(tokens) {
+				break
+			}
+			i++
+			out = append(out, tokens[i])
+			if cur != nil {
+				name := strings.ReplaceAll(strings.ReplaceAll(tokens[i], "~1", "/"), "~0", "~")
+				switch t {
+				case "$defs":
+					next = cur.Defs[name]
+				case "dependentSchemas":
+					next = cur.DependentSchemas[name]
+				case "properties":
+					next = cur.Properties[name]
+				case "patternProperties":
+					next = cur.PatternProperties[name]
+				default:
+					arr := map[string][]*Combined{"prefixItems": cur.PrefixItems, "allOf": cur.AllOf, "anyOf": cur.AnyOf, "oneOf": cur.OneOf}[t]
+					if n, err := strconv.Atoi(name); err == nil && n >= 0 && n < len(arr) {
+						next = arr[n]
+					}
+				}
+			}
+		case "items":
+			if cur != nil && cur.PrefixItems != nil {
+				out = append(out, "additionalItems")
+			} else {
+				out = append(out, t)
+			}
+			if cur != nil {
+				next = cur.Items
+			}
+		default:
+			out = append(out, t)
+			if cur != nil {
+				next = map[string]*Combined{
+					"additionalProperties": cur.AdditionalProperties,
+					"propertyNames":        cur.PropertyNames,
+					"contains":             cur.Contains,
+					"if":                   cur.If,
+					"then":                 cur.Then,
+					"else":                 cur.Else,
+					"not":                  cur.Not,
+				}[t]
+			}
+		}
+		cur = nil
+		if next != nil {
+			cur = next.Schema
+		}
+	}
+	return out
+}
+
+func isIndex(token string) bool {
+	_, err := strconv.Atoi(token)
+	return err == nil
+}
+
+// pointerAppend appends one reference token to a JSON Pointer,
+// escaping "~" and "/" as RFC 6901 requires.
+func pointerAppend(path, token string) string {
+	token = strings.ReplaceAll(token, "~", "~0")
+	token = strings.ReplaceAll(token, "/", "~1")
+	return path + "/" + token
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,108 @@
+package jsm2020
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/genelet/hclschema/jsm07"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFromDraft07(t *testing.T) {
+	old := new(jsm07.Schema)
+	if err := json.Unmarshal([]byte(`{
+		"$schema": "http://json-schema.org/draft-07/schema#",
+		"type": "object",
+		"properties": {
+			"point": {"type": "array", "items": [{"type": "number"}, {"$ref": "#/definitions/Lon"}], "additionalItems": false},
+			"tags": {"items": {"type": "string"}},
+			"lon": {"$ref": "#/properties/point/items/1", "description": "ok"},
+			"lat": {"$ref": "#/definitions/Lon", "minimum": -90}
+		},
+		"dependencies": {"card": ["billing"], "billing": {"required": ["card"]}},
+		"definitions": {"Lon": {"$id": "#lon", "type": "number"}}
+	}`), old); err != nil {
+		t.Fatalf("Failed to unmarshal schema: %v", err)
+	}
+
+	got, issues := FromDraft07(old)
+	expected := new(Schema)
+	if err := json.Unmarshal([]byte(`{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"type": "object",
+		"properties": {
+			"point": {"type": "array", "prefixItems": [{"type": "number"}, {"$ref": "#/$defs/Lon"}], "items": false},
+			"tags": {"items": {"type": "string"}},
+			"lon": {"$ref": "#/properties/point/prefixItems/1", "description": "ok"},
+			"lat": {"$ref": "#/$defs/Lon", "minimum": -90}
+		},
+		"dependentRequired": {"card": ["billing"]},
+		"dependentSchemas": {"billing": {"required": ["card"]}},
+		"$defs": {"Lon": {"$anchor": "lon", "type": "number"}}
+	}`), expected); err != nil {
+		t.Fatalf("Failed to unmarshal expected schema: %v", err)
+	}
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Errorf("Schema mismatch (-want +got):\n%s", diff)
+	}
+	if len(issues) != 1 || issues[0].Pointer != "/properties/lat" || issues[0].Keyword != "$ref" {
+		t.Errorf("Expected one $ref issue at /properties/lat, got %v", issues)
+	}
+
+	// back to draft-07, keeping the $ref sibling in effect
+	back, issues := got.ToDraft07()
+	if len(issues) != 0 {
+		t.Errorf("Expected no issues, got %v", issues)
+	}
+	lat := back.Properties["lat"].Schema
+	if lat.Ref != nil || len(lat.AllOf) != 1 || *lat.AllOf[0].Schema.Ref != "#/definitions/Lon" {
+		t.Errorf("Expected $ref wrapped in allOf, got %#v", lat)
+	}
+	lat.Ref, lat.AllOf = lat.AllOf[0].Schema.Ref, nil
+	if diff := cmp.Diff(old, back); diff != "" {
+		t.Errorf("Round trip mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestToDraft07(t *testing.T) {
+	s := new(Schema)
+	if err := json.Unmarshal([]byte(`{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"$dynamicAnchor": "node",
+		"properties": {
+			"children": {"type": "array", "items": {"$dynamicRef": "#node"}, "minContains": 1},
+			"point": {"prefixItems": [true], "items": {"$ref": "#/properties/point/items"}}
+		},
+		"dependentRequired": {"card": ["billing"]},
+		"dependentSchemas": {"card": {"properties": {"billing": {"type": "string"}}}},
+		"unevaluatedProperties": false
+	}`), s); err != nil {
+		t.Fatalf("Failed to unmarshal schema: %v", err)
+	}
+
+	got, issues := s.ToDraft07()
+	expected := new(jsm07.Schema)
+	if err := json.Unmarshal([]byte(`{
+		"$schema": "http://json-schema.org/draft-07/schema#",
+		"$id": "#node",
+		"properties": {
+			"children": {"type": "array", "items": {"$ref": "#node"}},
+			"point": {"items": [true], "additionalItems": {"$ref": "#/properties/point/additionalItems"}}
+		},
+		"dependencies": {"card": {"required": ["billing"], "allOf": [{"properties": {"billing": {"type": "string"}}}]}}
+	}`), expected); err != nil {
+		t.Fatalf("Failed to unmarshal expected schema: %v", err)
+	}
+	if diff := cmp.Diff(expected, got); diff != "" {
+		t.Errorf("Schema mismatch (-want +got):\n%s", diff)
+	}
+
+	keywords := []string{}
+	for _, issue := range issues {
+		keywords = append(keywords, issue.Pointer+" "+issue.Keyword)
+	}
+	want := []string{" $dynamicAnchor", " unevaluatedProperties", "/properties/children minContains", "/properties/children/items $dynamicRef"}
+	if diff := cmp.Diff(want, keywords); diff != "" {
+		t.Errorf("Issues mismatch (-want +got):\n%s", diff)
+	}
+}
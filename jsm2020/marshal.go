@@ -0,0 +1,496 @@
+package jsm2020
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+
+	"github.com/genelet/hcllight/light"
+	"github.com/genelet/hclschema/internal/lightexpr"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func assignRaw(attrs map[string]*light.Attribute, key string, val *json.RawMessage) (bool, error) {
+	if val == nil {
+		return false, nil
+	}
+	expr, err := lightexpr.FromRaw(*val)
+	if err != nil {
+		return false, err
+	}
+	attrs[key] = &light.Attribute{
+		Name: key,
+		Expr: expr,
+	}
+	return true, nil
+}
+
+func assignInt64(attrs map[string]*light.Attribute, key string, val *int64) bool {
+	if val == nil {
+		return false
+	}
+	attrs[key] = &light.Attribute{
+		Name: key,
+		Expr: light.Int64ToLiteralValueExpr(*val),
+	}
+	return true
+}
+
+func assignBool(attrs map[string]*light.Attribute, key string, val *bool) bool {
+	if val == nil {
+		return false
+	}
+	attrs[key] = &light.Attribute{
+		Name: key,
+		Expr: light.BooleanToLiteralValueExpr(*val),
+	}
+	return true
+}
+
+func assignIntegerOrFloat(attrs map[string]*light.Attribute, key string, val *IntegerOrFloat) bool {
+	if val == nil {
+		return false
+	}
+	if val.Float != nil {
+		attrs[key] = &light.Attribute{
+			Name: key,
+			Expr: light.Float64ToLiteralValueExpr(*val.Float),
+		}
+	} else {
+		attrs[key] = &light.Attribute{
+			Name: key,
+			Expr: light.Int64ToLiteralValueExpr(*val.Integer),
+		}
+	}
+	return true
+}
+
+func assignString(attrs map[string]*light.Attribute, key string, val *string) bool {
+	if val == nil {
+		return false
+	}
+	if key[0] == '$' {
+		key = `_` + key[1:]
+	}
+	attrs[key] = &light.Attribute{
+		Name: key,
+		Expr: lightexpr.String(*val),
+	}
+	return true
+}
+
+func assignCombined(attrs map[string]*light.Attribute, key string, val *Combined) bool {
+	if val == nil || val.Boolean == nil {
+		return false
+	}
+
+	attrs[key] = &light.Attribute{
+		Name: key,
+		Expr: light.BooleanToLiteralValueExpr(*val.Boolean),
+	}
+	return true
+}
+
+func assignEnum(attrs map[string]*light.Attribute, key string, val []SchemaEnumValue) (bool, error) {
+	if val == nil {
+		return false, nil
+	}
+
+	var arr []interface{}
+	for _, v := range val {
+		bs, err := json.Marshal(&v)
+		if err != nil {
+			return false, err
+		}
+		var x interface{}
+		if err := json.Unmarshal(bs, &x); err != nil {
+			return false, err
+		}
+		arr = append(arr, x)
+	}
+
+	attrs[key] = &light.Attribute{
+		Name: key,
+		Expr: lightexpr.FromInterface(arr),
+	}
+	return true, nil
+}
+
+func assignStringArrayMap(attrs map[string]*light.Attribute, key string, val map[string][]string) bool {
+	if val == nil {
+		return false
+	}
+
+	obj := make(map[string]interface{})
+	for k, v := range val {
+		arr := []interface{}{}
+		for _, x := range v {
+			arr = append(arr, x)
+		}
+		obj[k] = arr
+	}
+	attrs[key] = &light.Attribute{
+		Name: key,
+		Expr: lightexpr.FromInterface(obj),
+	}
+	return true
+}
+
+func assignBoolMap(attrs map[string]*light.Attribute, key string, val map[string]bool) bool {
+	if val == nil {
+		return false
+	}
+
+	obj := make(map[string]interface{})
+	for k, v := range val {
+		obj[k] = v
+	}
+	attrs[key] = &light.Attribute{
+		Name: key,
+		Expr: lightexpr.FromInterface(obj),
+	}
+	return true
+}
+
+func (self *Schema) MarshalHCL() ([]byte, error) {
+	attrs := map[string]*light.Attribute{}
+
+	trimmed := *self
+
+	if trimmed.Type != nil {
+		if trimmed.Type.String != nil {
+			attrs["type"] = &light.Attribute{
+				Name: "type",
+				Expr: lightexpr.String(*trimmed.Type.String),
+			}
+		} else {
+			attrs["type"] = &light.Attribute{
+				Name: "type",
+				Expr: light.StringArrayToTupleConsEpr(*trimmed.Type.StringArray),
+			}
+		}
+		trimmed.Type = nil
+	}
+
+	if assignString(attrs, "$id", trimmed.ID) {
+		trimmed.ID = nil
+	}
+	if assignString(attrs, "$ref", trimmed.Ref) {
+		trimmed.Ref = nil
+	}
+	if assignString(attrs, "$dynamicRef", trimmed.DynamicRef) {
+		trimmed.DynamicRef = nil
+	}
+	if assignString(attrs, "$schema", trimmed.Schema) {
+		trimmed.Schema = nil
+	}
+	if assignString(attrs, "$anchor", trimmed.Anchor) {
+		trimmed.Anchor = nil
+	}
+	if assignString(attrs, "$dynamicAnchor", trimmed.DynamicAnchor) {
+		trimmed.DynamicAnchor = nil
+	}
+	if assignString(attrs, "$comment", trimmed.Comment) {
+		trimmed.Comment = nil
+	}
+	if assignString(attrs, "format", trimmed.Format) {
+		trimmed.Format = nil
+	}
+	if assignString(attrs, "contentMediaType", trimmed.ContentMediaType) {
+		trimmed.ContentMediaType = nil
+	}
+	if assignString(attrs, "contentEncoding", trimmed.ContentEncoding) {
+		trimmed.ContentEncoding = nil
+	}
+	if assignString(attrs, "title", trimmed.Title) {
+		trimmed.Title = nil
+	}
+	if assignString(attrs, "description", trimmed.Description) {
+		trimmed.Description = nil
+	}
+	if assignString(attrs, "pattern", trimmed.Pattern) {
+		trimmed.Pattern = nil
+	}
+
+	if assignInt64(attrs, "maxLength", trimmed.MaxLength) {
+		trimmed.MaxLength = nil
+	}
+	if assignInt64(attrs, "minLength", trimmed.MinLength) {
+		trimmed.MinLength = nil
+	}
+	if assignInt64(attrs, "maxItems", trimmed.MaxItems) {
+		trimmed.MaxItems = nil
+	}
+	if assignInt64(attrs, "minItems", trimmed.MinItems) {
+		trimmed.MinItems = nil
+	}
+	if assignInt64(attrs, "maxContains", trimmed.MaxContains) {
+		trimmed.MaxContains = nil
+	}
+	if assignInt64(attrs, "minContains", trimmed.MinContains) {
+		trimmed.MinContains = nil
+	}
+	if assignInt64(attrs, "maxProperties", trimmed.MaxProperties) {
+		trimmed.MaxProperties = nil
+	}
+	if assignInt64(attrs, "minProperties", trimmed.MinProperties) {
+		trimmed.MinProperties = nil
+	}
+
+	if assignBool(attrs, "readOnly", trimmed.ReadOnly) {
+		trimmed.ReadOnly = nil
+	}
+	if assignBool(attrs, "writeOnly", trimmed.WriteOnly) {
+		trimmed.WriteOnly = nil
+	}
+	if assignBool(attrs, "deprecated", trimmed.Deprecated) {
+		trimmed.Deprecated = nil
+	}
+	if assignBool(attrs, "uniqueItems", trimmed.UniqueItems) {
+		trimmed.UniqueItems = nil
+	}
+
+	for _, raw := range []struct {
+		key string
+		val **json.RawMessage
+	}{
+		{"const", &trimmed.Const},
+		{"default", &trimmed.Default},
+		{"examples", &trimmed.Examples},
+	} {
+		ok, err := assignRaw(attrs, raw.key, *raw.val)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			*raw.val = nil
+		}
+	}
+
+	if assignIntegerOrFloat(attrs, "multipleOf", trimmed.MultipleOf) {
+		trimmed.MultipleOf = nil
+	}
+	if assignIntegerOrFloat(attrs, "maximum", trimmed.Maximum) {
+		trimmed.Maximum = nil
+	}
+	if assignIntegerOrFloat(attrs, "exclusiveMaximum", trimmed.ExclusiveMaximum) {
+		trimmed.ExclusiveMaximum = nil
+	}
+	if assignIntegerOrFloat(attrs, "minimum", trimmed.Minimum) {
+		trimmed.Minimum = nil
+	}
+	if assignIntegerOrFloat(attrs, "exclusiveMinimum", trimmed.ExclusiveMinimum) {
+		trimmed.ExclusiveMinimum = nil
+	}
+
+	if assignCombined(attrs, "items", trimmed.Items) {
+		trimmed.Items = nil
+	}
+	if assignCombined(attrs, "unevaluatedItems", trimmed.UnevaluatedItems) {
+		trimmed.UnevaluatedItems = nil
+	}
+	if assignCombined(attrs, "additionalProperties", trimmed.AdditionalProperties) {
+		trimmed.AdditionalProperties = nil
+	}
+	if assignCombined(attrs, "unevaluatedProperties", trimmed.UnevaluatedProperties) {
+		trimmed.UnevaluatedProperties = nil
+	}
+	if assignCombined(attrs, "propertyNames", trimmed.PropertyNames) {
+		trimmed.PropertyNames = nil
+	}
+	if assignCombined(attrs, "contains", trimmed.Contains) {
+		trimmed.Contains = nil
+	}
+	if assignCombined(attrs, "contentSchema", trimmed.ContentSchema) {
+		trimmed.ContentSchema = nil
+	}
+	if assignCombined(attrs, "if", trimmed.If) {
+		trimmed.If = nil
+	}
+	if assignCombined(attrs, "then", trimmed.Then) {
+		trimmed.Then = nil
+	}
+	if assignCombined(attrs, "else", trimmed.Else) {
+		trimmed.Else = nil
+	}
+	if assignCombined(attrs, "not", trimmed.Not) {
+		trimmed.Not = nil
+	}
+
+	ok, err := assignEnum(attrs, "enum", trimmed.Enumeration)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		trimmed.Enumeration = nil
+	}
+	if trimmed.Required != nil {
+		attrs["required"] = &light.Attribute{
+			Name: "required",
+			Expr: light.StringArrayToTupleConsEpr(trimmed.Required),
+		}
+		trimmed.Required = nil
+	}
+	if assignStringArrayMap(attrs, "dependentRequired", trimmed.DependentRequired) {
+		trimmed.DependentRequired = nil
+	}
+	if assignBoolMap(attrs, "_vocabulary", trimmed.Vocabulary) {
+		trimmed.Vocabulary = nil
+	}
+
+	// subschemas are written as blocks, one per line, since HCL does not
+	// allow a block with several arguments on a single line
+	var blocks bytes.Buffer
+	if err := trimmed.marshalBlocks(&blocks); err != nil {
+		return nil, err
+	}
+	if len(attrs) == 0 {
+		return []byte("  " + strings.TrimSpace(blocks.String())), nil
+	}
+
+	body := &light.Body{
+		Attributes: attrs,
+	}
+	data, err := body.MarshalHCL()
+	if err != nil {
+		return nil, err
+	}
+
+	str := "  " + strings.TrimSpace(string(data)+"\n"+blocks.String())
+	return []byte(str), nil
+}
+
+// marshalBlocks writes the subschemas left in self as blocks, labeled by
+// their keys for properties, patternProperties, dependentSchemas and
+// $defs, and repeated for prefixItems, allOf, anyOf and oneOf.
+func (self *Schema) marshalBlocks(buf *bytes.Buffer) error {
+	single := func(keyword string, c *Combined) error {
+		if c == nil {
+			return nil
+		}
+		body, err := marshalCombinedBody(c)
+		if err != nil {
+			return err
+		}
+		writeBlock(buf, keyword, nil, body)
+		return nil
+	}
+	many := func(keyword string, arr []*Combined) error {
+		for _, c := range arr {
+			body, err := marshalCombinedBody(c)
+			if err != nil {
+				return err
+			}
+			writeBlock(buf, keyword, nil, body)
+		}
+		return nil
+	}
+	keyed := func(keyword, name string, m map[string]*Combined) error {
+		for _, k := range self.OrderedKeys(keyword) {
+			body, err := marshalCombinedBody(m[k])
+			if err != nil {
+				return err
+			}
+			writeBlock(buf, name, &k, body)
+		}
+		return nil
+	}
+
+	if err := many("prefixItems", self.PrefixItems); err != nil {
+		return err
+	}
+	for _, x := range []struct {
+		keyword string
+		c       *Combined
+	}{
+		{"items", self.Items},
+		{"unevaluatedItems", self.UnevaluatedItems},
+		{"contains", self.Contains},
+		{"additionalProperties", self.AdditionalProperties},
+		{"unevaluatedProperties", self.UnevaluatedProperties},
+		{"propertyNames", self.PropertyNames},
+	} {
+		if err := single(x.keyword, x.c); err != nil {
+			return err
+		}
+	}
+
+	// an empty properties block stands for an empty map
+	if self.Properties != nil && len(self.Properties) == 0 {
+		writeBlock(buf, "properties", nil, nil)
+	}
+	for _, x := range []struct {
+		keyword string
+		name    string
+		m       map[string]*Combined
+	}{
+		{"properties", "properties", self.Properties},
+		{"patternProperties", "patternProperties", self.PatternProperties},
+		{"dependentSchemas", "dependentSchemas", self.DependentSchemas},
+		{"$defs", "_defs", self.Defs},
+	} {
+		if err := keyed(x.keyword, x.name, x.m); err != nil {
+			return err
+		}
+	}
+
+	for _, x := range []struct {
+		keyword string
+		c       *Combined
+	}{
+		{"contentSchema", self.ContentSchema},
+		{"if", self.If},
+		{"then", self.Then},
+		{"else", self.Else},
+	} {
+		if err := single(x.keyword, x.c); err != nil {
+			return err
+		}
+	}
+	for _, x := range []struct {
+		keyword string
+		arr     []*Combined
+	}{
+		{"allOf", self.AllOf},
+		{"anyOf", self.AnyOf},
+		{"oneOf", self.OneOf},
+	} {
+		if err := many(x.keyword, x.arr); err != nil {
+			return err
+		}
+	}
+	return single("not", self.Not)
+}
+
+// marshalCombinedBody returns the body of a block holding c. An empty
+// block stands for true and a block with an empty not for false.
+func marshalCombinedBody(c *Combined) ([]byte, error) {
+	switch {
+	case c == nil:
+		return nil, nil
+	case c.Schema != nil:
+		return c.Schema.MarshalHCL()
+	case c.Boolean != nil && !*c.Boolean:
+		return []byte("not {\n}"), nil
+	default:
+	}
+	return nil, nil
+}
+
+func writeBlock(buf *bytes.Buffer, keyword string, label *string, body []byte) {
+	buf.WriteString(keyword + " ")
+	if label != nil {
+		buf.Write(hclwrite.TokensForValue(cty.StringVal(*label)).Bytes())
+		buf.WriteString(" ")
+	}
+	buf.WriteString("{\n")
+	// the body is indented unevenly; format it before indenting it again
+	body = hclwrite.Format([]byte(strings.TrimSpace(string(body))))
+	for _, line := range strings.Split(string(body), "\n") {
+		if line != "" {
+			buf.WriteString("  " + line + "\n")
+		}
+	}
+	buf.WriteString("}\n")
+}
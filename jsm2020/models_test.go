@@ -0,0 +1,203 @@
+package jsm2020
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/genelet/determined/dethcl"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestSchemaJSON(t *testing.T) {
+	data := `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"$id": "https://example.com/tree",
+		"$dynamicAnchor": "node",
+		"type": "object",
+		"properties": {
+			"children": {"type": "array", "items": {"$dynamicRef": "#node"}, "maxContains": 3},
+			"point": {"prefixItems": [{"type": "number"}, {"$ref": "#/$defs/lon"}], "items": false}
+		},
+		"dependentRequired": {"credit_card": ["billing_address"]},
+		"dependentSchemas": {"credit_card": {"required": ["cvv"]}},
+		"unevaluatedProperties": false,
+		"$defs": {"lon": {"$anchor": "lon", "type": "number"}}
+	}`
+	schema := new(Schema)
+	if err := json.Unmarshal([]byte(data), schema); err != nil {
+		t.Fatalf("Failed to unmarshal schema: %v", err)
+	}
+	point := schema.Properties["point"].Schema
+	if len(point.PrefixItems) != 2 || point.Items.Boolean == nil || *point.Items.Boolean {
+		t.Errorf("Unexpected prefixItems and items: %#v", point.SchemaArray)
+	}
+	if schema.Defs["lon"] == nil || schema.DependentSchemas["credit_card"] == nil {
+		t.Errorf("Expected $defs and dependentSchemas, got %#v", schema)
+	}
+
+	bs, err := json.Marshal(schema)
+	if err != nil {
+		t.Fatalf("Failed to marshal schema: %v", err)
+	}
+	schema1 := new(Schema)
+	if err := json.Unmarshal(bs, schema1); err != nil {
+		t.Fatalf("Failed to unmarshal marshalled schema: %v", err)
+	}
+	if diff := cmp.Diff(schema, schema1); diff != "" {
+		t.Errorf("Schema mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestParseSchema(t *testing.T) {
+	schema, err := ParseSchema([]byte(`
+  _schema = "https://json-schema.org/draft/2020-12/schema"
+  _dynamicAnchor = "node"
+  type = "object"
+  dependentRequired = {
+    credit_card = ["billing_address"]
+  }
+  unevaluatedProperties = false
+  properties "children" {
+    type = "array"
+    items {
+      _dynamicRef = "#node"
+    }
+    maxContains = 3
+  }
+  properties "point" {
+    prefixItems {
+      type = "number"
+      minimum = -90
+    }
+    items = false
+  }
+  dependentSchemas "credit_card" {
+    required = ["cvv"]
+  }
+  _defs "lon" {
+    _anchor = "lon"
+    type = "number"
+    enum = [0, 1.5, "east", false]
+  }
+`))
+	if err != nil {
+		t.Fatalf("Failed to parse HCL: %v", err)
+	}
+
+	expected := new(Schema)
+	if err := json.Unmarshal([]byte(`{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"$dynamicAnchor": "node",
+		"type": "object",
+		"dependentRequired": {"credit_card": ["billing_address"]},
+		"unevaluatedProperties": false,
+		"properties": {
+			"children": {"type": "array", "items": {"$dynamicRef": "#node"}, "maxContains": 3},
+			"point": {"prefixItems": [{"type": "number", "minimum": -90}], "items": false}
+		},
+		"dependentSchemas": {"credit_card": {"required": ["cvv"]}},
+		"$defs": {"lon": {"$anchor": "lon", "type": "number", "enum": [0, 1.5, "east", false]}}
+	}`), expected); err != nil {
+		t.Fatalf("Failed to unmarshal schema: %v", err)
+	}
+	if diff := cmp.Diff(expected, schema); diff != "" {
+		t.Errorf("Schema mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestSchemaHCL(t *testing.T) {
+	schema := new(Schema)
+	if err := json.Unmarshal([]byte(`{
+		"$vocabulary": {"https://json-schema.org/draft/2020-12/vocab/core": true},
+		"type": ["array", "null"],
+		"deprecated": true,
+		"const": [{"a":[1,-2.5,"x",true]}],
+		"default": "none",
+		"minimum": -90,
+		"exclusiveMaximum": 180.5,
+		"enum": [0, 1.5, "east", false],
+		"items": false,
+		"unevaluatedItems": true,
+		"dependentRequired": {"credit_card": ["billing_address"]}
+	}`), schema); err != nil {
+		t.Fatalf("Failed to unmarshal schema: %v", err)
+	}
+
+	bs, err := dethcl.Marshal(schema)
+	if err != nil {
+		t.Fatalf("Failed to marshal schema to HCL: %v", err)
+	}
+	parsed, err := ParseSchema(bs)
+	if err != nil {
+		t.Fatalf("Failed to parse HCL: %v\n%s", err, bs)
+	}
+	if diff := cmp.Diff(schema, parsed); diff != "" {
+		t.Errorf("Schema mismatch (-want +got):\n%s\n%s", diff, bs)
+	}
+}
+
+func TestSchemaHCLNested(t *testing.T) {
+	schema := new(Schema)
+	if err := json.Unmarshal([]byte(`{
+		"type": "object",
+		"required": ["a"],
+		"properties": {
+			"b": {"type": "integer", "minimum": 3},
+			"a": {"type": "array", "prefixItems": [true, {"type": "string", "maxLength": 2}, false], "items": {"type": "number", "multipleOf": 0.5}}
+		},
+		"$defs": {"x": {"allOf": [{"required": ["p"], "minProperties": 1}, true], "not": {"type": "null", "title": "no"}}}
+	}`), schema); err != nil {
+		t.Fatalf("Failed to unmarshal schema: %v", err)
+	}
+
+	bs, err := dethcl.Marshal(schema)
+	if err != nil {
+		t.Fatalf("Failed to marshal schema to HCL: %v", err)
+	}
+	parsed, err := ParseSchema(bs)
+	if err != nil {
+		t.Fatalf("Failed to parse HCL: %v\n%s", err, bs)
+	}
+
+	// in blocks, true is written as an empty schema and false as an empty not
+	expected := new(Schema)
+	if err := json.Unmarshal([]byte(`{
+		"type": "object",
+		"required": ["a"],
+		"properties": {
+			"b": {"type": "integer", "minimum": 3},
+			"a": {"type": "array", "prefixItems": [{}, {"type": "string", "maxLength": 2}, {"not": {}}], "items": {"type": "number", "multipleOf": 0.5}}
+		},
+		"$defs": {"x": {"allOf": [{"required": ["p"], "minProperties": 1}, {}], "not": {"type": "null", "title": "no"}}}
+	}`), expected); err != nil {
+		t.Fatalf("Failed to unmarshal expected schema: %v", err)
+	}
+	if diff := cmp.Diff(expected, parsed); diff != "" {
+		t.Errorf("Schema mismatch (-want +got):\n%s\n%s", diff, bs)
+	}
+}
+
+func TestSchemaHCLStrings(t *testing.T) {
+	schema := new(Schema)
+	if err := json.Unmarshal([]byte(`{
+		"type": "string",
+		"title": "say \"hi\"",
+		"description": "line one\nline two \\ ${x} %{y}",
+		"pattern": "^\\d+$",
+		"$comment": "${not} a template"
+	}`), schema); err != nil {
+		t.Fatalf("Failed to unmarshal schema: %v", err)
+	}
+
+	bs, err := dethcl.Marshal(schema)
+	if err != nil {
+		t.Fatalf("Failed to marshal schema to HCL: %v", err)
+	}
+	parsed, err := ParseSchema(bs)
+	if err != nil {
+		t.Fatalf("Failed to parse HCL: %v\n%s", err, bs)
+	}
+	if diff := cmp.Diff(schema, parsed); diff != "" {
+		t.Errorf("Schema mismatch (-want +got):\n%s\n%s", diff, bs)
+	}
+}
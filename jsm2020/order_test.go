@@ -0,0 +1,33 @@
+package jsm2020
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestKeyOrderJSON(t *testing.T) {
+	input := `{"properties":{"z":{"type":"string"},"a":true},"dependentRequired":{"y":["z"],"b":["a"]},"$defs":{"Z":{},"A":{"properties":{"y":true,"x":false}}}}`
+	s := new(Schema)
+	if err := json.Unmarshal([]byte(input), s); err != nil {
+		t.Fatalf("Failed to unmarshal schema: %v", err)
+	}
+	if got := strings.Join(s.OrderedKeys("properties"), ","); got != "z,a" {
+		t.Errorf("Expected properties z,a, got %s", got)
+	}
+	bs, err := json.Marshal(s)
+	if err != nil {
+		t.Fatalf("Failed to marshal schema: %v", err)
+	}
+	if string(bs) != `{"dependentRequired":{"y":["z"],"b":["a"]},"properties":{"z":{"type":"string"},"a":true},"$defs":{"Z":{},"A":{"properties":{"y":true,"x":false}}}}` {
+		t.Errorf("Expected the key order kept, got %s", bs)
+	}
+
+	back, _ := s.ToDraft07()
+	if got := strings.Join(back.OrderedKeys("definitions"), ","); got != "Z,A" {
+		t.Errorf("Expected definitions Z,A, got %s", got)
+	}
+	if got := strings.Join(back.OrderedKeys("dependencies"), ","); got != "y,b" {
+		t.Errorf("Expected dependencies y,b, got %s", got)
+	}
+}
@@ -0,0 +1,518 @@
+// Package lint reports common mistakes in draft-07 schemas, such as
+// required properties that are not defined, bounds in the wrong order,
+// invalid patterns, dangling references, unused definitions, defaults
+// that fail their own schema and keywords that do not apply to the type.
+//
+// Each check is a rule that can be enabled or disabled by name. Schemas
+// linted from HCL report the file, line and column of every problem.
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"regexp"
+	"regexp/syntax"
+	"sort"
+	"strings"
+
+	"github.com/genelet/hclschema/jsm07"
+	"github.com/hashicorp/hcl/v2"
+)
+
+// Rule is one check of the linter.
+type Rule struct {
+	Name        string
+	Description string
+	check       func(l *linter, ptr string, s *jsm07.Schema) // on every schema
+	finish      func(l *linter)                              // once, after the walk
+}
+
+// Rules lists every rule, in the order they run.
+var Rules = []*Rule{
+	{"required-undefined", "required names a property not defined in properties", checkRequired, nil},
+	{"bounds-order", "a minimum is greater than the matching maximum", checkBounds, nil},
+	{"invalid-pattern", "pattern or a patternProperties key is not a valid regular expression", checkPatterns, nil},
+	{"dangling-ref", "$ref points to no schema in the document", checkRef, nil},
+	{"unused-definition", "a definition is not referenced from the rest of the schema", nil, checkUnused},
+	{"invalid-default", "default does not validate against its own schema", checkDefault, nil},
+	{"type-mismatch", "a keyword, const or enum value does not apply to the type", checkType, nil},
+}
+
+// Options controls which rules run.
+type Options struct {
+	// Rules lists the names of the rules to run; nil runs all of them.
+	Rules []string
+	// Disable lists the names of rules not to run.
+	Disable []string
+	// Filename is reported in the ranges of problems found in HCL.
+	Filename string
+	// Strict reports unknown HCL attributes and blocks as errors.
+	Strict bool
+}
+
+// Problem is one finding of a rule.
+type Problem struct {
+	Rule string
+	// Pointer is a JSON Pointer to the schema holding the problem, and
+	// Keyword the keyword in it, if any.
+	Pointer string
+	Keyword string
+	Message string
+	// Range locates the problem in HCL input, and is nil otherwise.
+	Range *hcl.Range
+}
+
+func (self *Problem) String() string {
+	path := self.Pointer
+	if path == "" {
+		path = "/"
+	}
+	if self.Range != nil {
+		return fmt.Sprintf("%s: %s: %s (%s)", self.Range, path, self.Message, self.Rule)
+	}
+	return fmt.Sprintf("%s: %s (%s)", path, self.Message, self.Rule)
+}
+
+// Diagnostic returns the problem as an HCL warning.
+func (self *Problem) Diagnostic() *hcl.Diagnostic {
+	return &hcl.Diagnostic{
+		Severity: hcl.DiagWarning,
+		Summary:  self.Message,
+		Detail:   fmt.Sprintf("Reported by rule %s at %s.", self.Rule, pointerText(self.Pointer)),
+		Subject:  self.Range,
+	}
+}
+
+// Lint runs the rules selected by opts over the schema, and returns the
+// problems found in document order.
+func Lint(schema *jsm07.Schema, opts *Options) ([]*Problem, error) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	rules, err := selectRules(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &linter{root: schema}
+	jsm07.Walk(schema, func(ptr jsm07.JSONPointer, s *jsm07.Schema) error {
+		for _, rule := range rules {
+			if rule.check != nil {
+				l.rule = rule.Name
+				rule.check(l, string(ptr), s)
+			}
+		}
+		return nil
+	})
+	for _, rule := range rules {
+		if rule.finish != nil {
+			l.rule = rule.Name
+			rule.finish(l)
+		}
+	}
+	return l.problems, nil
+}
+
+// LintHCL parses an HCL schema and lints it, locating each problem in
+// data. Parse errors are returned as diagnostics, with no problems.
+func LintHCL(data []byte, opts *Options) ([]*Problem, hcl.Diagnostics) {
+	if opts == nil {
+		opts = &Options{}
+	}
+	schema, diags := jsm07.ParseSchemaFile(data, &jsm07.ParseOptions{Filename: opts.Filename, Strict: opts.Strict})
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	problems, err := Lint(schema, opts)
+	if err != nil {
+		return nil, append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Invalid lint options",
+			Detail:   err.Error(),
+		})
+	}
+
+	ranges := locate(data, opts.Filename)
+	for _, p := range problems {
+		p.Range = ranges.find(p.Pointer, p.Keyword)
+	}
+	return problems, diags
+}
+
+func selectRules(opts *Options) ([]*Rule, error) {
+	known := make(map[string]bool)
+	for _, rule := range Rules {
+		known[rule.Name] = true
+	}
+	for _, name := range append(append([]string{}, opts.Rules...), opts.Disable...) {
+		if !known[name] {
+			return nil, fmt.Errorf("unknown rule %q", name)
+		}
+	}
+
+	enabled := make(map[string]bool)
+	for _, name := range opts.Rules {
+		enabled[name] = true
+	}
+	disabled := make(map[string]bool)
+	for _, name := range opts.Disable {
+		disabled[name] = true
+	}
+	var rules []*Rule
+	for _, rule := range Rules {
+		if (opts.Rules == nil || enabled[rule.Name]) && !disabled[rule.Name] {
+			rules = append(rules, rule)
+		}
+	}
+	return rules, nil
+}
+
+type linter struct {
+	root     *jsm07.Schema
+	rule     string
+	problems []*Problem
+}
+
+func (self *linter) report(ptr, keyword, format string, args ...interface{}) {
+	self.problems = append(self.problems, &Problem{
+		Rule:    self.rule,
+		Pointer: ptr,
+		Keyword: keyword,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+func checkRequired(l *linter, ptr string, s *jsm07.Schema) {
+	closed := s.AdditionalProperties != nil && s.AdditionalProperties.Boolean != nil && !*s.AdditionalProperties.Boolean
+	if len(s.Required) == 0 || (s.Properties == nil && !closed) {
+		return
+	}
+	for _, name := range s.Required {
+		if _, ok := s.Properties[name]; ok || matchesPattern(s, name) || inBranches(l.root, s, name) {
+			continue
+		}
+		l.report(ptr, "required", "required property %q is not defined in properties", name)
+	}
+}
+
+func matchesPattern(s *jsm07.Schema, name string) bool {
+	for pattern := range s.PatternProperties {
+		if re, err := regexp.Compile(pattern); err == nil && re.MatchString(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// inBranches reports whether an allOf, anyOf or oneOf branch of s, after
+// local references, defines the property.
+func inBranches(root, s *jsm07.Schema, name string) bool {
+	for _, branches := range [][]*jsm07.Combined{s.AllOf, s.AnyOf, s.OneOf} {
+		for _, c := range branches {
+			for i := 0; c != nil && c.Schema != nil && c.Schema.Ref != nil && i < 16; i++ {
+				target, err := root.Resolve(*c.Schema.Ref)
+				if err != nil {
+					break
+				}
+				c = target
+			}
+			if c != nil && c.Schema != nil {
+				if _, ok := c.Schema.Properties[name]; ok {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+func checkBounds(l *linter, ptr string, s *jsm07.Schema) {
+	numbers := []struct {
+		min, max       string
+		minVal, maxVal *jsm07.IntegerOrFloat
+		strict         bool
+	}{
+		{"minimum", "maximum", s.Minimum, s.Maximum, false},
+		{"exclusiveMinimum", "exclusiveMaximum", s.ExclusiveMinimum, s.ExclusiveMaximum, true},
+		{"minimum", "exclusiveMaximum", s.Minimum, s.ExclusiveMaximum, true},
+		{"exclusiveMinimum", "maximum", s.ExclusiveMinimum, s.Maximum, true},
+	}
+	for _, n := range numbers {
+		if n.minVal == nil || n.maxVal == nil {
+			continue
+		}
+		min, max := ratOf(n.minVal), ratOf(n.maxVal)
+		if min == nil || max == nil {
+			continue
+		}
+		switch cmp := min.Cmp(max); {
+		case cmp > 0:
+			l.report(ptr, n.min, "%s %s is greater than %s %s", n.min, min.RatString(), n.max, max.RatString())
+		case cmp == 0 && n.strict:
+			l.report(ptr, n.min, "%s %s leaves no value below %s %s", n.min, min.RatString(), n.max, max.RatString())
+		default:
+		}
+	}
+
+	counts := []struct {
+		min, max       string
+		minVal, maxVal *int64
+	}{
+		{"minLength", "maxLength", s.MinLength, s.MaxLength},
+		{"minItems", "maxItems", s.MinItems, s.MaxItems},
+		{"minProperties", "maxProperties", s.MinProperties, s.MaxProperties},
+	}
+	for _, n := range counts {
+		if n.minVal != nil && n.maxVal != nil && *n.minVal > *n.maxVal {
+			l.report(ptr, n.min, "%s %d is greater than %s %d", n.min, *n.minVal, n.max, *n.maxVal)
+		}
+	}
+	if s.Items != nil && s.Items.CombinedArray != nil && s.MinItems != nil && isFalse(s.AdditionalItems) && *s.MinItems > int64(len(*s.Items.CombinedArray)) {
+		l.report(ptr, "minItems", "minItems %d is greater than the %d items allowed", *s.MinItems, len(*s.Items.CombinedArray))
+	}
+}
+
+func ratOf(v *jsm07.IntegerOrFloat) *big.Rat {
+	if v.Integer != nil {
+		return new(big.Rat).SetInt64(*v.Integer)
+	}
+	if v.Float != nil {
+		return new(big.Rat).SetFloat64(*v.Float)
+	}
+	return nil
+}
+
+func isFalse(c *jsm07.Combined) bool {
+	return c != nil && c.Schema == nil && c.Boolean != nil && !*c.Boolean
+}
+
+func checkPatterns(l *linter, ptr string, s *jsm07.Schema) {
+	if s.Pattern != nil {
+		if _, err := regexp.Compile(*s.Pattern); err != nil {
+			l.report(ptr, "pattern", "invalid pattern %q: %v", *s.Pattern, regexpError(err))
+		}
+	}
+	for _, pattern := range s.OrderedKeys("patternProperties") {
+		if _, err := regexp.Compile(pattern); err != nil {
+			l.report(appendPointer(appendPointer(ptr, "patternProperties"), pattern), "", "invalid pattern %q: %v", pattern, regexpError(err))
+		}
+	}
+}
+
+// regexpError returns the reason of a regexp error, without the pattern
+// it repeats.
+func regexpError(err error) string {
+	if e, ok := err.(*syntax.Error); ok {
+		return string(e.Code)
+	}
+	return err.Error()
+}
+
+func checkRef(l *linter, ptr string, s *jsm07.Schema) {
+	if s.Ref == nil || !strings.HasPrefix(*s.Ref, "#") {
+		return
+	}
+	if _, err := l.root.Resolve(*s.Ref); err != nil {
+		l.report(ptr, "$ref", "$ref %q points to no schema", *s.Ref)
+	}
+}
+
+// checkUnused reports the definitions not reachable by local references
+// from the schema outside definitions. A schema holding only
+// definitions is a library whose definitions are all used.
+func checkUnused(l *linter) {
+	root := l.root
+	if len(root.Definitions) == 0 {
+		return
+	}
+	trimmed := *root
+	trimmed.Definitions = nil
+	if isEmpty(&trimmed) {
+		return
+	}
+
+	used := make(map[string]bool)
+	var queue []*jsm07.Combined
+	mark := func(_ jsm07.JSONPointer, s *jsm07.Schema) error {
+		if s.Ref == nil || !strings.HasPrefix(*s.Ref, "#/definitions/") {
+			return nil
+		}
+		key := strings.SplitN(strings.TrimPrefix(*s.Ref, "#/definitions/"), "/", 2)[0]
+		key = strings.ReplaceAll(strings.ReplaceAll(key, "~1", "/"), "~0", "~")
+		if c, ok := root.Definitions[key]; ok && !used[key] {
+			used[key] = true
+			queue = append(queue, c)
+		}
+		return nil
+	}
+	jsm07.Walk(&trimmed, mark)
+	for len(queue) > 0 {
+		c := queue[0]
+		queue = queue[1:]
+		jsm07.Walk(c.Schema, mark)
+	}
+
+	for _, k := range root.OrderedKeys("definitions") {
+		if !used[k] {
+			l.report(appendPointer("/definitions", k), "", "definition %q is not used", k)
+		}
+	}
+}
+
+// isEmpty reports whether s has no keywords that describe a value.
+func isEmpty(s *jsm07.Schema) bool {
+	bs, err := json.Marshal(s)
+	if err != nil {
+		return false
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(bs, &m); err != nil {
+		return false
+	}
+	for k, v := range m {
+		switch k {
+		case "$id", "$schema", "$comment", "title", "description", "examples":
+		default:
+			if v != nil {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+func checkDefault(l *linter, ptr string, s *jsm07.Schema) {
+	if s.Default == nil {
+		return
+	}
+	var instance interface{}
+	if err := json.Unmarshal(*s.Default, &instance); err != nil {
+		return
+	}
+	// validate against the schema in place, keeping references to the
+	// definitions of the root
+	copied := *s
+	copied.Default = nil
+	wrapper := &jsm07.Schema{Definitions: l.root.Definitions, AllOf: []*jsm07.Combined{jsm07.NewCombinedWithSchema(&copied)}}
+	err := wrapper.Validate(instance)
+	if errs, ok := err.(jsm07.ValidationErrors); ok {
+		l.report(ptr, "default", "default %s is invalid: %v", compact(*s.Default), errs[0])
+	}
+}
+
+func compact(raw json.RawMessage) string {
+	var v interface{}
+	if err := json.Unmarshal(raw, &v); err != nil {
+		return string(raw)
+	}
+	bs, err := json.Marshal(v)
+	if err != nil {
+		return string(raw)
+	}
+	return string(bs)
+}
+
+// keywordTypes maps the keywords that apply to one type to that type.
+var keywordTypes = map[string]string{
+	"minimum": "number", "maximum": "number", "exclusiveMinimum": "number", "exclusiveMaximum": "number", "multipleOf": "number",
+	"minLength": "string", "maxLength": "string", "pattern": "string",
+	"items": "array", "additionalItems": "array", "minItems": "array", "maxItems": "array", "uniqueItems": "array", "contains": "array",
+	"properties": "object", "patternProperties": "object", "additionalProperties": "object", "required": "object",
+	"minProperties": "object", "maxProperties": "object", "propertyNames": "object", "dependencies": "object",
+}
+
+func checkType(l *linter, ptr string, s *jsm07.Schema) {
+	if s.Type == nil {
+		return
+	}
+	types := make(map[string]bool)
+	if s.Type.String != nil {
+		types[*s.Type.String] = true
+	} else if s.Type.StringArray != nil {
+		for _, t := range *s.Type.StringArray {
+			types[t] = true
+		}
+	}
+
+	for _, k := range presentKeywords(s) {
+		if t, ok := keywordTypes[k]; ok && !types[t] && !(t == "number" && types["integer"]) {
+			l.report(ptr, k, "%s applies to type %s, not %s", k, t, typeText(s.Type))
+		}
+	}
+
+	check := func(keyword string, raw []byte) {
+		var v interface{}
+		if json.Unmarshal(raw, &v) != nil {
+			return
+		}
+		if t := valueType(v); !types[t] && !(t == "integer" && types["number"]) {
+			l.report(ptr, keyword, "%s value %s is not of type %s", keyword, compact(raw), typeText(s.Type))
+		}
+	}
+	if s.Const != nil {
+		check("const", *s.Const)
+	}
+	for _, e := range s.Enumeration {
+		if bs, err := json.Marshal(&e); err == nil {
+			check("enum", bs)
+		}
+	}
+}
+
+// presentKeywords returns the keywords set in s, sorted.
+func presentKeywords(s *jsm07.Schema) []string {
+	bs, err := json.Marshal(s)
+	if err != nil {
+		return nil
+	}
+	var m map[string]interface{}
+	if err := json.Unmarshal(bs, &m); err != nil {
+		return nil
+	}
+	var keys []string
+	for k, v := range m {
+		if v != nil {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func valueType(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case float64:
+		if t == float64(int64(t)) {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	default:
+	}
+	return "object"
+}
+
+func typeText(t *jsm07.StringOrStringArray) string {
+	if t.String != nil {
+		return *t.String
+	}
+	if t.StringArray != nil {
+		return "[" + strings.Join(*t.StringArray, ", ") + "]"
+	}
+	return "[]"
+}
+
+func pointerText(ptr string) string {
+	if ptr == "" {
+		return "/"
+	}
+	return ptr
+}
@@ -0,0 +1,176 @@
+package lint
+
+import (
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/genelet/hclschema/jsm07"
+)
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		name     string
+		schema   string
+		problems []string
+	}{
+		{"clean", `{"type": "object", "required": ["a"], "properties": {"a": {"type": "string", "default": "x"}}}`, nil},
+		{"required", `{"required": ["a", "b", "x-c"], "properties": {"a": {}}, "patternProperties": {"^x-": {}}}`, []string{
+			`/: required property "b" is not defined in properties (required-undefined)`,
+		}},
+		{"required in allOf", `{"required": ["a", "b"], "properties": {}, "allOf": [{"$ref": "#/definitions/base"}], "definitions": {"base": {"properties": {"a": {}}}}}`, []string{
+			`/: required property "b" is not defined in properties (required-undefined)`,
+		}},
+		{"bounds", `{"minimum": 5, "maximum": 1, "minLength": 3, "maxLength": 2, "exclusiveMinimum": 1, "exclusiveMaximum": 1}`, []string{
+			"/: minimum 5 is greater than maximum 1 (bounds-order)",
+			"/: exclusiveMinimum 1 leaves no value below exclusiveMaximum 1 (bounds-order)",
+			"/: minimum 5 is greater than exclusiveMaximum 1 (bounds-order)",
+			"/: exclusiveMinimum 1 leaves no value below maximum 1 (bounds-order)",
+			"/: minLength 3 is greater than maxLength 2 (bounds-order)",
+		}},
+		{"pattern", `{"properties": {"a": {"type": "string", "pattern": "a(b"}}, "patternProperties": {"[": {}}}`, []string{
+			`/patternProperties/[: invalid pattern "[": missing closing ] (invalid-pattern)`,
+			`/properties/a: invalid pattern "a(b": missing closing ) (invalid-pattern)`,
+		}},
+		{"ref", `{"properties": {"a": {"$ref": "#/definitions/missing"}, "b": {"$ref": "other.json"}}}`, []string{
+			`/properties/a: $ref "#/definitions/missing" points to no schema (dangling-ref)`,
+		}},
+		{"unused", `{"$ref": "#/definitions/a", "definitions": {"a": {"items": {"$ref": "#/definitions/b"}}, "b": {}, "c": {"$ref": "#/definitions/c"}}}`, []string{
+			`/definitions/c: definition "c" is not used (unused-definition)`,
+		}},
+		{"library", `{"definitions": {"a": {}, "b": {}}}`, nil},
+		{"default", `{"properties": {"a": {"$ref": "#/definitions/port", "default": 0}, "b": {"type": "integer", "minimum": 1, "default": 0}}, "definitions": {"port": {"minimum": 1}}}`, []string{
+			"/properties/a: default 0 is invalid: /: minimum: 0 is less than 1 (invalid-default)",
+			"/properties/b: default 0 is invalid: /: minimum: 0 is less than 1 (invalid-default)",
+		}},
+		{"type", `{"type": "string", "minimum": 1, "items": {}, "enum": ["a", 1]}`, []string{
+			"/: items applies to type array, not string (type-mismatch)",
+			"/: minimum applies to type number, not string (type-mismatch)",
+			"/: enum value 1 is not of type string (type-mismatch)",
+		}},
+		{"integer", `{"type": ["integer", "null"], "maximum": 3, "const": 2}`, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schema := new(jsm07.Schema)
+			if err := json.Unmarshal([]byte(tt.schema), schema); err != nil {
+				t.Fatalf("Failed to unmarshal schema: %v", err)
+			}
+			problems, err := Lint(schema, nil)
+			if err != nil {
+				t.Fatalf("Failed to lint: %v", err)
+			}
+			var got []string
+			for _, p := range problems {
+				got = append(got, p.String())
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.problems, "\n") {
+				t.Errorf("Expected\n%s\ngot\n%s", strings.Join(tt.problems, "\n"), strings.Join(got, "\n"))
+			}
+		})
+	}
+}
+
+func TestLintOptions(t *testing.T) {
+	schema := new(jsm07.Schema)
+	if err := json.Unmarshal([]byte(`{"type": "string", "minLength": 3, "maxLength": 2, "minimum": 1}`), schema); err != nil {
+		t.Fatalf("Failed to unmarshal schema: %v", err)
+	}
+
+	tests := []struct {
+		name  string
+		opts  *Options
+		rules []string
+	}{
+		{"all", nil, []string{"bounds-order", "type-mismatch"}},
+		{"enabled", &Options{Rules: []string{"type-mismatch"}}, []string{"type-mismatch"}},
+		{"disabled", &Options{Disable: []string{"type-mismatch"}}, []string{"bounds-order"}},
+		{"none", &Options{Rules: []string{}}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			problems, err := Lint(schema, tt.opts)
+			if err != nil {
+				t.Fatalf("Failed to lint: %v", err)
+			}
+			var got []string
+			for _, p := range problems {
+				got = append(got, p.Rule)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.rules, ",") {
+				t.Errorf("Expected rules %v, got %v", tt.rules, got)
+			}
+		})
+	}
+
+	if _, err := Lint(schema, &Options{Disable: []string{"no-such-rule"}}); err == nil || !strings.Contains(err.Error(), `unknown rule "no-such-rule"`) {
+		t.Errorf("Expected an unknown rule error, got %v", err)
+	}
+}
+
+func TestLintHCL(t *testing.T) {
+	data := `type = "object"
+required = ["name", "port"]
+
+properties "name" {
+  type = "string"
+  pattern = "a(b"
+}
+
+properties "tags" {
+  type = "array"
+  minItems = 3
+  maxItems = 1
+}
+
+definitions "unused" {
+  type = "string"
+}
+
+allOf {
+  _ref = "#/definitions/missing"
+}
+`
+	problems, diags := LintHCL([]byte(data), &Options{Filename: "server.hcl"})
+	if diags.HasErrors() {
+		t.Fatalf("Failed to parse: %v", diags)
+	}
+	expected := []string{
+		`server.hcl:2,1-28: /: required property "port" is not defined in properties (required-undefined)`,
+		`server.hcl:6,3-18: /properties/name: invalid pattern "a(b": missing closing ) (invalid-pattern)`,
+		`server.hcl:11,3-15: /properties/tags: minItems 3 is greater than maxItems 1 (bounds-order)`,
+		`server.hcl:20,3-33: /allOf/0: $ref "#/definitions/missing" points to no schema (dangling-ref)`,
+		`server.hcl:15,1-21: /definitions/unused: definition "unused" is not used (unused-definition)`,
+	}
+	var got []string
+	for _, p := range problems {
+		got = append(got, p.String())
+	}
+	if strings.Join(got, "\n") != strings.Join(expected, "\n") {
+		t.Errorf("Expected\n%s\ngot\n%s", strings.Join(expected, "\n"), strings.Join(got, "\n"))
+	}
+
+	if d := problems[0].Diagnostic(); d.Subject == nil || d.Subject.Start.Line != 2 {
+		t.Errorf("Expected a diagnostic on line 2, got %v", d)
+	}
+}
+
+func TestLintMCP(t *testing.T) {
+	bs, err := os.ReadFile("../jsm07/samples/mcp.json")
+	if err != nil {
+		t.Fatalf("Failed to read mcp.json: %v", err)
+	}
+	schema := new(jsm07.Schema)
+	if err := json.Unmarshal(bs, schema); err != nil {
+		t.Fatalf("Failed to unmarshal mcp.json: %v", err)
+	}
+	problems, err := Lint(schema, nil)
+	if err != nil {
+		t.Fatalf("Failed to lint: %v", err)
+	}
+	for _, p := range problems {
+		t.Errorf("Unexpected problem: %v", p)
+	}
+}
@@ -0,0 +1,125 @@
+package lint
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// ranges maps the JSON Pointers of the schemas and keywords of an HCL
+// file to where they are written.
+type ranges map[string]hcl.Range
+
+// locate returns the ranges of the schemas in data, which is known to
+// parse.
+func locate(data []byte, filename string) ranges {
+	r := make(ranges)
+	file, diags := hclsyntax.ParseConfig(data, filename, hcl.Pos{Line: 1, Column: 1})
+	if diags.HasErrors() {
+		return r
+	}
+	if body, ok := file.Body.(*hclsyntax.Body); ok {
+		r[""] = hcl.Range{Filename: filename, Start: body.SrcRange.Start, End: body.SrcRange.Start}
+		r.body("", body)
+	}
+	return r
+}
+
+// find returns the range of a keyword of the schema at ptr, or of the
+// schema if the keyword is not written in it, or of its nearest parent.
+func (self ranges) find(ptr, keyword string) *hcl.Range {
+	if keyword != "" {
+		if rng, ok := self[appendPointer(ptr, keyword)]; ok {
+			return &rng
+		}
+	}
+	for {
+		if rng, ok := self[ptr]; ok {
+			return &rng
+		}
+		i := strings.LastIndex(ptr, "/")
+		if i < 0 {
+			return nil
+		}
+		ptr = ptr[:i]
+	}
+}
+
+// keyword returns the JSON name of an HCL keyword.
+func keyword(name string) string {
+	if strings.HasPrefix(name, "_") {
+		return "$" + name[1:]
+	}
+	return name
+}
+
+func (self ranges) body(ptr string, body *hclsyntax.Body) {
+	for name, attr := range body.Attributes {
+		p := appendPointer(ptr, keyword(name))
+		self[p] = attr.SrcRange
+		if obj, ok := attr.Expr.(*hclsyntax.ObjectConsExpr); ok {
+			self.object(p, obj)
+		}
+	}
+
+	count := make(map[string]int)
+	for _, block := range body.Blocks {
+		count[block.Type]++
+	}
+	index := make(map[string]int)
+	for _, block := range body.Blocks {
+		p := appendPointer(ptr, keyword(block.Type))
+		switch {
+		case len(block.Labels) > 0:
+			p = appendPointer(p, block.Labels[0])
+		case block.Type == "allOf" || block.Type == "anyOf" || block.Type == "oneOf" || (block.Type == "items" && count["items"] > 1):
+			p = appendPointer(p, strconv.Itoa(index[block.Type]))
+			index[block.Type]++
+		default:
+		}
+		self[p] = block.DefRange()
+		self.body(p, block.Body)
+	}
+}
+
+// object records a schema written inline as an object expression.
+func (self ranges) object(ptr string, obj *hclsyntax.ObjectConsExpr) {
+	for _, item := range obj.Items {
+		key, diags := item.KeyExpr.Value(nil)
+		if diags.HasErrors() || key.IsNull() || key.Type() != cty.String {
+			continue
+		}
+		name := key.AsString()
+		p := appendPointer(ptr, keyword(name))
+		self[p] = hcl.RangeBetween(item.KeyExpr.Range(), item.ValueExpr.Range())
+		sub, ok := item.ValueExpr.(*hclsyntax.ObjectConsExpr)
+		if !ok {
+			continue
+		}
+		switch name {
+		case "properties", "patternProperties", "definitions", "dependencies":
+			for _, entry := range sub.Items {
+				k, diags := entry.KeyExpr.Value(nil)
+				if diags.HasErrors() || k.IsNull() || k.Type() != cty.String {
+					continue
+				}
+				q := appendPointer(p, k.AsString())
+				self[q] = hcl.RangeBetween(entry.KeyExpr.Range(), entry.ValueExpr.Range())
+				if x, ok := entry.ValueExpr.(*hclsyntax.ObjectConsExpr); ok {
+					self.object(q, x)
+				}
+			}
+		default:
+			self.object(p, sub)
+		}
+	}
+}
+
+// appendPointer appends one reference token to a JSON Pointer.
+func appendPointer(ptr, token string) string {
+	token = strings.ReplaceAll(token, "~", "~0")
+	return ptr + "/" + strings.ReplaceAll(token, "/", "~1")
+}